@@ -23,6 +23,7 @@ require (
 	github.com/urfave/cli/v3 v3.4.1
 	golang.org/x/image v0.28.0
 	golang.org/x/mod v0.27.0
+	golang.org/x/sys v0.32.0
 	golift.io/xtractr v0.2.2
 )
 
@@ -51,10 +52,10 @@ require (
 	github.com/hashicorp/errwrap v1.1.0 // indirect
 	github.com/hashicorp/go-multierror v1.1.1 // indirect
 	github.com/kdomanski/iso9660 v0.3.3 // indirect
-	github.com/klauspost/compress v1.16.3 // indirect
+	github.com/klauspost/compress v1.16.3
 	github.com/nwaples/rardecode v1.1.3 // indirect
 	github.com/pierrec/lz4/v4 v4.1.17 // indirect
-	github.com/ulikunitz/xz v0.5.11 // indirect
+	github.com/ulikunitz/xz v0.5.11
 	github.com/yorukot/ansichroma v0.1.0
 	go4.org v0.0.0-20230225012048-214862532bf5 // indirect
 )
@@ -79,6 +80,5 @@ require (
 	github.com/rwcarlsen/goexif v0.0.0-20190401172101-9e8deecbddbd
 	github.com/yusufpapurcu/wmi v1.2.4 // indirect
 	golang.org/x/sync v0.16.0 // indirect
-	golang.org/x/sys v0.32.0 // indirect
 	golang.org/x/text v0.28.0
 )
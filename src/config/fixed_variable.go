@@ -70,6 +70,12 @@ var (
 	// opened, before exiting
 	ChooserFile = ""
 
+	// Completed file operations, for undo and redo
+	JournalFile = filepath.Join(SuperFileStateDir, "journal.json")
+
+	// Finished processes of the process bar, one JSON object per line
+	HistoryFile = filepath.Join(SuperFileStateDir, "history.jsonl")
+
 	// Other state variables
 	FixHotkeys    = false
 	FixConfigFile = false
@@ -87,6 +93,14 @@ func SetChooserFile(path string) {
 	ChooserFile = path
 }
 
+func SetJournalFile(path string) {
+	JournalFile = path
+}
+
+func SetHistoryFile(path string) {
+	HistoryFile = path
+}
+
 func UpdateVarFromCliArgs(c *cli.Command) {
 	// Setting the config file path
 	configFileArg := c.String("config-file")
@@ -35,6 +35,7 @@ func InitIcon(nerdfont bool, directoryIconColor string) {
 		Music = ""
 		Templates = ""
 		PublicShare = ""
+		Trash = ""
 
 		// file operations
 		CompressFile = ""
@@ -42,6 +43,8 @@ func InitIcon(nerdfont bool, directoryIconColor string) {
 		Copy = ""
 		Cut = ""
 		Delete = ""
+		Rename = ""
+		Link = ""
 
 		// other
 		Cursor = ">"
@@ -51,6 +54,7 @@ func InitIcon(nerdfont bool, directoryIconColor string) {
 		Warn = ""
 		Done = ""
 		InOperation = ""
+		Queued = ""
 		Directory = ""
 		Search = ""
 		SortAsc = "^"
@@ -58,6 +62,8 @@ func InitIcon(nerdfont bool, directoryIconColor string) {
 		Terminal = ""
 		Pinned = ""
 		Disk = ""
+		Lock = ""
+		History = ""
 	}
 
 	if directoryIconColor == "" {
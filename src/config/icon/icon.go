@@ -19,6 +19,7 @@ var (
 	Music       = "♬"          // Printable Rune : "♬"
 	Templates   = "\U000f03e2" // Printable Rune : "󰏢"
 	PublicShare = "\uf0ac"     // Printable Rune : ""
+	Trash       = "\uf1f8"     // Printable Rune : ""
 
 	// file operations
 	CompressFile = "\U000f05c4" // Printable Rune : "󰗄"
@@ -26,6 +27,8 @@ var (
 	Copy         = "\U000f018f" // Printable Rune : "󰆏"
 	Cut          = "\U000f0190" // Printable Rune : "󰆐"
 	Delete       = "\U000f01b4" // Printable Rune : "󰆴"
+	Rename       = "\U000f0455" // Printable Rune : "󰑕"
+	Link         = "\uf0c1"     // Printable Rune : ""
 
 	// other
 	Cursor          = "\uf054"     // Printable Rune : ""
@@ -37,6 +40,7 @@ var (
 	Warn            = "\uf071"     // Printable Rune : ""
 	Done            = "\uf4a4"     // Printable Rune : ""
 	InOperation     = "\U000f0954" // Printable Rune : "󰥔"
+	Queued          = "\U000f051f" // Printable Rune : "󰔟"
 	Directory       = "\uf07b"     // Printable Rune : ""
 	Search          = "\ue68f"     // Printable Rune : ""
 	SortAsc         = "\uf0de"     // Printable Rune : ""
@@ -44,6 +48,8 @@ var (
 	Terminal        = "\ue795"     // Printable Rune : ""
 	Pinned          = "\U000f0403" // Printable Rune : "󰐃"
 	Disk            = "\U000f11f0" // Printable Rune : "󱇰"
+	Lock            = "\uf023"     // Printable Rune : ""
+	History         = "\U000f02da" // Printable Rune : "󰋚"
 
 )
 
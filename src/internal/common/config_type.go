@@ -78,6 +78,17 @@ type ConfigType struct {
 	SortOrderReversed      bool   `toml:"sort_order_reversed" comment:"\nDefault sort order (false: Ascending, true: Descending)."`
 	CaseSensitiveSort      bool   `toml:"case_sensitive_sort" comment:"\nCase sensitive sort by name (capital \"B\" comes before \"a\" if true)."`
 	ShellCloseOnSuccess    bool   `toml:"shell_close_on_success" comment:"\nWhether to close the shell on successful command execution."`
+	PasteConflictPolicy    string `toml:"paste_conflict_policy" comment:"\nWhat to do when a pasted or extracted item already exists in the destination. Values: \"ask\", \"overwrite\", \"skip\", \"rename\" (keep both), \"overwrite_if_newer\"."`
+	PreserveAttributes     bool   `toml:"preserve_attributes" comment:"\nWhether to preserve timestamps, permissions, ownership and extended attributes when copying."`
+	SymlinkPolicy          string `toml:"symlink_policy" comment:"\nHow to copy symlinks. Values: \"copy_link\" (copy the link itself), \"follow\" (copy what it points to), \"skip\"."`
+	CopyWorkers            int    `toml:"copy_workers" comment:"\nCount of files copied at the same time by a paste, from 1 to 64."`
+	OperationsPerDevice    int    `toml:"operations_per_device" comment:"\nCount of pastes, deletes, compressions and extractions that run at the same time on each device. The others wait in the queue, except moves that only rename items. 0 runs them all at once."`
+	PasteConfirmSizeMB     int    `toml:"paste_confirm_size_mb" comment:"\nSize in MiB above which a paste is confirmed before it starts. A paste that lacks free space, has unreadable files, or overwrites existing items as per paste_conflict_policy, is always confirmed. 0 never confirms a paste because of its size."`
+	FinishedProcessTTL     int    `toml:"finished_process_ttl" comment:"\nSeconds after which finished processes are removed from the process bar. They are kept in the operation history. 0 keeps them until they are cleared."`
+	NotifyOnCompletion     bool   `toml:"notify_on_completion" comment:"\nWhether to show a desktop notification via the terminal when a process finishes, if it ran for at least notify_min_duration seconds. Inside tmux, it needs tmux's allow-passthrough option."`
+	NotifyMinDuration      int    `toml:"notify_min_duration" comment:"\nSeconds a process has to run for, to be notified when it finishes."`
+	NotifyCommand          string `toml:"notify_command" comment:"\nCommand also run when a process is notified, with the process name and state as last arguments. It is split on spaces, quotes are not interpreted. (Leave blank to run none)."`
+	DefaultCompressFormat  string `toml:"default_compress_format" comment:"\nFormat of the archives created by compress_file. Values: \"zip\", \"tar\", \"tar.gz\", \"tar.bz2\" (needs the bzip2 command), \"tar.xz\", \"tar.zst\"."`
 	Debug                  bool   `toml:"debug" comment:"\nWhether to enable debug mode."`
 	// IgnoreMissingFields controls whether warnings about missing TOML fields are suppressed.
 	IgnoreMissingFields bool `toml:"ignore_missing_fields" comment:"\nWhether to ignore warnings about missing fields in the config file."`
@@ -135,15 +146,27 @@ type HotkeysType struct {
 
 	FilePanelItemCreate []string `toml:"file_panel_item_create" comment:"create file/directory and rename "`
 	FilePanelItemRename []string `toml:"file_panel_item_rename"`
+	BatchRename         []string `toml:"batch_rename"`
+	ChangePermissions   []string `toml:"change_permissions"`
 
 	CopyItems              []string `toml:"copy_items" comment:"file operate"`
 	PasteItems             []string `toml:"paste_items"`
+	PasteAsSymlink         []string `toml:"paste_as_symlink"`
+	PasteAsRelativeSymlink []string `toml:"paste_as_relative_symlink"`
+	PasteAsHardlink        []string `toml:"paste_as_hardlink"`
 	CutItems               []string `toml:"cut_items"`
 	DeleteItems            []string `toml:"delete_items"`
 	PermanentlyDeleteItems []string `toml:"permanently_delete_items"`
+	Undo                   []string `toml:"undo"`
+	Redo                   []string `toml:"redo"`
+	RestoreFromTrash       []string `toml:"restore_from_trash"`
+	EmptyTrash             []string `toml:"empty_trash"`
 
-	ExtractFile  []string `toml:"extract_file" comment:"compress and extract"`
-	CompressFile []string `toml:"compress_file"`
+	ExtractFile             []string `toml:"extract_file" comment:"compress and extract"`
+	ExtractFileHere         []string `toml:"extract_file_here"`
+	ExtractFileToOtherPanel []string `toml:"extract_file_to_other_panel"`
+	CompressFile            []string `toml:"compress_file"`
+	CompressFileWithFormat  []string `toml:"compress_file_with_format"`
 
 	OpenFileWithEditor             []string `toml:"open_file_with_editor" comment:"editor"`
 	OpenCurrentDirectoryWithEditor []string `toml:"open_current_directory_with_editor"`
@@ -156,6 +179,8 @@ type HotkeysType struct {
 	OpenSPFPrompt   []string `toml:"open_spf_prompt"`
 	OpenZoxide      []string `toml:"open_zoxide"`
 
+	OpenOperationHistory []string `toml:"open_operation_history"`
+
 	CopyPath []string `toml:"copy_path"`
 	CopyPWD  []string `toml:"copy_present_working_directory"`
 
@@ -170,4 +195,11 @@ type HotkeysType struct {
 	FilePanelSelectModeItemsSelectDown []string `toml:"file_panel_select_mode_items_select_down" comment:"=================================================================================================\nSelect mode hotkeys (can conflict with other modes, cannot conflict with global hotkeys)"`
 	FilePanelSelectModeItemsSelectUp   []string `toml:"file_panel_select_mode_items_select_up"`
 	FilePanelSelectAllItem             []string `toml:"file_panel_select_all_items"`
+
+	CancelProcess   []string `toml:"cancel_process" comment:"=================================================================================================\nProcess bar hotkeys (can conflict with other modes, cannot conflict with global hotkeys)"`
+	StartProcess    []string `toml:"start_process"`
+	MoveProcessUp   []string `toml:"move_process_up"`
+	MoveProcessDown []string `toml:"move_process_down"`
+
+	ClearFinishedProcesses []string `toml:"clear_finished_processes"`
 }
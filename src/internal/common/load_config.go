@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"slices"
 
 	"github.com/charmbracelet/x/ansi"
 	"github.com/pelletier/go-toml/v2"
@@ -65,6 +66,43 @@ func ValidateConfig(c *ConfigType) error {
 		return errors.New(LoadConfigError("default_sort_type"))
 	}
 
+	switch c.PasteConflictPolicy {
+	case PasteConflictAsk, PasteConflictOverwrite, PasteConflictSkip,
+		PasteConflictRename, PasteConflictOverwriteIfNewer:
+	default:
+		return errors.New(LoadConfigError("paste_conflict_policy"))
+	}
+
+	switch c.SymlinkPolicy {
+	case SymlinkCopyLink, SymlinkFollow, SymlinkSkip:
+	default:
+		return errors.New(LoadConfigError("symlink_policy"))
+	}
+
+	if !slices.Contains(CompressFormats, c.DefaultCompressFormat) {
+		return errors.New(LoadConfigError("default_compress_format"))
+	}
+
+	if c.CopyWorkers < 1 || c.CopyWorkers > MaxCopyWorkers {
+		return errors.New(LoadConfigError("copy_workers"))
+	}
+
+	if c.OperationsPerDevice < 0 {
+		return errors.New(LoadConfigError("operations_per_device"))
+	}
+
+	if c.PasteConfirmSizeMB < 0 {
+		return errors.New(LoadConfigError("paste_confirm_size_mb"))
+	}
+
+	if c.FinishedProcessTTL < 0 {
+		return errors.New(LoadConfigError("finished_process_ttl"))
+	}
+
+	if c.NotifyMinDuration < 0 {
+		return errors.New(LoadConfigError("notify_min_duration"))
+	}
+
 	if ansi.StringWidth(c.BorderTop) != 1 {
 		return errors.New(LoadConfigError("border_top"))
 	}
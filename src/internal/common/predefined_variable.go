@@ -19,6 +19,69 @@ const TrashWarnTitle = "Are you sure you want to move this to trash can"
 const TrashWarnContent = "This operation will move file or directory to trash can."
 const PermanentDeleteWarnTitle = "Are you sure you want to completely delete"
 const PermanentDeleteWarnContent = "This operation cannot be undone and your data will be completely lost."
+const EmptyTrashWarnTitle = "Are you sure you want to empty the trash can"
+
+const PasteConflictWarnTitle = "An item with this name already exists"
+
+const PasteAsLinkFailedTitle = "Cannot paste as link"
+
+const PermissionsFailedTitle = "Cannot change permissions"
+
+const CompressFailedTitle = "Cannot compress items"
+const ExtractFailedTitle = "Cannot extract archive"
+const ExtractNoOtherPanelContent = "Open another file panel to extract into its directory"
+const ArchiveReadOnlyTitle = "Archives are read-only"
+const ArchiveReadOnlyContent = "Copy items out of the archive, or extract it, to change them"
+const ArchiveWrongPasswordText = "Wrong password, try again"
+const ArchiveOpenFailedTitle = "Cannot open archive"
+
+const PasteFailedTitle = "Cannot paste items"
+const PasteMixedArchiveContent = "Items inside an archive can't be pasted along with other items"
+const PastePendingContent = "Another paste is being checked, or waiting to be confirmed"
+
+const PastePreflightTitle = "Paste items"
+
+const BulkRenameTitle = "Rename items"
+const BulkRenameFailedTitle = "Cannot rename items"
+
+const UndoFailedTitle = "Cannot undo"
+const RedoFailedTitle = "Cannot redo"
+
+// Values accepted by the paste_conflict_policy config
+const (
+	PasteConflictAsk              = "ask"
+	PasteConflictOverwrite        = "overwrite"
+	PasteConflictSkip             = "skip"
+	PasteConflictRename           = "rename"
+	PasteConflictOverwriteIfNewer = "overwrite_if_newer"
+)
+
+// Maximum value of the copy_workers config
+const MaxCopyWorkers = 64
+
+// Values accepted by the symlink_policy config
+const (
+	SymlinkCopyLink = "copy_link"
+	SymlinkFollow   = "follow"
+	SymlinkSkip     = "skip"
+)
+
+// Values accepted by the default_compress_format config. They are also the
+// extensions of the created archives.
+const (
+	CompressFormatZip    = "zip"
+	CompressFormatTar    = "tar"
+	CompressFormatTarGz  = "tar.gz"
+	CompressFormatTarBz2 = "tar.bz2"
+	CompressFormatTarXz  = "tar.xz"
+	CompressFormatTarZst = "tar.zst"
+)
+
+// Formats in the order they are shown in the format chooser
+var CompressFormats = []string{
+	CompressFormatZip, CompressFormatTar, CompressFormatTarGz,
+	CompressFormatTarBz2, CompressFormatTarXz, CompressFormatTarZst,
+}
 
 const (
 	MinimumHeight = 24
@@ -39,16 +102,19 @@ var (
 
 	ProcessBarNoneText string
 
-	FilePanelTopDirectoryIcon string
-	FilePanelNoneText         string
+	FilePanelTopDirectoryIcon   string
+	FilePanelNoneText           string
+	FilePanelArchiveLoadingText string
 
 	FilePreviewNoContentText           string
 	FilePreviewNoFileInfoText          string
 	FilePreviewUnsupportedFormatText   string
 	FilePreviewUnsupportedFileMode     string
 	FilePreviewDirectoryUnreadableText string
+	FilePreviewTooLargeText            string
 	FilePreviewEmptyText               string
 	FilePreviewError                   string
+	FilePreviewPasswordNeededText      string
 
 	CheckboxChecked        string
 	CheckboxCheckedFocused string
@@ -92,6 +158,7 @@ func LoadPrerenderedVariables() {
 
 	FilePanelTopDirectoryIcon = FilePanelTopDirectoryIconStyle.Render(" " + icon.Directory + icon.Space)
 	FilePanelNoneText = FilePanelStyle.Render(" " + icon.Error + icon.Space + "No such file or directory")
+	FilePanelArchiveLoadingText = FilePanelStyle.Render(" " + icon.InOperation + icon.Space + "Reading archive...")
 
 	// TODO : This "---" being appended before and after should be done via a function
 	FilePreviewNoContentText = "\n--- " + icon.Error + icon.Space + "No content to preview" + icon.Space + "---"
@@ -99,7 +166,10 @@ func LoadPrerenderedVariables() {
 	FilePreviewUnsupportedFormatText = "\n--- " + icon.Error + icon.Space + "Unsupported formats" + icon.Space + "---"
 	FilePreviewUnsupportedFileMode = "\n--- " + icon.Error + icon.Space + "Unsupported File Mode" + icon.Space + "---"
 	FilePreviewDirectoryUnreadableText = "\n--- " + icon.Error + icon.Space + "Cannot read directory" + icon.Space + "---"
+	FilePreviewTooLargeText = "\n--- " + icon.Error + icon.Space + "File is too large to preview" + icon.Space + "---"
 	FilePreviewError = "\n--- " + icon.Error + icon.Space + "Error" + icon.Space + "---"
+	FilePreviewPasswordNeededText = "\n--- " + icon.Error + icon.Space + "Extract it with its password to preview it" +
+		icon.Space + "---"
 	FilePreviewEmptyText = "\n--- Empty ---"
 
 	CheckboxChecked = FilePanelSelectBoxStyle.
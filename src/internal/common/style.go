@@ -37,6 +37,7 @@ var (
 	FilePanelTopPathStyle          lipgloss.Style
 	FilePanelItemSelectedStyle     lipgloss.Style
 	FilePanelSelectBoxStyle        lipgloss.Style
+	FilePanelHintStyle             lipgloss.Style
 )
 
 var (
@@ -193,6 +194,7 @@ func LoadThemeConfig() { //nolint: funlen // Variable initialization
 	FilePanelItemSelectedStyle = lipgloss.NewStyle().Foreground(filePanelItemSelectedFGColor).
 		Background(filePanelItemSelectedBGColor)
 	FilePanelSelectBoxStyle = lipgloss.NewStyle().Background(FilePanelBGColor)
+	FilePanelHintStyle = lipgloss.NewStyle().Foreground(hintColor).Background(FilePanelBGColor)
 
 	// Sidebar Special Style
 	SidebarDividerStyle = lipgloss.NewStyle().Foreground(sidebarDividerColor).Background(SidebarBGColor)
@@ -1,5 +1,10 @@
 package common
 
+import (
+	"fmt"
+	"os"
+)
+
 // Placeholder inteface for now, might later move 'model' type to commons and have
 // and add an execute(model) function to this
 type ModelAction interface {
@@ -44,3 +49,93 @@ type OpenPanelAction struct {
 func (o OpenPanelAction) String() string {
 	return "OpenPanelAction at " + o.Location
 }
+
+// Renames Sources[i] to Destinations[i], all at once
+type BatchRenameAction struct {
+	Sources      []string
+	Destinations []string
+}
+
+func (b BatchRenameAction) String() string {
+	return fmt.Sprintf("BatchRenameAction for %d items", len(b.Sources))
+}
+
+// Changes the mode, owner or group of Items, and of their contents if Recursive
+type ChangePermissionsAction struct {
+	Items []string
+	// Mode is only changed if SetMode is true
+	SetMode bool
+	Mode    os.FileMode
+	// -1 keeps the current owner or group
+	UID       int
+	GID       int
+	Recursive bool
+	// Changes are only applied to directories, or only to files
+	DirsOnly  bool
+	FilesOnly bool
+}
+
+func (c ChangePermissionsAction) String() string {
+	return fmt.Sprintf("ChangePermissionsAction for %d items, recursive %v", len(c.Items), c.Recursive)
+}
+
+// Creates the archive Target of Format, containing Items
+type CreateArchiveAction struct {
+	Items  []string
+	Target string
+	Format string
+	// From 1 to 9, 0 for the default level of the format
+	Level     int
+	StoreOnly bool
+	// Glob patterns of the items that are left out
+	Excludes []string
+}
+
+func (c CreateArchiveAction) String() string {
+	return fmt.Sprintf("CreateArchiveAction for %d items to %s", len(c.Items), c.Target)
+}
+
+// Extracts Archive, an encrypted archive, with Password
+type ExtractArchiveAction struct {
+	Archive  string
+	Password string
+}
+
+// The password is left out, as actions are logged
+func (e ExtractArchiveAction) String() string {
+	return "ExtractArchiveAction for " + e.Archive
+}
+
+// How the items of a failed operation are run again
+type RetryMode int
+
+const (
+	// Only the items that failed
+	RetryFailed RetryMode = iota
+	// The items that failed, and the ones the operation didn't get to
+	RetryRemaining
+	// Only the items the operation didn't get to, without stopping on failures
+	RetrySkipErrors
+)
+
+func (r RetryMode) String() string {
+	switch r {
+	case RetryFailed:
+		return "retry failed"
+	case RetryRemaining:
+		return "retry all remaining"
+	case RetrySkipErrors:
+		return "skip errors and continue"
+	}
+	return "unknown"
+}
+
+// Runs again the items of the failed operation of process ProcessID
+type RetryOperationAction struct {
+	ProcessID string
+	Mode      RetryMode
+}
+
+func (r RetryOperationAction) String() string {
+	return fmt.Sprintf("RetryOperationAction for process %s, %s", r.ProcessID, r.Mode)
+}
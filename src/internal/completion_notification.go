@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+	"github.com/yorukot/superfile/src/internal/utils"
+)
+
+const (
+	completionNotificationTitle = "superfile"
+	// The notification command shouldn't run forever
+	notifyCommandTimeout = 10 * time.Second
+)
+
+// getCompletionNotificationCmd returns the command telling the user that the process p
+// finished, if it ran long enough for them to have switched to something else. The
+// notification is written to out, the output of the program.
+func getCompletionNotificationCmd(p processbar.Process, out io.Writer) tea.Cmd {
+	minDuration := time.Duration(common.Config.NotifyMinDuration) * time.Second
+	if !common.Config.NotifyOnCompletion || !shouldNotifyCompletion(p, minDuration) {
+		return nil
+	}
+	name := notificationText(p.Name)
+	slog.Debug("Notifying process completion", "name", name, "state", p.State)
+
+	// The terminal ignores escape sequences it doesn't know, so both are sent. The renderer
+	// writes each frame in a single write, and writes to a file don't interleave, so a single
+	// write lands between two frames without suspending the program.
+	seq := completionNotificationSeq(name+": "+p.State.String(), os.Getenv("TMUX") != "")
+	cmds := []tea.Cmd{func() tea.Msg {
+		if _, err := io.WriteString(out, seq); err != nil {
+			slog.Error("Could not write the completion notification", "error", err)
+		}
+		return nil
+	}}
+	// Arguments are split on whitespace, quotes are not interpreted
+	if command := strings.Fields(common.Config.NotifyCommand); len(command) > 0 {
+		cmds = append(cmds, func() tea.Msg {
+			runNotifyCommand(command, name, p.State.String())
+			return nil
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+func shouldNotifyCompletion(p processbar.Process, minDuration time.Duration) bool {
+	// The user cancelled it, so they already know
+	if p.State == processbar.Cancelled {
+		return false
+	}
+	return p.DoneTime.Sub(p.StartTime) >= minDuration
+}
+
+// completionNotificationSeq returns the OSC 9 and OSC 777 sequences showing a desktop
+// notification with body. Inside tmux, they are wrapped so that tmux passes them
+// to the terminal, which needs tmux's allow-passthrough option.
+func completionNotificationSeq(body string, inTmux bool) string {
+	seqs := []string{
+		"\x1b]9;" + completionNotificationTitle + ": " + body + "\x07",
+		"\x1b]777;notify;" + completionNotificationTitle + ";" + body + "\x07",
+	}
+	if inTmux {
+		for i, seq := range seqs {
+			seqs[i] = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+		}
+	}
+	return strings.Join(seqs, "")
+}
+
+// notificationText removes the characters of s that can't be in a notification. Control
+// characters would end the escape sequence, and the nerd font icons of process names
+// are likely not in the font of the notification.
+func notificationText(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || unicode.Is(unicode.Co, r) {
+			return -1
+		}
+		return r
+	}, s)
+	return strings.TrimSpace(s)
+}
+
+func runNotifyCommand(command []string, name string, state string) {
+	//nolint:gocritic // appendAssign: intentionally creating a new slice
+	args := append(command[1:], name, state)
+	retCode, output, err := utils.ExecuteCommand(notifyCommandTimeout, "", command[0], args...)
+	if err != nil || retCode != 0 {
+		slog.Error("Notification command failed", "command", command, "retCode", retCode,
+			"output", output, "error", err)
+	}
+}
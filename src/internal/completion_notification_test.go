@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yorukot/superfile/src/config/icon"
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+)
+
+func TestCompletionNotification(t *testing.T) {
+	start := time.Now()
+	t.Run("Only long and not cancelled processes are notified", func(t *testing.T) {
+		testdata := []struct {
+			name     string
+			state    processbar.ProcessState
+			duration time.Duration
+			expected bool
+		}{
+			{"Long successful process", processbar.Successful, 20 * time.Second, true},
+			{"Long failed process", processbar.Failed, 10 * time.Second, true},
+			{"Short process", processbar.Successful, 9 * time.Second, false},
+			{"Cancelled process", processbar.Cancelled, time.Minute, false},
+		}
+		for _, tt := range testdata {
+			t.Run(tt.name, func(t *testing.T) {
+				p := processbar.Process{State: tt.state, StartTime: start, DoneTime: start.Add(tt.duration)}
+				assert.Equal(t, tt.expected, shouldNotifyCompletion(p, 10*time.Second))
+			})
+		}
+	})
+
+	t.Run("Escape sequences", func(t *testing.T) {
+		assert.Equal(t, "\x1b]9;superfile: a.txt: successful\x07\x1b]777;notify;superfile;a.txt: successful\x07",
+			completionNotificationSeq("a.txt: successful", false))
+		assert.Equal(t, "\x1bPtmux;\x1b\x1b]9;superfile: a\x07\x1b\\\x1bPtmux;\x1b\x1b]777;notify;superfile;a\x07\x1b\\",
+			completionNotificationSeq("a", true))
+	})
+
+	t.Run("Notified through the program", func(t *testing.T) {
+		enabled := common.Config.NotifyOnCompletion
+		t.Cleanup(func() { common.Config.NotifyOnCompletion = enabled })
+		p := processbar.Process{Name: "a", State: processbar.Successful, StartTime: start,
+			DoneTime: start.Add(time.Hour)}
+
+		common.Config.NotifyOnCompletion = false
+		assert.Nil(t, getCompletionNotificationCmd(p, &bytes.Buffer{}))
+		common.Config.NotifyOnCompletion = true
+
+		var out bytes.Buffer
+		cmd := getCompletionNotificationCmd(p, &out)
+		require.NotNil(t, cmd)
+		// Any message, like the one of tea.Exec, would suspend the program or change the model
+		msg := cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			for _, c := range batch {
+				assert.Nil(t, c())
+			}
+		} else {
+			assert.Nil(t, msg)
+		}
+		assert.Equal(t, completionNotificationSeq("a: successful", os.Getenv("TMUX") != ""), out.String())
+	})
+
+	t.Run("Icons and control characters are removed", func(t *testing.T) {
+		assert.Equal(t, "ab.txt", notificationText("\U000f018f"+icon.Space+"a\x07b.txt\x1b"))
+	})
+}
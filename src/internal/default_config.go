@@ -1,11 +1,21 @@
 package internal
 
 import (
+	"os"
 	"path/filepath"
 
 	zoxidelib "github.com/lazysegtree/go-zoxide"
 
+	variable "github.com/yorukot/superfile/src/config"
+	"github.com/yorukot/superfile/src/internal/history"
+	"github.com/yorukot/superfile/src/internal/journal"
+	"github.com/yorukot/superfile/src/internal/ui/batchrename"
+	"github.com/yorukot/superfile/src/internal/ui/compress"
+	"github.com/yorukot/superfile/src/internal/ui/failures"
+	historyui "github.com/yorukot/superfile/src/internal/ui/history"
 	"github.com/yorukot/superfile/src/internal/ui/metadata"
+	"github.com/yorukot/superfile/src/internal/ui/password"
+	"github.com/yorukot/superfile/src/internal/ui/permissions"
 	"github.com/yorukot/superfile/src/internal/ui/processbar"
 	"github.com/yorukot/superfile/src/internal/ui/sidebar"
 
@@ -21,10 +31,16 @@ import (
 // Something like `RendererConfig` struct for `Renderer` struct in ui/renderer package
 func defaultModelConfig(toggleDotFile, toggleFooter, firstUse bool,
 	firstFilePanelDirs []string, zClient *zoxidelib.Client) *model {
+	opHistory := history.New(variable.HistoryFile)
+	processBarModel := processbar.New()
+	processBarModel.AddFinishHook(func(p processbar.Process) {
+		opHistory.Record(newHistoryEntry(p))
+	})
 	return &model{
 		filePanelFocusIndex: 0,
 		focusPanel:          nonePanelFocus,
-		processBarModel:     processbar.New(),
+		processBarModel:     processBarModel,
+		pasteConflictChan:   make(chan pasteConflictReq),
 		sidebarModel:        sidebar.New(),
 		fileMetaData:        metadata.New(),
 		fileModel: fileModel{
@@ -32,15 +48,29 @@ func defaultModelConfig(toggleDotFile, toggleFooter, firstUse bool,
 			filePreview: preview.New(),
 			width:       10,
 		},
-		helpMenu:       newHelpMenuModal(),
-		promptModal:    prompt.DefaultModel(prompt.PromptMinHeight, prompt.PromptMinWidth),
-		zoxideModal:    zoxideui.DefaultModel(zoxideui.ZoxideMinHeight, zoxideui.ZoxideMinWidth, zClient),
-		zClient:        zClient,
-		modelQuitState: notQuitting,
-		toggleDotFile:  toggleDotFile,
-		toggleFooter:   toggleFooter,
-		firstUse:       firstUse,
-		hasTrash:       common.InitTrash(),
+		helpMenu:    newHelpMenuModal(),
+		promptModal: prompt.DefaultModel(prompt.PromptMinHeight, prompt.PromptMinWidth),
+		zoxideModal: zoxideui.DefaultModel(zoxideui.ZoxideMinHeight, zoxideui.ZoxideMinWidth, zClient),
+		batchRenameModal: batchrename.DefaultModel(batchrename.BatchRenameMinHeight,
+			batchrename.BatchRenameMinWidth),
+		permissionsModal: permissions.DefaultModel(permissions.PermissionsMinHeight,
+			permissions.PermissionsMinWidth),
+		passwordModal:    password.DefaultModel(password.PasswordMinWidth),
+		compressModal:    compress.DefaultModel(compress.CompressMinWidth, countCompressSource),
+		historyModal:     historyui.DefaultModel(historyui.HistoryMinHeight, historyui.HistoryMinWidth),
+		failuresModal:    failures.DefaultModel(failures.FailuresMinHeight, failures.FailuresMinWidth),
+		archivePasswords: make(map[string]string),
+		archiveLoads:     make(map[string]archiveLoad),
+		failedOps:        make(map[string]failedOperation),
+		zClient:          zClient,
+		output:           os.Stdout,
+		modelQuitState:   notQuitting,
+		toggleDotFile:    toggleDotFile,
+		toggleFooter:     toggleFooter,
+		firstUse:         firstUse,
+		hasTrash:         common.InitTrash(),
+		journal:          journal.New(variable.JournalFile),
+		history:          opHistory,
 	}
 }
 
@@ -156,6 +186,11 @@ func getHelpMenuData() []helpMenuModalData { //nolint: funlen // This should be
 			description:    "Focus on the metadata panel",
 			hotkeyWorkType: globalType,
 		},
+		{
+			hotkey:         common.Hotkeys.OpenOperationHistory,
+			description:    "Open the history of file operations",
+			hotkeyWorkType: globalType,
+		},
 		{
 			subTitle: "Panel movement",
 		},
@@ -222,6 +257,21 @@ func getHelpMenuData() []helpMenuModalData { //nolint: funlen // This should be
 			description:    "Rename file or folder",
 			hotkeyWorkType: globalType,
 		},
+		{
+			hotkey:         common.Hotkeys.FilePanelItemRename,
+			description:    "Rename selected items with the editor (select mode)",
+			hotkeyWorkType: globalType,
+		},
+		{
+			hotkey:         common.Hotkeys.BatchRename,
+			description:    "Rename selected items with a pattern",
+			hotkeyWorkType: globalType,
+		},
+		{
+			hotkey:         common.Hotkeys.ChangePermissions,
+			description:    "Change permissions and ownership of selected items",
+			hotkeyWorkType: globalType,
+		},
 		{
 			hotkey:         common.Hotkeys.CopyItems,
 			description:    "Copy selected items to the clipboard",
@@ -237,6 +287,21 @@ func getHelpMenuData() []helpMenuModalData { //nolint: funlen // This should be
 			description:    "Paste clipboard items into the current file panel",
 			hotkeyWorkType: globalType,
 		},
+		{
+			hotkey:         common.Hotkeys.PasteAsSymlink,
+			description:    "Paste clipboard items as symlinks",
+			hotkeyWorkType: globalType,
+		},
+		{
+			hotkey:         common.Hotkeys.PasteAsRelativeSymlink,
+			description:    "Paste clipboard items as relative symlinks",
+			hotkeyWorkType: globalType,
+		},
+		{
+			hotkey:         common.Hotkeys.PasteAsHardlink,
+			description:    "Paste clipboard items as hardlinks",
+			hotkeyWorkType: globalType,
+		},
 		{
 			hotkey:         common.Hotkeys.DeleteItems,
 			description:    "Delete selected items",
@@ -247,6 +312,26 @@ func getHelpMenuData() []helpMenuModalData { //nolint: funlen // This should be
 			description:    "Permanently delete selected items",
 			hotkeyWorkType: globalType,
 		},
+		{
+			hotkey:         common.Hotkeys.Undo,
+			description:    "Undo the last file operation",
+			hotkeyWorkType: globalType,
+		},
+		{
+			hotkey:         common.Hotkeys.Redo,
+			description:    "Redo the last undone file operation",
+			hotkeyWorkType: globalType,
+		},
+		{
+			hotkey:         common.Hotkeys.RestoreFromTrash,
+			description:    "Restore selected items of the trash",
+			hotkeyWorkType: globalType,
+		},
+		{
+			hotkey:         common.Hotkeys.EmptyTrash,
+			description:    "Empty the trash",
+			hotkeyWorkType: globalType,
+		},
 		{
 			hotkey:         common.Hotkeys.CopyPath,
 			description:    "Copy current file or directory path",
@@ -254,12 +339,27 @@ func getHelpMenuData() []helpMenuModalData { //nolint: funlen // This should be
 		},
 		{
 			hotkey:         common.Hotkeys.ExtractFile,
-			description:    "Extract compressed file",
+			description:    "Extract selected archives next to them",
+			hotkeyWorkType: normalType,
+		},
+		{
+			hotkey:         common.Hotkeys.ExtractFileHere,
+			description:    "Extract selected archives directly into the current directory",
+			hotkeyWorkType: normalType,
+		},
+		{
+			hotkey:         common.Hotkeys.ExtractFileToOtherPanel,
+			description:    "Extract selected archives into the directory of the next file panel",
 			hotkeyWorkType: normalType,
 		},
 		{
 			hotkey:         common.Hotkeys.CompressFile,
-			description:    "Zip file or folder to .zip file",
+			description:    "Compress file or folder to an archive of the default format",
+			hotkeyWorkType: normalType,
+		},
+		{
+			hotkey:         common.Hotkeys.CompressFileWithFormat,
+			description:    "Create an archive, choosing its name, destination, format and level",
 			hotkeyWorkType: normalType,
 		},
 		{
@@ -272,6 +372,39 @@ func getHelpMenuData() []helpMenuModalData { //nolint: funlen // This should be
 			description:    "Open current directory with default editor",
 			hotkeyWorkType: normalType,
 		},
+		{
+			subTitle: "Process bar",
+		},
+		{
+			hotkey:         common.Hotkeys.CancelProcess,
+			description:    "Cancel the process under the cursor",
+			hotkeyWorkType: globalType,
+		},
+		{
+			hotkey:         common.Hotkeys.StartProcess,
+			description:    "Start the queued process under the cursor now",
+			hotkeyWorkType: globalType,
+		},
+		{
+			hotkey:         common.Hotkeys.MoveProcessUp,
+			description:    "Move the queued process under the cursor up the queue",
+			hotkeyWorkType: globalType,
+		},
+		{
+			hotkey:         common.Hotkeys.MoveProcessDown,
+			description:    "Move the queued process under the cursor down the queue",
+			hotkeyWorkType: globalType,
+		},
+		{
+			hotkey:         common.Hotkeys.ClearFinishedProcesses,
+			description:    "Remove the finished processes from the process bar",
+			hotkeyWorkType: globalType,
+		},
+		{
+			hotkey:         common.Hotkeys.Confirm,
+			description:    "Show the failed items of the process under the cursor",
+			hotkeyWorkType: globalType,
+		},
 	}
 
 	return data
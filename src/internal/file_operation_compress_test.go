@@ -1,16 +1,28 @@
 package internal
 
 import (
+	"archive/tar"
 	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
 
+	"github.com/yorukot/superfile/src/internal/common"
 	"github.com/yorukot/superfile/src/internal/ui/processbar"
 	"github.com/yorukot/superfile/src/internal/utils"
 )
@@ -89,14 +101,14 @@ func TestZipSources(t *testing.T) {
 			}
 
 			targetZip := filepath.Join(tempDir, "test.zip")
-			err = zipSources(sources, targetZip, &processBar)
+			err = compressSources(sources, targetZip, common.CompressFormatZip, &processBar)
 
 			if tt.expectError {
-				require.Error(t, err, "zipSources should return error")
+				require.Error(t, err, "compressSources should return error")
 				return
 			}
 
-			require.NoError(t, err, "zipSources should not return error")
+			require.NoError(t, err, "compressSources should not return error")
 
 			zipReader, err := zip.OpenReader(targetZip)
 			require.NoError(t, err, "should be able to open ZIP file")
@@ -149,6 +161,136 @@ func TestZipSourcesInvalidTarget(t *testing.T) {
 	require.NoError(t, err, "should be able to create test file")
 
 	invalidTarget := "/invalid/path/test.zip"
-	err = zipSources([]string{testFile}, invalidTarget, &processBar)
-	require.Error(t, err, "zipSources should return error for invalid target")
+	err = compressSources([]string{testFile}, invalidTarget, common.CompressFormatZip, &processBar)
+	require.Error(t, err, "compressSources should return error for invalid target")
+}
+
+func TestCompressSourcesTar(t *testing.T) {
+	processBar := processbar.New()
+	processBar.ListenForChannelUpdates()
+	t.Cleanup(processBar.SendStopListeningMsgBlocking)
+	srcDir := filepath.Join(t.TempDir(), "src")
+	utils.SetupDirectories(t, srcDir, filepath.Join(srcDir, "sub"))
+	utils.SetupFilesWithData(t, []byte("Content of script"), filepath.Join(srcDir, "script.sh"))
+	utils.SetupFilesWithData(t, []byte("Content of file"), filepath.Join(srcDir, "sub", "file.txt"))
+	require.NoError(t, os.Chmod(filepath.Join(srcDir, "script.sh"), 0o750))
+
+	tests := []struct {
+		format    string
+		newReader func(r io.Reader) (io.Reader, error)
+	}{
+		{common.CompressFormatTar, func(r io.Reader) (io.Reader, error) { return r, nil }},
+		{common.CompressFormatTarGz, func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{common.CompressFormatTarBz2, func(r io.Reader) (io.Reader, error) { return bzip2.NewReader(r), nil }},
+		{common.CompressFormatTarXz, func(r io.Reader) (io.Reader, error) { return xz.NewReader(r) }},
+		{common.CompressFormatTarZst, func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if tt.format == common.CompressFormatTarBz2 {
+				if _, err := exec.LookPath(bzip2Command); err != nil {
+					t.Skip("bzip2 is not installed")
+				}
+			}
+			target, err := getArchivePath(t.TempDir(), "src", tt.format)
+			require.NoError(t, err)
+			require.Equal(t, "src."+tt.format, filepath.Base(target))
+			require.NoError(t, compressSources([]string{srcDir}, target, tt.format, &processBar))
+
+			f, err := os.Open(target)
+			require.NoError(t, err)
+			defer f.Close()
+			r, err := tt.newReader(f)
+			require.NoError(t, err)
+			tarReader := tar.NewReader(r)
+			found := map[string]string{}
+			for {
+				header, err := tarReader.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				require.NoError(t, err)
+				data, err := io.ReadAll(tarReader)
+				require.NoError(t, err)
+				found[header.Name] = string(data)
+				if header.Name == "src/script.sh" && runtime.GOOS != utils.OsWindows {
+					assert.Equal(t, int64(0o750), header.Mode&0o777, "permissions should be kept")
+				}
+			}
+			assert.Equal(t, map[string]string{
+				"src/":             "",
+				"src/script.sh":    "Content of script",
+				"src/sub/":         "",
+				"src/sub/file.txt": "Content of file",
+			}, found)
+		})
+	}
+}
+
+func TestCompressSourcesWithOptions(t *testing.T) {
+	processBar := processbar.New()
+	processBar.ListenForChannelUpdates()
+	t.Cleanup(processBar.SendStopListeningMsgBlocking)
+	srcDir := filepath.Join(t.TempDir(), "src")
+	utils.SetupDirectories(t, filepath.Join(srcDir, ".git"), filepath.Join(srcDir, "web", "node_modules"))
+	utils.SetupFilesWithData(t, []byte(strings.Repeat("Content of file", 100)), filepath.Join(srcDir, "file.txt"))
+	utils.SetupFilesWithData(t, []byte("Content of lib"), filepath.Join(srcDir, "web", "node_modules", "lib.js"))
+	utils.SetupFilesWithData(t, []byte("Content of head"), filepath.Join(srcDir, ".git", "HEAD"))
+	excludes := []string{".git", "node_modules"}
+
+	tests := []struct {
+		format    string
+		level     int
+		storeOnly bool
+	}{
+		{format: common.CompressFormatZip, storeOnly: true},
+		{format: common.CompressFormatZip, level: 1},
+		{format: common.CompressFormatTarGz, level: 9},
+		{format: common.CompressFormatTarBz2, level: 1},
+		{format: common.CompressFormatTarXz, level: 3},
+		{format: common.CompressFormatTarZst, level: 9},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s level %d", tt.format, tt.level), func(t *testing.T) {
+			if tt.format == common.CompressFormatTarBz2 {
+				if _, err := exec.LookPath(bzip2Command); err != nil {
+					t.Skip("bzip2 is not installed")
+				}
+			}
+			target := filepath.Join(t.TempDir(), "src."+tt.format)
+			opts := compressOptions{format: tt.format, level: tt.level, storeOnly: tt.storeOnly, excludes: excludes}
+			require.NoError(t, compressSourcesWithOptions([]string{srcDir}, target, opts, &processBar))
+
+			entries, err := listArchive(context.Background(), target, tt.format)
+			require.NoError(t, err)
+			names := make([]string, 0, len(entries))
+			for _, entry := range entries {
+				names = append(names, strings.TrimSuffix(entry.name, "/"))
+			}
+			assert.ElementsMatch(t, []string{"src", "src/file.txt", "src/web"}, names,
+				"excluded items and their content should be left out")
+
+			if tt.storeOnly {
+				zipReader, err := zip.OpenReader(target)
+				require.NoError(t, err)
+				defer zipReader.Close()
+				for _, f := range zipReader.File {
+					assert.Equal(t, zip.Store, f.Method, "%s should not be compressed", f.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestGetArchivePath(t *testing.T) {
+	curTestDir := t.TempDir()
+	utils.SetupFiles(t, filepath.Join(curTestDir, "docs.tar.gz"), filepath.Join(curTestDir, "docs(1).tar.gz"))
+
+	path, err := getArchivePath(curTestDir, "docs", common.CompressFormatTarGz)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(curTestDir, "docs(2).tar.gz"), path)
+
+	path, err = getArchivePath(curTestDir, "notes.txt", common.CompressFormatZip)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(curTestDir, "notes.zip"), path)
 }
@@ -1,6 +1,8 @@
 package internal
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -8,18 +10,19 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"syscall"
 
-	"github.com/yorukot/superfile/src/internal/ui/processbar"
+	"github.com/yorukot/superfile/src/internal/common"
 	"github.com/yorukot/superfile/src/internal/utils"
 
 	trash_win "github.com/hymkor/trash-go"
-	"github.com/rkoesters/xdg/trash"
 
 	variable "github.com/yorukot/superfile/src/config"
 	"github.com/yorukot/superfile/src/config/icon"
 )
 
-// isSamePartition checks if two paths are on the same filesystem partition
+// isSamePartition reports whether path1 and path2 are on the same device, so that path1
+// can be renamed to path2. path2 doesn't need to exist yet.
 func isSamePartition(path1, path2 string) (bool, error) {
 	// Get the absolute path to handle relative paths
 	absPath1, err := filepath.Abs(path1)
@@ -39,8 +42,20 @@ func isSamePartition(path1, path2 string) (bool, error) {
 		return drive1 == drive2, nil
 	}
 
-	// For Unix-like systems, we use the same path to check the root partition
-	return filepath.VolumeName(absPath1) == filepath.VolumeName(absPath2), nil
+	// Paths can't tell mount points apart, unlike the device IDs
+	return sameDevice(absPath1, absPath2)
+}
+
+// renameOnSameDevice renames src to dst, that should be on the same device. It returns
+// false without error if the rename fails with EXDEV, for the caller to copy src instead.
+// Two bind mounts of the same filesystem share a device ID, but renames across them fail.
+func renameOnSameDevice(src, dst string) (bool, error) {
+	err := os.Rename(src, dst)
+	if errors.Is(err, syscall.EXDEV) {
+		slog.Debug("Cannot rename across mount points, copying instead", "src", src, "dst", dst)
+		return false, nil
+	}
+	return err == nil, err
 }
 
 // getDriveLetter extracts the drive letter from a Windows path
@@ -50,8 +65,10 @@ func getDriveLetter(path string) string {
 	return strings.ToUpper(string(path[0]))
 }
 
-// moveElement moves a file or directory efficiently
-func moveElement(src, dst string) error {
+// moveElement moves a file or directory efficiently. If it has to be copied, the
+// strategies used are counted in stats, and onProgress is called with the count of
+// bytes copied as the copy progresses, if they are not nil.
+func moveElement(ctx context.Context, src, dst string, stats copyStats, onProgress func(int64)) error {
 	// Check if source and destination are on the same partition
 	sameDev, err := isSamePartition(src, dst)
 	if err != nil {
@@ -60,42 +77,59 @@ func moveElement(src, dst string) error {
 
 	// If on the same partition, attempt to rename (which will use the same inode)
 	if sameDev {
-		if err = os.Rename(src, dst); err == nil {
+		moved, err := renameOnSameDevice(src, dst)
+		if err != nil {
+			return fmt.Errorf("failed to rename: %w", err)
+		}
+		if moved {
 			return nil
 		}
-		// If rename fails, fall back to copy+delete
 	}
 
-	// If on different partitions or rename failed, fall back to copy+delete
-	err = copyElement(src, dst)
-	if err != nil {
+	// If on different partitions, fall back to copy+delete
+	_, statErr := os.Lstat(dst)
+	dstExisted := statErr == nil
+	err = copyElement(ctx, src, dst, stats, onProgress)
+	if errors.Is(err, context.Canceled) && !dstExisted {
+		// The source is still intact, so the partial copy is removed
+		if rmErr := os.RemoveAll(dst); rmErr != nil {
+			slog.Error("Cannot remove partial copy of cancelled move", "dst", dst, "error", rmErr)
+		}
+		return err
+	}
+	if err != nil && !isAttrPreserveError(err) {
 		return fmt.Errorf("failed to copy: %w", err)
 	}
 
-	err = os.RemoveAll(src)
-	if err != nil {
-		return fmt.Errorf("failed to remove source after copy: %w", err)
+	if rmErr := os.RemoveAll(src); rmErr != nil {
+		return fmt.Errorf("failed to remove source after copy: %w", rmErr)
 	}
 
-	return nil
+	// Attributes that could not be preserved, if any
+	return err
 }
 
-// copyElement handles copying of both files and directories
-func copyElement(src, dst string) error {
-	srcInfo, err := os.Stat(src)
+// copyElement handles copying of both files and directories. Symlinks are copied
+// as links, as it is only used for moves. See copyFile for onProgress.
+func copyElement(ctx context.Context, src, dst string, stats copyStats, onProgress func(int64)) error {
+	srcInfo, err := os.Lstat(src)
 	if err != nil {
 		return fmt.Errorf("failed to stat source: %w", err)
 	}
 
 	if srcInfo.IsDir() {
-		return copyDir(src, dst, srcInfo)
+		return copyDir(ctx, src, dst, srcInfo, stats, onProgress)
 	}
-	return copyFile(src, dst, srcInfo)
+	strategy, err := copyFile(ctx, src, dst, srcInfo, onProgress)
+	stats.add(strategy)
+	return err
 }
 
 // copyDir recursively copies a directory
-func copyDir(src, dst string, srcInfo os.FileInfo) error {
-	err := os.MkdirAll(dst, srcInfo.Mode())
+func copyDir(ctx context.Context, src, dst string, srcInfo os.FileInfo, stats copyStats,
+	onProgress func(int64)) error {
+	preserveAttrs := common.Config.PreserveAttributes
+	err := os.MkdirAll(dst, dirCreateMode(srcInfo, preserveAttrs))
 	if err != nil {
 		return fmt.Errorf("failed to create destination directory: %w", err)
 	}
@@ -105,7 +139,11 @@ func copyDir(src, dst string, srcInfo os.FileInfo) error {
 		return fmt.Errorf("failed to read source directory: %w", err)
 	}
 
+	attrErr := &attrPreserveError{}
 	for _, entry := range entries {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
 		srcPath := filepath.Join(src, entry.Name())
 		dstPath := filepath.Join(dst, entry.Name())
 
@@ -115,139 +153,296 @@ func copyDir(src, dst string, srcInfo os.FileInfo) error {
 		}
 
 		if entryInfo.IsDir() {
-			err = copyDir(srcPath, dstPath, entryInfo)
+			err = copyDir(ctx, srcPath, dstPath, entryInfo, stats, onProgress)
 		} else {
-			err = copyFile(srcPath, dstPath, entryInfo)
+			var strategy copyStrategy
+			strategy, err = copyFile(ctx, srcPath, dstPath, entryInfo, onProgress)
+			stats.add(strategy)
 		}
-		if err != nil {
+		if err != nil && !isAttrPreserveError(err) {
 			return err
 		}
+		attrErr.add(err)
 	}
-	return nil
+
+	// After the children, as writing them updates the modification time of dst
+	if preserveAttrs {
+		attrErr.add(preserveAttributes(src, dst, srcInfo))
+	}
+	return attrErr.orNil()
 }
 
-// copyFile copies a single file
-func copyFile(src, dst string, srcInfo os.FileInfo) error {
+// copyFile copies a single file. If ctx is cancelled midway, the partially
+// written dst is removed. onProgress, if not nil, is called with the count
+// of bytes copied as the copy progresses. If attributes are to be preserved
+// and some of them could not be, an *attrPreserveError is returned.
+// If srcInfo is the info of a symlink, the link itself is copied.
+// The strategy used to copy the content is returned, even if there is an error.
+func copyFile(ctx context.Context, src, dst string, srcInfo os.FileInfo,
+	onProgress func(int64)) (copyStrategy, error) {
+	// An existing dst is overwritten, but never through a symlink
+	if dstInfo, err := os.Lstat(dst); err == nil && !dstInfo.IsDir() &&
+		(isSymlink(srcInfo) || isSymlink(dstInfo)) {
+		if err = os.Remove(dst); err != nil {
+			return "", fmt.Errorf("failed to remove existing destination: %w", err)
+		}
+	}
+	if isSymlink(srcInfo) {
+		if err := copySymlink(src, dst); err != nil {
+			return "", err
+		}
+		if common.Config.PreserveAttributes {
+			return copyStrategySymlink, preserveAttributes(src, dst, srcInfo)
+		}
+		return copyStrategySymlink, nil
+	}
+
 	srcFile, err := os.Open(src)
 	if err != nil {
-		return fmt.Errorf("failed to open source file: %w", err)
+		return "", fmt.Errorf("failed to open source file: %w", err)
 	}
 	defer srcFile.Close()
 
 	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, srcInfo.Mode())
 	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
+		return "", fmt.Errorf("failed to create destination file: %w", err)
 	}
 	defer dstFile.Close()
 
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
-		return fmt.Errorf("failed to copy file contents: %w", err)
+	strategy, err := copyContents(ctx, dstFile, srcFile, srcInfo, onProgress)
+	if err != nil {
+		if ctx.Err() != nil {
+			dstFile.Close()
+			if rmErr := os.Remove(dst); rmErr != nil {
+				slog.Error("Failed to remove partially copied file", "path", dst, "error", rmErr)
+			}
+		}
+		return strategy, fmt.Errorf("failed to copy file contents: %w", err)
 	}
-	return nil
+	if common.Config.PreserveAttributes {
+		return strategy, preserveAttributes(src, dst, srcInfo)
+	}
+	return strategy, nil
+}
+
+// copyReader fails reads once ctx is cancelled, so that long copies can be stopped.
+// It also reports the count of bytes read to onRead, if set.
+type copyReader struct {
+	ctx    context.Context
+	r      io.Reader
+	onRead func(int64)
 }
 
-func moveToTrash(src string) error {
+func (r *copyReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := r.r.Read(p)
+	if n > 0 && r.onRead != nil {
+		r.onRead(int64(n))
+	}
+	return n, err
+}
+
+// moveToTrash moves src to the trash. It returns the location of src in the trash, so that
+// it can be restored, or an empty string if it is unknown.
+func moveToTrash(src string) (string, error) {
 	var err error
+	var trashedPath string
 	switch runtime.GOOS {
 	case utils.OsDarwin:
-		err = moveElement(src, filepath.Join(variable.DarwinTrashDirectory, filepath.Base(src)))
+		trashedPath, err = renameIfDuplicate(filepath.Join(variable.DarwinTrashDirectory, filepath.Base(src)))
+		if err == nil {
+			err = moveElement(context.Background(), src, trashedPath, nil, nil)
+		}
 	case utils.OsWindows:
 		err = trash_win.Throw(src)
 	default:
-		// TODO: We should consider moving away from this package. Its not well written.
-		// It uses package globals, It doesn't initializes trash directory, and we have to do it
-		// separately outside of the this package. There is not documentation about this
-		// It also uses deprecated libraries, and isn't well maintained.
-		err = trash.Trash(src)
+		trashedPath, err = moveToLinuxTrash(src)
 	}
 	if err != nil {
 		slog.Error("Error while deleting single item, in function to move file to trash can", "error", err)
+		return "", err
 	}
-	return err
+	return trashedPath, nil
 }
 
-// pasteDir handles directory copying with progress tracking
-func pasteDir(src, dst string, p *processbar.Process, cut bool, processBarModel *processbar.Model) error {
-	dst, err := renameIfDuplicate(dst)
-	if err != nil {
+// pasteDir handles directory copying with progress tracking.
+// Conflicts of src itself must already be resolved by the caller. If dst is an existing
+// directory, contents of src are merged into it, and each conflicting entry is resolved
+// via the resolver. Files are copied by up to common.Config.CopyWorkers goroutines.
+func pasteDir(ctx context.Context, src, dst string, cut bool, progress *pasteProgress,
+	resolver *pasteConflictResolver) error {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
-
 	// Check if we can do a fast move within the same partition
 	sameDev, err := isSamePartition(src, dst)
-	if err == nil && sameDev && cut {
+	if err != nil {
+		slog.Debug("Cannot check partitions, copying instead", "src", src, "dst", dst, "error", err)
+		sameDev = false
+	}
+	if sameDev && cut && !isExistingDir(dst) {
 		// For cut operations on same partition, try fast rename first
-		err = os.Rename(src, dst)
-		if err == nil {
-			return nil
+		var moved bool
+		if moved, err = renameOnSameDevice(src, dst); moved || err != nil {
+			return err
 		}
-		// If rename fails, fall back to manual copy
+		// The contents are on another mount point, and have to be copied
+		sameDev = false
 	}
 
-	err = filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+	// Destination of each walked directory. Needed because a directory could be pasted
+	// with a different name after conflict resolution.
+	dstDirs := map[string]string{src: dst}
+	// Directories whose attributes are restored once their contents are pasted
+	var pastedDirs []pastedDir
+	skipped := false
+	// Files are copied by the pool, while directories are created by the walk, before their contents
+	pool := newCopyPool(common.Config.CopyWorkers)
+	// Destinations of the copies submitted since the last flush of the pool
+	pendingDsts := map[string]struct{}{}
+	err = walkWithSymlinkPolicy(src, getSymlinkPolicy(cut), func(path string, info os.FileInfo) error {
+		if pool.failed() {
+			return filepath.SkipAll
+		}
+		err := ctx.Err()
 		if err != nil {
 			return err
 		}
 
-		relPath, err := filepath.Rel(src, path)
-		if err != nil {
-			return err
+		newPath := dst
+		if path != src {
+			var skip bool
+			itemDst := filepath.Join(dstDirs[filepath.Dir(path)], info.Name())
+			newPath, skip, err = resolver.resolve(ctx, path, itemDst)
+			if _, isPending := pendingDsts[newPath]; isPending && err == nil && !skip {
+				// A copy in progress is creating newPath. The conflict can only be seen once it is done.
+				pool.flush()
+				clear(pendingDsts)
+				newPath, skip, err = resolver.resolve(ctx, path, itemDst)
+			}
+			if err != nil {
+				return err
+			}
+			if skip {
+				skipped = true
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				progress.fileDone(info.Size())
+				return nil
+			}
+		}
+		if info.IsDir() {
+			dstDirs[path] = newPath
+			pastedDirs = append(pastedDirs, pastedDir{src: path, dst: newPath, info: info})
 		}
-		newPath := filepath.Join(dst, relPath)
-		return actualPasteOperation(info, path, newPath, cut, sameDev, p, processBarModel)
+		// Renames within the same partition are fast enough to not need the pool
+		if info.IsDir() || (cut && sameDev) {
+			return actualPasteOperation(ctx, info, path, newPath, cut, sameDev, progress)
+		}
+		pendingDsts[newPath] = struct{}{}
+		pool.submit(func() error {
+			return actualPasteOperation(ctx, info, path, newPath, cut, sameDev, progress)
+		})
+		return nil
 	})
-
-	if err != nil {
+	pool.fail(err)
+	if err = pool.wait(); err != nil {
 		return err
 	}
+	return finishDirPaste(src, cut, skipped, pastedDirs, progress)
+}
 
-	// If this was a cut operation and we had to do a manual copy, remove the source
-	if cut && !sameDev {
-		err = os.RemoveAll(src)
-		if err != nil {
-			return fmt.Errorf("failed to remove source after move: %w", err)
+// finishDirPaste restores the attributes of the pasted directories, and removes the
+// source directory of a cut once all its contents are pasted.
+func finishDirPaste(src string, cut bool, skipped bool, pastedDirs []pastedDir, progress *pasteProgress) error {
+	if common.Config.PreserveAttributes {
+		// Deepest first, as restoring a directory's mtime must happen after its children
+		for i := len(pastedDirs) - 1; i >= 0; i-- {
+			progress.addWarnings(preserveAttributes(pastedDirs[i].src, pastedDirs[i].dst, pastedDirs[i].info))
 		}
 	}
 
+	if !cut {
+		return nil
+	}
+	// Skipped items must stay in the source. Everything else is already moved.
+	if skipped {
+		removeEmptyDirs(src)
+		return nil
+	}
+	err := os.RemoveAll(src)
+	if err != nil {
+		return fmt.Errorf("failed to remove source after move: %w", err)
+	}
 	return nil
 }
 
-func actualPasteOperation(info os.FileInfo, path string, newPath string, cut bool, sameDev bool,
-	p *processbar.Process, processBarModel *processbar.Model) error {
+type pastedDir struct {
+	src  string
+	dst  string
+	info os.FileInfo
+}
+
+func actualPasteOperation(ctx context.Context, info os.FileInfo, path string, newPath string,
+	cut bool, sameDev bool, progress *pasteProgress) error {
 	var err error
 	if info.IsDir() {
-		// TODO - this is likely not needed because we did
-		// dst, err := renameIfDuplicate(dst) above
-		newPath, err = renameIfDuplicate(newPath)
-		if err != nil {
-			return err
-		}
-		err = os.MkdirAll(newPath, info.Mode())
-		return err
+		// newPath may already exist, in which case the contents are merged
+		return os.MkdirAll(newPath, dirCreateMode(info, common.Config.PreserveAttributes))
 	}
 
 	// File
-	p.Name = icon.GetCopyOrCutIcon(cut) + icon.Space + filepath.Base(path)
+	progress.setCurrentItem(icon.GetCopyOrCutIcon(cut) + icon.Space + filepath.Base(path))
+	// Bytes of the file reported so far
+	var copiedBytes int64
+	moved := false
 	if cut && sameDev {
-		err = os.Rename(path, newPath)
-	} else {
-		err = copyFile(path, newPath, info)
+		moved, err = renameOnSameDevice(path, newPath)
+	}
+	if err == nil && !moved {
+		var strategy copyStrategy
+		strategy, err = copyFile(ctx, path, newPath, info, func(n int64) {
+			copiedBytes += n
+			progress.addBytes(n)
+		})
+		progress.addStrategy(strategy)
+		if isAttrPreserveError(err) {
+			progress.addWarnings(err)
+			err = nil
+		}
+		if err == nil && cut {
+			err = os.Remove(path)
+		}
 	}
 
+	// The caller marks the process as failed or cancelled
 	if err != nil {
-		p.State = processbar.Failed
-		pSendErr := processBarModel.SendUpdateProcessMsg(*p, true)
-		if pSendErr != nil {
-			slog.Error("Error sending process update", "error", pSendErr)
-		}
+		progress.fileFailed(copiedBytes)
 		return err
 	}
 
-	p.Done++
-	processBarModel.TrySendingUpdateProcessMsg(*p)
+	progress.fileDone(info.Size() - copiedBytes)
 	return nil
 }
 
+// removeEmptyDirs removes root and all directories below it that are empty, deepest first.
+// Errors are ignored, as non empty directories are expected to fail.
+func removeEmptyDirs(root string) {
+	var dirs []string
+	_ = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	for i := len(dirs) - 1; i >= 0; i-- {
+		_ = os.Remove(dirs[i])
+	}
+}
+
 // isAncestor checks if dst is the same as src or a subdirectory of src.
 // It handles symlinks by resolving them and applies case-insensitive comparison on Windows.
 func isAncestor(src, dst string) bool {
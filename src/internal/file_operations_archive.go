@@ -0,0 +1,268 @@
+package internal
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+
+	"github.com/yorukot/superfile/src/internal/common"
+)
+
+// Maximum size of the target of a zip symlink entry, which is stored as its content
+const maxZipSymlinkSize = 4096
+
+var errUnsafeArchivePath = errors.New("archive entry would be extracted outside of the destination")
+
+// archiveEntry is an entry of an archive, read without its content
+type archiveEntry struct {
+	// Slash separated path of the entry in the archive, without trailing slash
+	name string
+	info os.FileInfo
+	// Target of a symlink, or path in the archive of the target of a hardlink
+	linkname string
+	hardlink bool
+}
+
+type archiveSuffix struct {
+	suffix string
+	format string
+}
+
+// Suffixes of the archives that superfile reads itself, with their format
+func archiveSuffixes() []archiveSuffix {
+	return []archiveSuffix{
+		{".zip", common.CompressFormatZip},
+		{".tar", common.CompressFormatTar},
+		{".tar.gz", common.CompressFormatTarGz},
+		{".tgz", common.CompressFormatTarGz},
+		{".tar.bz2", common.CompressFormatTarBz2},
+		{".tbz2", common.CompressFormatTarBz2},
+		{".tbz", common.CompressFormatTarBz2},
+		{".tar.xz", common.CompressFormatTarXz},
+		{".txz", common.CompressFormatTarXz},
+		{".tar.zst", common.CompressFormatTarZst},
+		{".tzst", common.CompressFormatTarZst},
+	}
+}
+
+// archiveFormatOf returns the format of the archive at path from its name, or an
+// empty string if it is not an archive that superfile reads itself
+func archiveFormatOf(path string) string {
+	name := strings.ToLower(filepath.Base(path))
+	for _, s := range archiveSuffixes() {
+		if strings.HasSuffix(name, s.suffix) {
+			return s.format
+		}
+	}
+	return ""
+}
+
+// walkArchive calls fn for each entry of the archive at path, in the order they are
+// stored. r reads the content of regular files, and is nil for other entries. Entries
+// that can't be extracted, like devices, are left out. Encrypted entries are decrypted
+// with password, and reading them fails if it is empty.
+func walkArchive(ctx context.Context, path string, format string, password string,
+	fn func(entry archiveEntry, r io.Reader) error) error {
+	if format == common.CompressFormatZip {
+		return walkZipArchive(ctx, path, password, fn)
+	}
+	return walkTarArchive(ctx, path, format, fn)
+}
+
+// listArchive returns the entries of the archive at path. Tar archives have no index,
+// so they are read entirely.
+func listArchive(ctx context.Context, path string, format string) ([]archiveEntry, error) {
+	var entries []archiveEntry
+	err := walkArchive(ctx, path, format, "", func(entry archiveEntry, _ io.Reader) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, err
+}
+
+func walkZipArchive(ctx context.Context, path string, password string,
+	fn func(entry archiveEntry, r io.Reader) error) error {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	for _, f := range reader.File {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		entry := archiveEntry{name: strings.TrimSuffix(f.Name, "/"), info: f.FileInfo()}
+		if err = walkZipEntry(ctx, f, entry, password, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkZipEntry(ctx context.Context, f *zip.File, entry archiveEntry, password string,
+	fn func(entry archiveEntry, r io.Reader) error) error {
+	mode := entry.info.Mode()
+	if mode.IsDir() || (!mode.IsRegular() && !isSymlink(entry.info)) {
+		return fn(entry, nil)
+	}
+	// Like with the zip command, the content of a symlink entry is its target
+	if isSymlink(entry.info) {
+		rc, err := openZipFile(f, password)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		target, err := io.ReadAll(io.LimitReader(rc, maxZipSymlinkSize))
+		if err != nil {
+			return err
+		}
+		entry.linkname = string(target)
+		return fn(entry, nil)
+	}
+	r := &zipEntryReader{open: func() (io.ReadCloser, error) {
+		return openZipFile(f, password)
+	}}
+	defer r.close()
+	return fn(entry, &copyReader{ctx: ctx, r: r})
+}
+
+// zipEntryReader opens the content of an entry on the first read, so that the entries
+// that are not read, like when listing the archive, are not decompressed or decrypted
+type zipEntryReader struct {
+	open func() (io.ReadCloser, error)
+	rc   io.ReadCloser
+	err  error
+}
+
+func (z *zipEntryReader) Read(p []byte) (int, error) {
+	if z.rc == nil && z.err == nil {
+		z.rc, z.err = z.open()
+	}
+	if z.err != nil {
+		return 0, z.err
+	}
+	return z.rc.Read(p)
+}
+
+func (z *zipEntryReader) close() {
+	if z.rc != nil {
+		z.rc.Close()
+	}
+}
+
+func walkTarArchive(ctx context.Context, path string, format string,
+	fn func(entry archiveEntry, r io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r, err := newTarDecompressor(&copyReader{ctx: ctx, r: f}, format)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	tarReader := tar.NewReader(r)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		entry := archiveEntry{
+			name:     strings.TrimSuffix(header.Name, "/"),
+			info:     header.FileInfo(),
+			linkname: header.Linkname,
+		}
+		// Next() already turns the entries of old archives into regular files and directories
+		switch header.Typeflag {
+		case tar.TypeReg:
+			err = fn(entry, tarReader)
+		case tar.TypeDir, tar.TypeSymlink, tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+			err = fn(entry, nil)
+		case tar.TypeLink:
+			entry.hardlink = true
+			err = fn(entry, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// newTarDecompressor returns the reader of the tar archive in a compressed archive of the
+// given format
+func newTarDecompressor(r io.Reader, format string) (io.ReadCloser, error) {
+	switch format {
+	case common.CompressFormatTar:
+		return io.NopCloser(r), nil
+	case common.CompressFormatTarGz:
+		return gzip.NewReader(r)
+	case common.CompressFormatTarBz2:
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	case common.CompressFormatTarXz:
+		xzReader, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(xzReader), nil
+	case common.CompressFormatTarZst:
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	}
+	return nil, fmt.Errorf("unsupported archive format %q", format)
+}
+
+// archiveEntryPath returns where the entry called name is extracted in dest. Names that
+// would end up outside of dest, like "../x" or "/etc/x", are refused.
+func archiveEntryPath(dest string, name string) (string, error) {
+	local := filepath.FromSlash(name)
+	if name == "" {
+		local = "."
+	}
+	if !filepath.IsLocal(local) {
+		return "", fmt.Errorf("%w: %s", errUnsafeArchivePath, name)
+	}
+	return filepath.Join(dest, local), nil
+}
+
+// checkInsideDest refuses path if it is outside of dest once its links are followed. The
+// links extracted from an archive could otherwise make later entries go through them.
+// Only the existing part of path is resolved, the rest doesn't contain links yet.
+func checkInsideDest(dest string, path string) error {
+	realDest, err := filepath.EvalSymlinks(dest)
+	if err != nil {
+		return err
+	}
+	existing, rest := path, ""
+	realPath, err := filepath.EvalSymlinks(existing)
+	for os.IsNotExist(err) && filepath.Dir(existing) != existing {
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = filepath.Dir(existing)
+		realPath, err = filepath.EvalSymlinks(existing)
+	}
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(realDest, filepath.Join(realPath, rest))
+	if err != nil || !filepath.IsLocal(rel) {
+		return fmt.Errorf("%w: %s", errUnsafeArchivePath, path)
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Count of archives whose listing is kept in memory
+const maxCachedArchives = 4
+
+var (
+	errArchiveEntryFound = errors.New("archive entry found")
+	errArchiveNotLoaded  = errors.New("the archive is not loaded yet")
+)
+
+// Listings of the archives that are browsed. Reading the listing of a compressed tar
+// means decompressing it entirely, so it is done in a tea.Cmd, with load, and only done
+// again if the archive changes. Everything else only uses the loaded listings.
+var archiveListings = &archiveListingCache{ //nolint: gochecknoglobals // Shared by the file panels and the preview
+	listings: make(map[string]*archiveListing),
+}
+
+type archiveListingCache struct {
+	mu       sync.Mutex
+	listings map[string]*archiveListing
+}
+
+// archiveListing is the directory tree of an archive. Directories that have no entry of
+// their own, like in zips created without them, are added.
+type archiveListing struct {
+	modTime  time.Time
+	size     int64
+	lastUsed time.Time
+	// Info of each entry, by its slash separated path in the archive
+	infos map[string]fs.FileInfo
+	// Names of the children of each directory, with "" for the root of the archive
+	children map[string][]string
+}
+
+// archiveDirInfo is the info of a directory of an archive that has no entry
+type archiveDirInfo struct {
+	name string
+}
+
+func (i archiveDirInfo) Name() string       { return i.name }
+func (i archiveDirInfo) Size() int64        { return 0 }
+func (i archiveDirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0o755 }
+func (i archiveDirInfo) ModTime() time.Time { return time.Time{} }
+func (i archiveDirInfo) IsDir() bool        { return true }
+func (i archiveDirInfo) Sys() any           { return nil }
+
+// get returns the listing of the archive at archivePath, or errArchiveNotLoaded if it
+// was not loaded, or if the archive was modified since
+func (c *archiveListingCache) get(archivePath string) (*archiveListing, error) {
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	listing, ok := c.listings[archivePath]
+	if !ok || !listing.modTime.Equal(info.ModTime()) || listing.size != info.Size() {
+		return nil, fmt.Errorf("%s: %w", archivePath, errArchiveNotLoaded)
+	}
+	listing.lastUsed = time.Now()
+	return listing, nil
+}
+
+// load reads the listing of the archive at archivePath, if it is not loaded yet
+func (c *archiveListingCache) load(ctx context.Context, archivePath string) error {
+	_, err := c.get(archivePath)
+	if !errors.Is(err, errArchiveNotLoaded) {
+		return err
+	}
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return err
+	}
+	// The lock isn't held while reading, the other archives can be browsed meanwhile
+	entries, err := listArchive(ctx, archivePath, archiveFormatOf(archivePath))
+	if err != nil {
+		return fmt.Errorf("cannot read archive %s: %w", archivePath, err)
+	}
+	listing := newArchiveListing(entries)
+	listing.modTime = info.ModTime()
+	listing.size = info.Size()
+	listing.lastUsed = time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listings[archivePath] = listing
+	c.evict()
+	return nil
+}
+
+// Remove the least recently used listings, if there are too many. c.mu must be held.
+func (c *archiveListingCache) evict() {
+	for len(c.listings) > maxCachedArchives {
+		var oldest string
+		for archivePath, listing := range c.listings {
+			if oldest == "" || listing.lastUsed.Before(c.listings[oldest].lastUsed) {
+				oldest = archivePath
+			}
+		}
+		delete(c.listings, oldest)
+	}
+}
+
+func newArchiveListing(entries []archiveEntry) *archiveListing {
+	l := &archiveListing{
+		infos:    make(map[string]fs.FileInfo, len(entries)),
+		children: map[string][]string{"": nil},
+	}
+	for _, entry := range entries {
+		name := path.Clean(entry.name)
+		// Entries that would not be extracted can't be browsed either
+		if name == "." || !filepath.IsLocal(filepath.FromSlash(name)) {
+			continue
+		}
+		parent := archiveParent(name)
+		l.addDir(parent)
+		if _, exists := l.infos[name]; !exists {
+			l.children[parent] = append(l.children[parent], path.Base(name))
+		}
+		l.infos[name] = entry.info
+		if _, exists := l.children[name]; entry.info.IsDir() && !exists {
+			l.children[name] = nil
+		}
+	}
+	return l
+}
+
+// addDir adds the directory dir and its parents, if they have no entry
+func (l *archiveListing) addDir(dir string) {
+	if _, exists := l.children[dir]; exists {
+		return
+	}
+	parent := archiveParent(dir)
+	l.addDir(parent)
+	l.infos[dir] = archiveDirInfo{name: path.Base(dir)}
+	l.children[parent] = append(l.children[parent], path.Base(dir))
+	l.children[dir] = nil
+}
+
+// archiveParent returns the parent directory of an entry of an archive, with "" for the root
+func archiveParent(name string) string {
+	parent := path.Dir(name)
+	if parent == "." {
+		return ""
+	}
+	return parent
+}
+
+// splitArchivePath splits a path inside an archive, like /a/b.zip/dir/file, into the path
+// of the archive and the slash separated path inside it, which is empty for the archive
+// itself. ok is false for paths that are not in an archive.
+func splitArchivePath(location string) (string, string, bool) {
+	for cur := location; ; {
+		info, err := os.Stat(cur)
+		if err == nil {
+			if !info.Mode().IsRegular() || archiveFormatOf(cur) == "" {
+				return "", "", false
+			}
+			inner, err := filepath.Rel(cur, location)
+			if err != nil {
+				return "", "", false
+			}
+			if inner == "." {
+				inner = ""
+			}
+			return cur, filepath.ToSlash(inner), true
+		}
+		parent := filepath.Dir(cur)
+		if parent == cur {
+			return "", "", false
+		}
+		cur = parent
+	}
+}
+
+// isInArchive reports whether location is an archive, or a path inside of one
+func isInArchive(location string) bool {
+	_, _, ok := splitArchivePath(location)
+	return ok
+}
+
+// isArchiveDir reports whether location is an archive, or a directory inside of one, that
+// can be browsed like a directory. Until the archive is loaded, any path inside of it
+// could be one.
+func isArchiveDir(location string) bool {
+	archivePath, inner, ok := splitArchivePath(location)
+	if !ok {
+		return false
+	}
+	listing, err := archiveListings.get(archivePath)
+	if errors.Is(err, errArchiveNotLoaded) {
+		return true
+	}
+	if err != nil {
+		return false
+	}
+	_, isDir := listing.children[inner]
+	return isDir
+}
+
+// isArchiveLoading reports whether location is inside an archive that is not loaded yet
+func isArchiveLoading(location string) bool {
+	archivePath, _, ok := splitArchivePath(location)
+	if !ok {
+		return false
+	}
+	_, err := archiveListings.get(archivePath)
+	return errors.Is(err, errArchiveNotLoaded)
+}
+
+// realDirectory returns the directory containing the archive if location is inside of one,
+// and location otherwise, for the uses that need a directory on disk
+func realDirectory(location string) string {
+	if archivePath, _, ok := splitArchivePath(location); ok {
+		return filepath.Dir(archivePath)
+	}
+	return location
+}
+
+// readDir is like os.ReadDir, but also reads the directories inside archives
+func readDir(location string) ([]os.DirEntry, error) {
+	archivePath, inner, ok := splitArchivePath(location)
+	if !ok {
+		return os.ReadDir(location)
+	}
+	listing, err := archiveListings.get(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	children, isDir := listing.children[inner]
+	if !isDir {
+		return nil, fmt.Errorf("%s is not a directory of the archive: %w", location, os.ErrNotExist)
+	}
+	res := make([]os.DirEntry, 0, len(children))
+	for _, name := range children {
+		res = append(res, fs.FileInfoToDirEntry(listing.infos[path.Join(inner, name)]))
+	}
+	slices.SortFunc(res, func(a, b os.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return res, nil
+}
+
+// addArchiveInfo sets the info of the elements of a directory inside an archive, for
+// their sizes and dates to be shown
+func addArchiveInfo(elements []element, location string) []element {
+	archivePath, inner, ok := splitArchivePath(location)
+	if !ok {
+		return elements
+	}
+	listing, err := archiveListings.get(archivePath)
+	if err != nil {
+		return elements
+	}
+	for i := range elements {
+		elements[i].archiveInfo = listing.infos[path.Join(inner, elements[i].name)]
+	}
+	return elements
+}
+
+// archiveSelection maps the names of the entries of an archive to the names they are
+// extracted as. ok is false for the entries that are not extracted.
+type archiveSelection func(name string) (string, bool)
+
+func selectAllEntries(name string) (string, bool) {
+	return name, true
+}
+
+// selectArchiveItems selects the items at the slash separated paths inner, along with
+// their content. They are extracted as if the destination was their parent directory.
+func selectArchiveItems(inner []string) archiveSelection {
+	return func(name string) (string, bool) {
+		name = path.Clean(name)
+		for _, item := range inner {
+			if name != item && !strings.HasPrefix(name, item+"/") {
+				continue
+			}
+			parent := archiveParent(item)
+			if parent == "" {
+				return name, true
+			}
+			return strings.TrimPrefix(name, parent+"/"), true
+		}
+		return "", false
+	}
+}
+
+// readArchiveEntry writes the content of the regular file at the slash separated path
+// inner of the archive to w, decrypting it with password if it is encrypted
+func readArchiveEntry(ctx context.Context, archivePath string, inner string, password string, w io.Writer) error {
+	format := archiveFormatOf(archivePath)
+	err := walkArchive(ctx, archivePath, format, password, func(entry archiveEntry, r io.Reader) error {
+		if path.Clean(entry.name) != inner || r == nil {
+			return nil
+		}
+		if _, err := io.Copy(w, r); err != nil {
+			return err
+		}
+		return errArchiveEntryFound
+	})
+	if errors.Is(err, errArchiveEntryFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%s is not a file of %s: %w", inner, archivePath, os.ErrNotExist)
+}
@@ -0,0 +1,250 @@
+package internal
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+	"github.com/yorukot/superfile/src/internal/utils"
+)
+
+// Zip created without directory entries, like by some archivers
+func setupZipWithoutDirs(t *testing.T, archive string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(archive)
+	require.NoError(t, err)
+	writer := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := writer.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	require.NoError(t, f.Close())
+}
+
+func dirEntryNames(entries []os.DirEntry) []string {
+	res := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		res = append(res, entry.Name())
+	}
+	return res
+}
+
+func TestReadDirInArchive(t *testing.T) {
+	curTestDir := t.TempDir()
+	archive := filepath.Join(curTestDir, "test.zip")
+	setupZipWithoutDirs(t, archive, map[string]string{
+		"a/b/file.txt": "0123456789",
+		"a/other.txt":  "01234",
+		"top.txt":      "",
+	})
+
+	t.Run("Not loaded", func(t *testing.T) {
+		assert.True(t, isArchiveLoading(archive))
+		_, err := readDir(archive)
+		require.ErrorIs(t, err, errArchiveNotLoaded)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		require.ErrorIs(t, archiveListings.load(ctx, archive), context.Canceled)
+		assert.True(t, isArchiveLoading(archive), "A cancelled load should leave the archive not loaded")
+	})
+	require.NoError(t, archiveListings.load(t.Context(), archive))
+	assert.False(t, isArchiveLoading(archive))
+
+	testdata := []struct {
+		name     string
+		location string
+		expected []string
+		isDir    bool
+	}{
+		{name: "Root", location: archive, expected: []string{"a", "top.txt"}, isDir: true},
+		{name: "Directory without entry", location: filepath.Join(archive, "a"),
+			expected: []string{"b", "other.txt"}, isDir: true},
+		{name: "Nested directory", location: filepath.Join(archive, "a", "b"), expected: []string{"file.txt"}, isDir: true},
+		{name: "File", location: filepath.Join(archive, "top.txt")},
+		{name: "Missing", location: filepath.Join(archive, "missing")},
+	}
+	for _, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.isDir, isArchiveDir(tt.location))
+			entries, err := readDir(tt.location)
+			if !tt.isDir {
+				require.ErrorIs(t, err, os.ErrNotExist)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, dirEntryNames(entries))
+		})
+	}
+
+	t.Run("Paths outside of archives", func(t *testing.T) {
+		_, _, ok := splitArchivePath(curTestDir)
+		assert.False(t, ok)
+		assert.False(t, isArchiveDir(curTestDir))
+		assert.Equal(t, curTestDir, realDirectory(curTestDir))
+		assert.Equal(t, curTestDir, realDirectory(filepath.Join(archive, "a", "b")))
+	})
+
+	t.Run("Sizes and sorting", func(t *testing.T) {
+		sortOptions := defaultFilePanel(archive, true).sortOptions.data
+		sortOptions.selected = slices.Index(sortOptions.options, string(sortingSize))
+		location := filepath.Join(archive, "a")
+		elements := addArchiveInfo(returnDirElement(location, true, sortOptions), location)
+		require.Len(t, elements, 2)
+		assert.Equal(t, "b", elements[0].name)
+		assert.True(t, elements[0].directory)
+		require.NotNil(t, elements[1].archiveInfo)
+		assert.Equal(t, int64(5), elements[1].archiveInfo.Size())
+	})
+}
+
+func TestPasteFromArchive(t *testing.T) {
+	processBar := processbar.New()
+	srcDir := filepath.Join(t.TempDir(), "src")
+	utils.SetupDirectories(t, filepath.Join(srcDir, "sub"))
+	utils.SetupFilesWithData(t, []byte("Content of file"), filepath.Join(srcDir, "sub", "file.txt"))
+	utils.SetupFilesWithData(t, []byte("Not copied"), filepath.Join(srcDir, "other.txt"))
+
+	for _, format := range []string{common.CompressFormatZip, common.CompressFormatTarGz} {
+		t.Run(format, func(t *testing.T) {
+			archive := filepath.Join(t.TempDir(), "src."+format)
+			require.NoError(t, compressSources([]string{srcDir}, archive, format, &processBar))
+			dest := t.TempDir()
+
+			state := pasteFromArchive(&processBar, archive, "", []string{filepath.Join(archive, "src", "sub")}, dest,
+				newPasteConflictResolver(common.PasteConflictAsk, nil))
+			assert.Equal(t, processbar.Successful, state)
+
+			data, err := os.ReadFile(filepath.Join(dest, "sub", "file.txt"))
+			require.NoError(t, err)
+			assert.Equal(t, "Content of file", string(data))
+			assert.NoFileExists(t, filepath.Join(dest, "other.txt"))
+			assert.NoDirExists(t, filepath.Join(dest, "src"))
+		})
+	}
+
+	t.Run("Directory conflicting with a file", func(t *testing.T) {
+		archive := filepath.Join(t.TempDir(), "src.zip")
+		require.NoError(t, compressSources([]string{srcDir}, archive, common.CompressFormatZip, &processBar))
+		dest := t.TempDir()
+		existing := filepath.Join(dest, "sub")
+		utils.SetupFilesWithData(t, []byte("existing"), existing)
+		asked := 0
+		resolver := newPasteConflictResolver(common.PasteConflictAsk,
+			func(_ context.Context, _, dst string) (pasteConflictResp, error) {
+				asked++
+				assert.Equal(t, existing, dst)
+				return pasteConflictResp{action: conflictKeepBoth}, nil
+			})
+
+		state := pasteFromArchive(&processBar, archive, "", []string{filepath.Join(archive, "src", "sub")}, dest,
+			resolver)
+		assert.Equal(t, processbar.Successful, state)
+		assert.Equal(t, 1, asked)
+		data, err := os.ReadFile(existing)
+		require.NoError(t, err)
+		assert.Equal(t, "existing", string(data))
+		assert.FileExists(t, filepath.Join(dest, "sub(1)", "file.txt"))
+		entries, err := os.ReadDir(dest)
+		require.NoError(t, err)
+		assert.Len(t, entries, 2, "Nothing else should be left in the destination")
+	})
+}
+
+func TestGetClipboardArchive(t *testing.T) {
+	curTestDir := t.TempDir()
+	archive := filepath.Join(curTestDir, "test.zip")
+	other := filepath.Join(curTestDir, "other.zip")
+	setupZipWithoutDirs(t, archive, map[string]string{"a.txt": "", "b.txt": ""})
+	setupZipWithoutDirs(t, other, map[string]string{"a.txt": ""})
+	file := filepath.Join(curTestDir, "file.txt")
+	utils.SetupFiles(t, file)
+
+	testdata := []struct {
+		name        string
+		items       []string
+		expected    string
+		expectedErr bool
+	}{
+		{name: "Files", items: []string{file, archive}, expected: ""},
+		{name: "Items of an archive", items: []string{filepath.Join(archive, "a.txt"), filepath.Join(archive, "b.txt")},
+			expected: archive},
+		{name: "Archive items after a file", items: []string{file, filepath.Join(archive, "a.txt")}, expectedErr: true},
+		{name: "File after archive items", items: []string{filepath.Join(archive, "a.txt"), file}, expectedErr: true},
+		{name: "Items of two archives", items: []string{filepath.Join(archive, "a.txt"), filepath.Join(other, "a.txt")},
+			expectedErr: true},
+	}
+	for _, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			archivePath, err := getClipboardArchive(tt.items)
+			if tt.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, archivePath)
+		})
+	}
+}
+
+func TestBrowseArchive(t *testing.T) {
+	curTestDir := t.TempDir()
+	dir1 := filepath.Join(curTestDir, "dir1")
+	dir2 := filepath.Join(curTestDir, "dir2")
+	utils.SetupDirectories(t, dir1, dir2)
+	archive := filepath.Join(dir1, "test.zip")
+	setupZipWithoutDirs(t, archive, map[string]string{"file.txt": "Content of file"})
+
+	p := NewTestTeaProgWithEventLoop(t, defaultTestModel(dir1))
+	require.Equal(t, "test.zip", p.getModel().getFocusedFilePanel().getSelectedItem().name)
+	p.SendKey(common.Hotkeys.Confirm[0])
+	assert.Eventually(t, func() bool {
+		panel := p.getModel().getFocusedFilePanel()
+		return panel.location == archive && len(panel.element) == 1
+	}, DefaultTestTimeout, DefaultTestTick, "Confirm on an archive should enter it, once it is read")
+
+	// The archive can't be changed
+	p.SendKey(common.Hotkeys.PasteItems[0])
+	assert.Eventually(t, func() bool {
+		return p.getModel().notifyModel.IsOpen() &&
+			p.getModel().notifyModel.GetTitle() == common.ArchiveReadOnlyTitle
+	}, DefaultTestTimeout, DefaultTestTick)
+	p.SendKey(common.Hotkeys.CancelTyping[0])
+
+	p.SendKey(common.Hotkeys.CopyItems[0])
+	assert.Eventually(t, func() bool {
+		return slices.Equal([]string{filepath.Join(archive, "file.txt")}, p.getModel().copyItems.items)
+	}, DefaultTestTimeout, DefaultTestTick)
+	updateCurrentFilePanelDirOfTestModel(t, p, dir2)
+	p.SendKey(common.Hotkeys.PasteItems[0])
+	assert.Eventually(t, func() bool {
+		data, err := os.ReadFile(filepath.Join(dir2, "file.txt"))
+		return err == nil && string(data) == "Content of file"
+	}, DefaultTestTimeout, DefaultTestTick, "File should be copied out of the archive")
+}
+
+func TestBrowseBrokenArchive(t *testing.T) {
+	curTestDir := t.TempDir()
+	archive := filepath.Join(curTestDir, "broken.zip")
+	utils.SetupFilesWithData(t, []byte("Not a zip"), archive)
+
+	p := NewTestTeaProgWithEventLoop(t, defaultTestModel(curTestDir))
+	p.SendKey(common.Hotkeys.Confirm[0])
+	assert.Eventually(t, func() bool {
+		return p.getModel().notifyModel.IsOpen() &&
+			p.getModel().notifyModel.GetTitle() == common.ArchiveOpenFailedTitle
+	}, DefaultTestTimeout, DefaultTestTick)
+	assert.Equal(t, curTestDir, p.getModel().getFocusedFilePanel().location,
+		"The file panel should leave an archive that can't be read")
+}
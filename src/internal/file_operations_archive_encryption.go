@@ -0,0 +1,342 @@
+package internal
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha1" //nolint:gosec // WinZip AES derives its keys with SHA-1
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"path/filepath"
+	"regexp"
+
+	"github.com/yorukot/superfile/src/internal/common"
+)
+
+const (
+	zipFlagEncrypted      = 0x1
+	zipFlagDataDescriptor = 0x8
+	// Compression method of the entries encrypted with WinZip AES
+	zipMethodAES = 99
+	// Tag of the extra field of WinZip AES, with the actual compression method
+	zipExtraAES = 0x9901
+
+	zipCryptoHeaderSize  = 12
+	zipAESVerifierSize   = 2
+	zipAESAuthCodeSize   = 10
+	zipAESKeyIterations  = 1000
+	zipAESExtraFieldSize = 7
+
+	// Error of github.com/nwaples/rardecode, which xtractr reads rar archives with, for a
+	// missing or wrong password. It isn't exported, xtractr itself matches its message.
+	rarPasswordErrorText = "rardecode: incorrect password"
+)
+
+var (
+	errArchivePasswordRequired = errors.New("archive is encrypted, a password is required")
+	errArchiveWrongPassword    = errors.New("wrong password for the archive")
+
+	// Suffixes of the volumes of multi-volume archives, like .part2.rar or .7z.002
+	archiveVolumeSuffixRegex = regexp.MustCompile(`(?i)(\.part\d+\.rar|\.\d{3})$`)
+)
+
+// archiveSetName returns the name shared by all the volumes of a multi-volume archive,
+// for a password to be asked only once for all of them
+func archiveSetName(path string) string {
+	return filepath.Join(filepath.Dir(path), archiveVolumeSuffixRegex.ReplaceAllString(filepath.Base(path), ""))
+}
+
+// isArchivePasswordError reports whether err is due to a missing or wrong password, that
+// the user can be asked for
+func isArchivePasswordError(err error) bool {
+	return errors.Is(err, errArchivePasswordRequired) || errors.Is(err, errArchiveWrongPassword)
+}
+
+// isPasswordError reports whether err, from xtractr, is due to a missing or wrong
+// password. Only rar 5 archives report it: the readers of older rar archives and of 7z
+// fail like for corrupted data, and their error is shown as is.
+func isPasswordError(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if err.Error() == rarPasswordErrorText {
+			return true
+		}
+	}
+	return false
+}
+
+func isZipFileEncrypted(f *zip.File) bool {
+	return f.Flags&zipFlagEncrypted != 0
+}
+
+// checkArchivePassword checks the password of an archive before it is extracted, so that
+// the user is asked for it before anything is written. Only zips are checked, as other
+// archives superfile reads itself can't be encrypted. Only the first encrypted entry is
+// checked, as deriving the key of each AES entry is slow. An entry encrypted with another
+// password still fails its extraction with errArchiveWrongPassword.
+func checkArchivePassword(path string, format string, password string) error {
+	if format != common.CompressFormatZip {
+		return nil
+	}
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	for _, f := range reader.File {
+		if !isZipFileEncrypted(f) || f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := openZipFile(f, password)
+		if err != nil {
+			return err
+		}
+		return rc.Close()
+	}
+	return nil
+}
+
+// openZipFile opens the content of a zip entry, decrypting it with password if it is
+// encrypted. archive/zip can't decrypt, so the encrypted entries are read raw and
+// decrypted and decompressed here.
+func openZipFile(f *zip.File, password string) (io.ReadCloser, error) {
+	if !isZipFileEncrypted(f) {
+		return f.Open()
+	}
+	if password == "" {
+		return nil, errArchivePasswordRequired
+	}
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return nil, err
+	}
+	method := f.Method
+	var decrypted io.Reader
+	if f.Method == zipMethodAES {
+		var aesMethod uint16
+		decrypted, aesMethod, err = newZipAESReader(f, raw, password)
+		method = aesMethod
+	} else {
+		decrypted, err = newZipCryptoReader(f, raw, password)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rc io.ReadCloser
+	switch method {
+	case zip.Store:
+		rc = io.NopCloser(decrypted)
+	case zip.Deflate:
+		rc = flate.NewReader(decrypted)
+	default:
+		return nil, fmt.Errorf("%w: method %d of encrypted entry %s", zip.ErrAlgorithm, method, f.Name)
+	}
+	// Entries of AE-2 have no checksum, as the authentication code is enough
+	if f.Method == zipMethodAES && f.CRC32 == 0 {
+		return rc, nil
+	}
+	// ZipCrypto only checks one byte of the password, a wrong one can pass it. The
+	// checksum tells it apart.
+	mismatchErr := errArchiveWrongPassword
+	if f.Method == zipMethodAES {
+		mismatchErr = zip.ErrChecksum
+	}
+	return &zipChecksumReader{rc: rc, hash: crc32.NewIEEE(), expected: f.CRC32, mismatchErr: mismatchErr}, nil
+}
+
+type zipChecksumReader struct {
+	rc          io.ReadCloser
+	hash        hash.Hash32
+	expected    uint32
+	mismatchErr error
+}
+
+func (z *zipChecksumReader) Read(p []byte) (int, error) {
+	n, err := z.rc.Read(p)
+	z.hash.Write(p[:n])
+	if errors.Is(err, io.EOF) && z.hash.Sum32() != z.expected {
+		return n, z.mismatchErr
+	}
+	return n, err
+}
+
+func (z *zipChecksumReader) Close() error {
+	return z.rc.Close()
+}
+
+// zipCryptoKeys are the keys of the traditional PKWARE encryption, updated by each
+// decrypted byte
+type zipCryptoKeys [3]uint32
+
+func newZipCryptoKeys(password string) *zipCryptoKeys {
+	k := &zipCryptoKeys{305419896, 591751049, 878082192}
+	for i := range len(password) {
+		k.update(password[i])
+	}
+	return k
+}
+
+func crc32Update(crc uint32, b byte) uint32 {
+	return crc32.IEEETable[(crc^uint32(b))&0xff] ^ (crc >> 8)
+}
+
+func (k *zipCryptoKeys) update(b byte) {
+	k[0] = crc32Update(k[0], b)
+	k[1] = (k[1]+(k[0]&0xff))*134775813 + 1
+	k[2] = crc32Update(k[2], byte(k[1]>>24))
+}
+
+func (k *zipCryptoKeys) decrypt(buf []byte) {
+	for i, c := range buf {
+		temp := k[2] | 2
+		buf[i] = c ^ byte((temp*(temp^1))>>8)
+		k.update(buf[i])
+	}
+}
+
+type zipCryptoReader struct {
+	r    io.Reader
+	keys *zipCryptoKeys
+}
+
+func newZipCryptoReader(f *zip.File, raw io.Reader, password string) (io.Reader, error) {
+	keys := newZipCryptoKeys(password)
+	header := make([]byte, zipCryptoHeaderSize)
+	if _, err := io.ReadFull(raw, header); err != nil {
+		return nil, err
+	}
+	keys.decrypt(header)
+	// The last byte of the header is the high byte of the checksum, or of the time when
+	// the checksum is written after the content
+	check := byte(f.CRC32 >> 24)
+	if f.Flags&zipFlagDataDescriptor != 0 {
+		check = byte(f.ModifiedTime >> 8) //nolint:staticcheck // The MS-DOS time is the one that is checked
+	}
+	if header[zipCryptoHeaderSize-1] != check {
+		return nil, errArchiveWrongPassword
+	}
+	return &zipCryptoReader{r: raw, keys: keys}, nil
+}
+
+func (z *zipCryptoReader) Read(p []byte) (int, error) {
+	n, err := z.r.Read(p)
+	z.keys.decrypt(p[:n])
+	return n, err
+}
+
+// zipAESReader decrypts an entry encrypted with WinZip AES, and checks its
+// authentication code once it is read entirely
+type zipAESReader struct {
+	r        io.Reader
+	authCode io.Reader
+	stream   *zipAESStream
+	mac      hash.Hash
+}
+
+func newZipAESReader(f *zip.File, raw io.Reader, password string) (io.Reader, uint16, error) {
+	strength, method, err := parseZipAESExtra(f.Extra)
+	if err != nil {
+		return nil, 0, err
+	}
+	// AES-128, AES-192 or AES-256
+	keySize := 8 + int(strength)*8
+	saltSize := keySize / 2
+	overhead := uint64(saltSize + zipAESVerifierSize + zipAESAuthCodeSize)
+	if strength < 1 || strength > 3 || f.CompressedSize64 < overhead {
+		return nil, 0, fmt.Errorf("%w: invalid AES entry %s", zip.ErrFormat, f.Name)
+	}
+	header := make([]byte, saltSize+zipAESVerifierSize)
+	if _, err = io.ReadFull(raw, header); err != nil {
+		return nil, 0, err
+	}
+	keys, err := pbkdf2.Key(sha1.New, password, header[:saltSize], zipAESKeyIterations,
+		2*keySize+zipAESVerifierSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !bytes.Equal(keys[2*keySize:], header[saltSize:]) {
+		return nil, 0, errArchiveWrongPassword
+	}
+	block, err := aes.NewCipher(keys[:keySize])
+	if err != nil {
+		return nil, 0, err
+	}
+	return &zipAESReader{
+		r:        io.LimitReader(raw, int64(f.CompressedSize64-overhead)), //nolint:gosec // Checked above
+		authCode: raw,
+		stream:   newZipAESStream(block),
+		mac:      hmac.New(sha1.New, keys[keySize:2*keySize]),
+	}, method, nil
+}
+
+// parseZipAESExtra returns the key strength and the actual compression method of an
+// entry encrypted with WinZip AES, from its extra field
+func parseZipAESExtra(extra []byte) (byte, uint16, error) {
+	for len(extra) >= 4 {
+		tag := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		extra = extra[4:]
+		if size > len(extra) {
+			break
+		}
+		if tag == zipExtraAES && size >= zipAESExtraFieldSize {
+			return extra[4], binary.LittleEndian.Uint16(extra[5:]), nil
+		}
+		extra = extra[size:]
+	}
+	return 0, 0, fmt.Errorf("%w: missing AES extra field", zip.ErrFormat)
+}
+
+func (z *zipAESReader) Read(p []byte) (int, error) {
+	n, err := z.r.Read(p)
+	z.mac.Write(p[:n])
+	z.stream.XORKeyStream(p[:n], p[:n])
+	if errors.Is(err, io.EOF) {
+		authCode := make([]byte, zipAESAuthCodeSize)
+		if _, readErr := io.ReadFull(z.authCode, authCode); readErr != nil {
+			return n, readErr
+		}
+		if !hmac.Equal(authCode, z.mac.Sum(nil)[:zipAESAuthCodeSize]) {
+			return n, fmt.Errorf("%w: authentication code mismatch", zip.ErrChecksum)
+		}
+	}
+	return n, err
+}
+
+// zipAESStream is the counter mode of WinZip AES. Unlike cipher.NewCTR, the counter is
+// little endian, and starts at 1.
+type zipAESStream struct {
+	block     cipher.Block
+	counter   [aes.BlockSize]byte
+	keyStream [aes.BlockSize]byte
+	pos       int
+}
+
+func newZipAESStream(block cipher.Block) *zipAESStream {
+	return &zipAESStream{block: block, pos: aes.BlockSize}
+}
+
+func (s *zipAESStream) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if s.pos == aes.BlockSize {
+			for j := range s.counter {
+				s.counter[j]++
+				if s.counter[j] != 0 {
+					break
+				}
+			}
+			s.block.Encrypt(s.keyStream[:], s.counter[:])
+			s.pos = 0
+		}
+		dst[i] = src[i] ^ s.keyStream[s.pos]
+		s.pos++
+	}
+}
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// attrPreserveError is returned when items were copied, but some of their attributes
+// could not be preserved. It doesn't make the copy fail.
+type attrPreserveError struct {
+	failures []string
+}
+
+func (e *attrPreserveError) Error() string {
+	return "could not preserve attributes : " + strings.Join(e.failures, ", ")
+}
+
+func (e *attrPreserveError) add(err error) {
+	var attrErr *attrPreserveError
+	if errors.As(err, &attrErr) {
+		e.failures = append(e.failures, attrErr.failures...)
+	}
+}
+
+// orNil returns e, or nil if there were no failures, so that it can be returned as an error
+func (e *attrPreserveError) orNil() error {
+	if len(e.failures) == 0 {
+		return nil
+	}
+	return e
+}
+
+// isAttrPreserveError reports whether err is only about attributes that could not be preserved.
+// If so, the copy itself has succeeded.
+func isAttrPreserveError(err error) bool {
+	var attrErr *attrPreserveError
+	return errors.As(err, &attrErr)
+}
+
+// Mode to create a directory with, before its children are copied. When attributes
+// are preserved, the actual mode is set after the children, so the directory must
+// stay writable till then.
+func dirCreateMode(info os.FileInfo, preserveAttrs bool) os.FileMode {
+	if preserveAttrs {
+		return info.Mode() | 0o700
+	}
+	return info.Mode()
+}
+
+// preserveAttributes copies the permissions, timestamps, ownership and extended
+// attributes of src to dst. It tries all of them, and returns an *attrPreserveError
+// with everything that failed.
+func preserveAttributes(src, dst string, info os.FileInfo) error {
+	var failed []string
+	// Before chmod, as chown can clear the setuid and setgid bits
+	if err := preserveOwnership(dst, info); err != nil {
+		failed = append(failed, "ownership : "+err.Error())
+	}
+	// Permissions of symlinks are not used, and the other calls would follow them
+	if isSymlink(info) {
+		if err := lchtimes(dst, fileAtime(info), info.ModTime()); err != nil {
+			failed = append(failed, "timestamps : "+err.Error())
+		}
+	} else {
+		if err := copyXattrs(src, dst); err != nil {
+			failed = append(failed, "extended attributes : "+err.Error())
+		}
+		mode := info.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+		if err := os.Chmod(dst, mode); err != nil {
+			failed = append(failed, "permissions : "+err.Error())
+		}
+		// Last, as the other changes could update the access time
+		if err := os.Chtimes(dst, fileAtime(info), info.ModTime()); err != nil {
+			failed = append(failed, "timestamps : "+err.Error())
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+	slog.Warn("Could not preserve attributes", "src", src, "dst", dst, "failed", failed)
+	return &attrPreserveError{failures: []string{dst + " (" + strings.Join(failed, "; ") + ")"}}
+}
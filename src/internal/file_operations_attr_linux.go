@@ -0,0 +1,16 @@
+//go:build linux
+
+package internal
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+func fileAtime(info os.FileInfo) time.Time {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(stat.Atim.Unix())
+	}
+	return info.ModTime()
+}
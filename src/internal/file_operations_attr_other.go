@@ -0,0 +1,26 @@
+//go:build !linux && !darwin
+
+package internal
+
+import (
+	"os"
+	"time"
+)
+
+// Ownership, extended attributes and timestamps of symlinks are only preserved on Linux and macOS
+
+func preserveOwnership(_ string, _ os.FileInfo) error {
+	return nil
+}
+
+func copyXattrs(_, _ string) error {
+	return nil
+}
+
+func lchtimes(_ string, _, _ time.Time) error {
+	return nil
+}
+
+func fileAtime(info os.FileInfo) time.Time {
+	return info.ModTime()
+}
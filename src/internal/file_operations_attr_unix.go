@@ -0,0 +1,96 @@
+//go:build linux || darwin
+
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func preserveOwnership(dst string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	if os.Geteuid() == 0 {
+		return os.Lchown(dst, int(stat.Uid), int(stat.Gid))
+	}
+	// Like rsync, non root users only preserve the group, and only if they are a member of it
+	err := os.Lchown(dst, -1, int(stat.Gid))
+	if errors.Is(err, os.ErrPermission) {
+		return nil
+	}
+	return err
+}
+
+// lchtimes is like os.Chtimes, but doesn't follow symlinks
+func lchtimes(path string, atime, mtime time.Time) error {
+	tv := []unix.Timeval{unix.NsecToTimeval(atime.UnixNano()), unix.NsecToTimeval(mtime.UnixNano())}
+	if err := unix.Lutimes(path, tv); err != nil {
+		return &os.PathError{Op: "lutimes", Path: path, Err: err}
+	}
+	return nil
+}
+
+func copyXattrs(src, dst string) error {
+	names, err := listXattrs(src)
+	if errors.Is(err, unix.ENOTSUP) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, name := range names {
+		val, err := getXattr(src, name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err = unix.Setxattr(dst, name, val, 0); err != nil {
+			errs = append(errs, &os.PathError{Op: "setxattr " + name, Path: dst, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func listXattrs(path string) ([]string, error) {
+	size, err := unix.Listxattr(path, nil)
+	if err != nil {
+		return nil, &os.PathError{Op: "listxattr", Path: path, Err: err}
+	}
+	if size == 0 {
+		return nil, nil
+	}
+	buf := make([]byte, size)
+	size, err = unix.Listxattr(path, buf)
+	if err != nil {
+		return nil, &os.PathError{Op: "listxattr", Path: path, Err: err}
+	}
+	var names []string
+	for _, name := range bytes.Split(buf[:size], []byte{0}) {
+		if len(name) > 0 {
+			names = append(names, string(name))
+		}
+	}
+	return names, nil
+}
+
+func getXattr(path string, name string) ([]byte, error) {
+	size, err := unix.Getxattr(path, name, nil)
+	if err != nil {
+		return nil, &os.PathError{Op: "getxattr " + name, Path: path, Err: err}
+	}
+	buf := make([]byte, size)
+	size, err = unix.Getxattr(path, name, buf)
+	if err != nil {
+		return nil, &os.PathError{Op: "getxattr " + name, Path: path, Err: err}
+	}
+	return buf[:size], nil
+}
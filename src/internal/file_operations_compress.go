@@ -2,37 +2,89 @@ package internal
 
 import (
 	"archive/zip"
+	"compress/flate"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/yorukot/superfile/src/config/icon"
+	"github.com/yorukot/superfile/src/internal/common"
 	"github.com/yorukot/superfile/src/internal/ui/processbar"
+	"github.com/yorukot/superfile/src/internal/utils"
 )
 
-func zipSources(sources []string, target string, processBar *processbar.Model) error {
+// archiveWriter writes the entries of an archive, in the order they are walked
+type archiveWriter interface {
+	// writeEntry adds the item at path, with the given info, as relPath in the archive
+	writeEntry(ctx context.Context, path string, relPath string, info os.FileInfo) error
+	// Close finishes the archive. The archive is only usable if it succeeds.
+	Close() error
+}
+
+// compressOptions are the settings of an archive being created
+type compressOptions struct {
+	format string
+	// Compression level, from 1 for the fastest to 9 for the smallest. 0 is the default
+	// level of the format.
+	level int
+	// Entries of zips are stored without compression
+	storeOnly bool
+	// Glob patterns of the items that are left out, see utils.MatchesExcludePattern
+	excludes []string
+}
+
+// newArchiveWriter returns the writer of an archive with the given options into w
+func newArchiveWriter(w io.Writer, opts compressOptions) (archiveWriter, error) {
+	if opts.format == common.CompressFormatZip {
+		return newZipArchiveWriter(w, opts), nil
+	}
+	return newTarArchiveWriter(w, opts.format, opts.level)
+}
+
+// compressSources creates the archive target of the given format, containing sources.
+// The returned error is about the archive not being created at all, failures while
+// writing it are reported by the process.
+func compressSources(sources []string, target string, format string, processBar *processbar.Model) error {
+	return compressSourcesWithOptions(sources, target, compressOptions{format: format}, processBar)
+}
+
+// compressSourcesWithOptions is like compressSources, for an archive with the given options
+func compressSourcesWithOptions(sources []string, target string, opts compressOptions,
+	processBar *processbar.Model) error {
 	var err error
+	format := opts.format
 
 	totalFiles := 0
 	for _, src := range sources {
 		if _, err = os.Stat(src); os.IsNotExist(err) {
 			return fmt.Errorf("source path does not exist: %s", src)
 		}
-		count, e := countFiles(src)
+		count, _, e := countCompressSource(context.Background(), src, opts.excludes)
 		if e != nil {
-			slog.Error("Error while zip file count files ", "error", e)
+			slog.Error("Error while counting files to compress", "error", e)
 		}
 		totalFiles += count
 	}
-	p, err := processBar.SendAddProcessMsg("zip file", totalFiles, true)
+	if err = checkCompressFormat(format); err != nil {
+		return err
+	}
+	p, ctx, err := processBar.SendAddQueuedProcessMsg(format+" file", totalFiles, deviceKey(filepath.Dir(target)))
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("cannot spawn process : %w", err)
 	}
+	p.Sources = sources
+	p.Destination = target
 	_, err = os.Stat(target)
 	if err == nil {
 		p.Name = icon.CompressFile + icon.Space + "File already exist"
@@ -47,15 +99,36 @@ func zipSources(sources []string, target string, processBar *processbar.Model) e
 
 	f, err := os.Create(target)
 	if err != nil {
+		p.State = processbar.Failed
+		p.ErrorMsg = err.Error()
+		p.DoneTime = time.Now()
+		if pSendErr := processBar.SendUpdateProcessMsg(p, true); pSendErr != nil {
+			slog.Error("Error sending process update", "error", pSendErr)
+		}
 		return err
 	}
-	defer f.Close()
-	writer := zip.NewWriter(f)
-	defer writer.Close()
-
-	zipSourcesCore(sources, processBar, &p, writer)
+	writer, err := newArchiveWriter(f, opts)
+	if err != nil {
+		slog.Error("Error while starting archive", "format", format, "error", err)
+		p.State = processbar.Failed
+		p.ErrorMsg = err.Error()
+	} else {
+		compressSourcesCore(ctx, sources, opts.excludes, processBar, &p, writer)
+		// Close() writes the end of the archive, so it must finish before the archive is usable
+		if err = writer.Close(); err != nil && p.State == processbar.InOperation {
+			slog.Error("Error while finishing archive", "format", format, "error", err)
+			p.State = processbar.Failed
+			p.ErrorMsg = err.Error()
+		}
+	}
+	f.Close()
+	if p.State == processbar.Cancelled || p.State == processbar.Failed {
+		if err = os.Remove(target); err != nil {
+			slog.Error("Error while removing incomplete archive", "target", target, "error", err)
+		}
+	}
 
-	if p.State != processbar.Failed {
+	if p.State == processbar.InOperation {
 		// TODO: User p.SetSuccessful(), p.SetFailed()
 		p.State = processbar.Successful
 		p.Done = totalFiles
@@ -68,21 +141,31 @@ func zipSources(sources []string, target string, processBar *processbar.Model) e
 	return nil
 }
 
-func zipSourcesCore(sources []string, processBar *processbar.Model,
-	p *processbar.Process, writer *zip.Writer) {
+// checkCompressFormat returns an error if archives of the given format cannot be
+// created, so that it can be reported before starting
+func checkCompressFormat(format string) error {
+	if !slices.Contains(common.CompressFormats, format) {
+		return fmt.Errorf("unsupported archive format %q", format)
+	}
+	if format == common.CompressFormatTarBz2 {
+		if _, err := exec.LookPath(bzip2Command); err != nil {
+			return fmt.Errorf("%s archives need the %s command, which was not found", format, bzip2Command)
+		}
+	}
+	return nil
+}
+
+func compressSourcesCore(ctx context.Context, sources []string, excludes []string, processBar *processbar.Model,
+	p *processbar.Process, writer archiveWriter) {
 	for _, src := range sources {
-		srcParentDir := filepath.Dir(src)
-		err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		err := walkCompressSource(src, excludes, func(path string, relPath string, info os.FileInfo) error {
 			p.Name = icon.CompressFile + icon.Space + filepath.Base(path)
-			if err != nil {
-				return err
-			}
-			relPath, err := filepath.Rel(srcParentDir, path)
+			err := ctx.Err()
 			if err != nil {
 				return err
 			}
 
-			err = writeZipFile(path, relPath, info, writer)
+			err = writer.writeEntry(ctx, path, relPath, info)
 			if err != nil {
 				return err
 			}
@@ -91,20 +174,90 @@ func zipSourcesCore(sources []string, processBar *processbar.Model,
 			processBar.TrySendingUpdateProcessMsg(*p)
 			return nil
 		})
+		if errors.Is(err, context.Canceled) {
+			p.State = processbar.Cancelled
+			break
+		}
 		if err != nil {
-			slog.Error("Error while zip file", "error", err)
+			slog.Error("Error while compressing file", "error", err)
 			p.State = processbar.Failed
+			p.ErrorMsg = err.Error()
 			break
 		}
 	}
 }
 
-func writeZipFile(path string, relPath string, info os.FileInfo, writer *zip.Writer) error {
+// walkCompressSource walks src like walkWithSymlinkPolicy, leaving out the items matched
+// by excludes, and their content. relPath is the path of the items in the archive.
+func walkCompressSource(src string, excludes []string,
+	fn func(path string, relPath string, info os.FileInfo) error) error {
+	srcParentDir := filepath.Dir(src)
+	return walkWithSymlinkPolicy(src, common.Config.SymlinkPolicy, func(path string, info os.FileInfo) error {
+		relPath, err := filepath.Rel(srcParentDir, path)
+		if err != nil {
+			return err
+		}
+		if utils.MatchesExcludePattern(filepath.ToSlash(relPath), excludes) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return fn(path, relPath, info)
+	})
+}
+
+// countCompressSource returns the count of files that are archived from src, and their
+// total size
+func countCompressSource(ctx context.Context, src string, excludes []string) (int, int64, error) {
+	count := 0
+	var size int64
+	err := walkCompressSource(src, excludes, func(_ string, _ string, info os.FileInfo) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			count++
+			size += info.Size()
+		}
+		return nil
+	})
+	return count, size, err
+}
+
+type zipArchiveWriter struct {
+	writer *zip.Writer
+	method uint16
+}
+
+func newZipArchiveWriter(w io.Writer, opts compressOptions) *zipArchiveWriter {
+	z := &zipArchiveWriter{writer: zip.NewWriter(w), method: zip.Deflate}
+	switch {
+	case opts.storeOnly:
+		z.method = zip.Store
+	case opts.level != 0:
+		z.writer.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(out, opts.level)
+		})
+	}
+	return z
+}
+
+func (z *zipArchiveWriter) writeEntry(ctx context.Context, path string, relPath string, info os.FileInfo) error {
+	return writeZipFile(ctx, path, relPath, info, z.writer, z.method)
+}
+
+func (z *zipArchiveWriter) Close() error {
+	return z.writer.Close()
+}
+
+func writeZipFile(ctx context.Context, path string, relPath string, info os.FileInfo, writer *zip.Writer,
+	method uint16) error {
 	header, err := zip.FileInfoHeader(info)
 	if err != nil {
 		return err
 	}
-	header.Method = zip.Deflate
+	header.Method = method
 	header.Name = relPath
 	if info.IsDir() {
 		header.Name += "/"
@@ -116,20 +269,45 @@ func writeZipFile(path string, relPath string, info os.FileInfo, writer *zip.Wri
 	if info.IsDir() {
 		return nil
 	}
+	// Like with the zip command, the content of a symlink entry is its target
+	if isSymlink(info) {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(headerWriter, target)
+		return err
+	}
 	file, err := os.Open(path)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
-	_, err = io.Copy(headerWriter, file)
+	_, err = io.Copy(headerWriter, &copyReader{ctx: ctx, r: file})
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func getZipArchiveName(base string) (string, error) {
-	zipName := strings.TrimSuffix(base, filepath.Ext(base)) + ".zip"
-	zipName, err := renameIfDuplicate(zipName)
-	return zipName, err
+// getArchivePath returns a free path in dir for an archive of the given format,
+// named after base. Like with renameIfDuplicate, "(1)", "(2)", ... are added to the
+// name till it is free, but before the whole extension, like "name(1).tar.gz".
+func getArchivePath(dir string, base string, format string) (string, error) {
+	stem := strings.TrimSuffix(base, filepath.Ext(base))
+	for i := range 10_000 {
+		name := stem + "." + format
+		if i > 0 {
+			name = fmt.Sprintf("%s(%d).%s", stem, i, format)
+		}
+		path := filepath.Join(dir, name)
+		_, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			return path, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("could not find free name for %s after many attempts", base)
 }
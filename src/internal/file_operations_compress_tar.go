@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+
+	"github.com/yorukot/superfile/src/internal/common"
+)
+
+// Go has no bzip2 compressor, tar.bz2 archives are compressed by this command
+const bzip2Command = "bzip2"
+
+// tarArchiveWriter writes a tar archive, compressed by compressor if it is not nil.
+// Unlike zip, tar keeps the permissions, ownership and symlinks of the entries.
+type tarArchiveWriter struct {
+	writer     *tar.Writer
+	compressor io.WriteCloser
+}
+
+// newTarArchiveWriter returns the writer of a tar archive of the given format, compressed
+// at level, from 1 to 9, or at the default level of the format if it is 0
+func newTarArchiveWriter(w io.Writer, format string, level int) (*tarArchiveWriter, error) {
+	if format == common.CompressFormatTar {
+		return &tarArchiveWriter{writer: tar.NewWriter(w)}, nil
+	}
+	compressor, err := newTarCompressor(w, format, level)
+	if err != nil {
+		return nil, err
+	}
+	return &tarArchiveWriter{writer: tar.NewWriter(compressor), compressor: compressor}, nil
+}
+
+// newTarCompressor returns the compressor of a compressed tar archive of the given format
+func newTarCompressor(w io.Writer, format string, level int) (io.WriteCloser, error) {
+	switch format {
+	case common.CompressFormatTarGz:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+	case common.CompressFormatTarBz2:
+		if level == 0 {
+			return newCommandWriter(w, bzip2Command, "-c")
+		}
+		return newCommandWriter(w, bzip2Command, "-c", "-"+strconv.Itoa(level))
+	case common.CompressFormatTarXz:
+		config := xz.WriterConfig{}
+		if level != 0 {
+			config.DictCap = xzDictCap(level)
+		}
+		return config.NewWriter(w)
+	case common.CompressFormatTarZst:
+		if level == 0 {
+			return zstd.NewWriter(w)
+		}
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+	}
+	return nil, fmt.Errorf("unsupported archive format %q", format)
+}
+
+// xzDictCap returns the dictionary size of the preset level of the xz command. Go's xz
+// has no levels, but the presets mostly differ by the size of the dictionary.
+func xzDictCap(level int) int {
+	const mib = 1 << 20
+	switch {
+	case level <= 2:
+		return level * mib
+	case level <= 4:
+		return 4 * mib
+	case level <= 6:
+		return 8 * mib
+	default:
+		return (16 << (level - 7)) * mib
+	}
+}
+
+func (t *tarArchiveWriter) writeEntry(ctx context.Context, path string, relPath string, info os.FileInfo) error {
+	// Like tar, sockets are left out as they can't be archived
+	if info.Mode()&os.ModeSocket != 0 {
+		return nil
+	}
+	link := ""
+	if isSymlink(info) {
+		var err error
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	}
+	// The header gets the mode, mtime and, on unix, the owner of the entry
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.ToSlash(relPath)
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	if err = t.writer.WriteHeader(header); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	// The size in the header must match, a file growing while it is archived is truncated
+	_, err = io.CopyN(t.writer, &copyReader{ctx: ctx, r: file}, header.Size)
+	return err
+}
+
+func (t *tarArchiveWriter) Close() error {
+	err := t.writer.Close()
+	if t.compressor != nil {
+		err = errors.Join(err, t.compressor.Close())
+	}
+	return err
+}
+
+// commandWriter compresses what is written to it with a command, that reads its
+// standard input and writes the result to its standard output
+type commandWriter struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr *bytes.Buffer
+}
+
+func newCommandWriter(w io.Writer, name string, args ...string) (*commandWriter, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = w
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, fmt.Errorf("cannot start %s: %w", name, err)
+	}
+	return &commandWriter{cmd: cmd, stdin: stdin, stderr: stderr}, nil
+}
+
+func (c *commandWriter) Write(p []byte) (int, error) {
+	return c.stdin.Write(p)
+}
+
+// Close waits for the command to write all of its output
+func (c *commandWriter) Close() error {
+	err := c.stdin.Close()
+	if waitErr := c.cmd.Wait(); waitErr != nil {
+		return fmt.Errorf("%s failed: %w: %s", c.cmd.Path, waitErr, strings.TrimSpace(c.stderr.String()))
+	}
+	return err
+}
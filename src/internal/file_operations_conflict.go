@@ -0,0 +1,253 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+)
+
+// How a single conflicting item of a paste operation is handled
+type pasteConflictAction int
+
+const (
+	conflictKeepBoth pasteConflictAction = iota
+	conflictOverwrite
+	conflictSkip
+	conflictOverwriteIfNewer
+)
+
+type pasteConflictChoice struct {
+	label  string
+	action pasteConflictAction
+}
+
+// Choices shown in the conflict dialog, in the order they are rendered
+func pasteConflictChoices() []pasteConflictChoice {
+	return []pasteConflictChoice{
+		{label: "Overwrite (merge directories)", action: conflictOverwrite},
+		{label: "Skip", action: conflictSkip},
+		{label: "Keep both", action: conflictKeepBoth},
+		{label: "Overwrite if newer", action: conflictOverwriteIfNewer},
+	}
+}
+
+func pasteConflictChoiceLabels() []string {
+	choices := pasteConflictChoices()
+	labels := make([]string, len(choices))
+	for i, choice := range choices {
+		labels[i] = choice.label
+	}
+	return labels
+}
+
+// Answer of the user to a pasteConflictReq
+type pasteConflictResp struct {
+	action     pasteConflictAction
+	applyToAll bool
+}
+
+// pasteConflictReq is sent by a running paste operation when the policy is to ask the user.
+// The operation is blocked until the answer is sent on resp.
+type pasteConflictReq struct {
+	src  string
+	dst  string
+	resp chan pasteConflictResp
+	// Closed once the operation is cancelled, and doesn't wait for the answer anymore
+	done <-chan struct{}
+}
+
+// pasteConflictResolver decides what happens to pasted items whose destination already exists.
+// One resolver is used for a whole paste operation, so that "apply to all" covers every
+// remaining item, including the ones inside subdirectories.
+type pasteConflictResolver struct {
+	policy string
+	// Used when policy is common.PasteConflictAsk. Blocks till the user answers,
+	// or ctx is done
+	ask func(ctx context.Context, src, dst string) (pasteConflictResp, error)
+
+	// Set once the user has chosen "apply to all"
+	hasFixedAction bool
+	fixedAction    pasteConflictAction
+
+	// Set once an existing item has been overwritten or merged into
+	overwrote bool
+
+	// Items that failed in a previous run of the operation, by source, with where they
+	// were being pasted. See resumeFailed()
+	resumed map[string]string
+}
+
+func newPasteConflictResolver(policy string,
+	ask func(ctx context.Context, src, dst string) (pasteConflictResp, error)) *pasteConflictResolver {
+	return &pasteConflictResolver{
+		policy: policy,
+		ask:    ask,
+	}
+}
+
+// resumeFailed makes the items of failures be pasted again where they were being pasted,
+// instead of being resolved as new conflicts. What was fully pasted is kept.
+func (r *pasteConflictResolver) resumeFailed(failures []processbar.ItemFailure) {
+	for _, failure := range failures {
+		if failure.Dst == "" {
+			continue
+		}
+		if r.resumed == nil {
+			r.resumed = make(map[string]string)
+		}
+		r.resumed[failure.Item] = failure.Dst
+	}
+}
+
+// isResumed reports whether src is an item pasted again after a failure, or is inside one
+func (r *pasteConflictResolver) isResumed(src string) bool {
+	for item := range r.resumed {
+		if src == item || strings.HasPrefix(src, item+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *pasteConflictResolver) getAction(ctx context.Context, src, dst string) (pasteConflictAction, error) {
+	if r.hasFixedAction {
+		return r.fixedAction, nil
+	}
+	switch r.policy {
+	case common.PasteConflictOverwrite:
+		return conflictOverwrite, nil
+	case common.PasteConflictSkip:
+		return conflictSkip, nil
+	case common.PasteConflictOverwriteIfNewer:
+		return conflictOverwriteIfNewer, nil
+	case common.PasteConflictAsk:
+		if r.ask == nil {
+			return conflictKeepBoth, nil
+		}
+		resp, err := r.ask(ctx, src, dst)
+		if err != nil {
+			return conflictSkip, err
+		}
+		if resp.applyToAll {
+			r.hasFixedAction = true
+			r.fixedAction = resp.action
+		}
+		return resp.action, nil
+	default:
+		return conflictKeepBoth, nil
+	}
+}
+
+// resolve returns the path src should be pasted to, given that its intended destination is dst.
+// skip is true if src must not be pasted at all. If src and dst are both directories and the
+// item is overwritten, dst is returned as it is and the caller is expected to merge the contents.
+// Overwriting an item of a different type removes it first. If the user is asked, and ctx
+// is done before they answer, ctx.Err() is returned.
+func (r *pasteConflictResolver) resolve(ctx context.Context, src, dst string) (string, bool, error) {
+	if resumedDst, ok := r.resumed[src]; ok {
+		dst = resumedDst
+	}
+	dstInfo, err := os.Lstat(dst)
+	if os.IsNotExist(err) {
+		return dst, false, nil
+	} else if err != nil {
+		return "", false, fmt.Errorf("failed to stat destination: %w", err)
+	}
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to stat source: %w", err)
+	}
+	return r.resolveExisting(ctx, src, srcInfo, dst, dstInfo)
+}
+
+// resolveInfo is like resolve, for a src that may not be a file on disk, like an
+// entry of an archive. srcInfo is the info of src.
+func (r *pasteConflictResolver) resolveInfo(ctx context.Context, src string, srcInfo os.FileInfo,
+	dst string) (string, bool, error) {
+	dstInfo, err := os.Lstat(dst)
+	if os.IsNotExist(err) {
+		return dst, false, nil
+	} else if err != nil {
+		return "", false, fmt.Errorf("failed to stat destination: %w", err)
+	}
+	return r.resolveExisting(ctx, src, srcInfo, dst, dstInfo)
+}
+
+// resolveExisting resolves the conflict of pasting src to dst, that already exists
+func (r *pasteConflictResolver) resolveExisting(ctx context.Context, src string, srcInfo os.FileInfo, dst string,
+	dstInfo os.FileInfo) (string, bool, error) {
+	// Pasting an item onto itself, for example copying into the same directory.
+	// Overwriting would truncate the source.
+	if os.SameFile(srcInfo, dstInfo) {
+		return resolveToFreeName(dst)
+	}
+	if r.isResumed(src) {
+		return resolveResumed(srcInfo, dst, dstInfo)
+	}
+
+	action, err := r.getAction(ctx, src, dst)
+	if err != nil {
+		return "", false, err
+	}
+	if action == conflictOverwriteIfNewer {
+		action = conflictSkip
+		if (srcInfo.IsDir() && dstInfo.IsDir()) || srcInfo.ModTime().After(dstInfo.ModTime()) {
+			action = conflictOverwrite
+		}
+	}
+
+	switch action {
+	case conflictSkip:
+		return "", true, nil
+	case conflictOverwrite:
+		r.overwrote = true
+		if srcInfo.IsDir() != dstInfo.IsDir() {
+			if err = os.RemoveAll(dst); err != nil {
+				return "", false, fmt.Errorf("failed to remove existing destination: %w", err)
+			}
+		}
+		return dst, false, nil
+	case conflictKeepBoth, conflictOverwriteIfNewer:
+		fallthrough
+	default:
+		return resolveToFreeName(dst)
+	}
+}
+
+// resolveResumed resolves the conflict of an item pasted again after a failure, with what
+// was pasted by the failed run. Directories are merged, and files that have the size of
+// src, and are not older, were fully pasted and are skipped. Others are overwritten.
+func resolveResumed(srcInfo os.FileInfo, dst string, dstInfo os.FileInfo) (string, bool, error) {
+	if srcInfo.IsDir() && dstInfo.IsDir() {
+		return dst, false, nil
+	}
+	if srcInfo.Mode().IsRegular() && dstInfo.Mode().IsRegular() && srcInfo.Size() == dstInfo.Size() &&
+		!srcInfo.ModTime().After(dstInfo.ModTime()) {
+		return "", true, nil
+	}
+	if dstInfo.IsDir() {
+		if err := os.RemoveAll(dst); err != nil {
+			return "", false, fmt.Errorf("failed to remove existing destination: %w", err)
+		}
+	}
+	return dst, false, nil
+}
+
+func resolveToFreeName(dst string) (string, bool, error) {
+	dst, err := renameIfDuplicate(dst)
+	if err != nil {
+		return "", false, err
+	}
+	return dst, false, nil
+}
+
+// isExistingDir reports whether path exists and is a directory
+func isExistingDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,258 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/notify"
+	"github.com/yorukot/superfile/src/internal/utils"
+)
+
+func TestPasteConflictResolver(t *testing.T) {
+	curTestDir := t.TempDir()
+	srcDir := filepath.Join(curTestDir, "src")
+	dstDir := filepath.Join(curTestDir, "dst")
+	srcFile := filepath.Join(srcDir, "file.txt")
+	dstFile := filepath.Join(dstDir, "file.txt")
+	srcSubDir := filepath.Join(srcDir, "dir")
+	dstSubDir := filepath.Join(dstDir, "dir")
+	dstFileAsDir := filepath.Join(dstDir, "file_as_dir.txt")
+	utils.SetupDirectories(t, srcDir, dstDir, srcSubDir, dstSubDir, dstFileAsDir)
+	utils.SetupFiles(t, srcFile, dstFile)
+
+	older := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(dstFile, older, older))
+
+	testdata := []struct {
+		name         string
+		policy       string
+		src          string
+		dst          string
+		expectedDst  string
+		expectedSkip bool
+	}{
+		{
+			name:        "No conflict",
+			policy:      common.PasteConflictSkip,
+			src:         srcFile,
+			dst:         filepath.Join(dstDir, "new.txt"),
+			expectedDst: filepath.Join(dstDir, "new.txt"),
+		},
+		{
+			name:        "Rename keeps both",
+			policy:      common.PasteConflictRename,
+			src:         srcFile,
+			dst:         dstFile,
+			expectedDst: filepath.Join(dstDir, "file(1).txt"),
+		},
+		{
+			name:         "Skip",
+			policy:       common.PasteConflictSkip,
+			src:          srcFile,
+			dst:          dstFile,
+			expectedSkip: true,
+		},
+		{
+			name:        "Overwrite file",
+			policy:      common.PasteConflictOverwrite,
+			src:         srcFile,
+			dst:         dstFile,
+			expectedDst: dstFile,
+		},
+		{
+			name:        "Overwrite directory merges",
+			policy:      common.PasteConflictOverwrite,
+			src:         srcSubDir,
+			dst:         dstSubDir,
+			expectedDst: dstSubDir,
+		},
+		{
+			name:        "Overwrite if newer with newer source",
+			policy:      common.PasteConflictOverwriteIfNewer,
+			src:         srcFile,
+			dst:         dstFile,
+			expectedDst: dstFile,
+		},
+		{
+			name:         "Overwrite if newer with older source",
+			policy:       common.PasteConflictOverwriteIfNewer,
+			src:          dstFile,
+			dst:          srcFile,
+			expectedSkip: true,
+		},
+		{
+			name:        "Pasting onto itself always keeps both",
+			policy:      common.PasteConflictOverwrite,
+			src:         srcFile,
+			dst:         srcFile,
+			expectedDst: filepath.Join(srcDir, "file(1).txt"),
+		},
+	}
+
+	for _, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := newPasteConflictResolver(tt.policy, nil)
+			dst, skip, err := resolver.resolve(t.Context(), tt.src, tt.dst)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedSkip, skip)
+			assert.Equal(t, tt.expectedDst, dst)
+		})
+	}
+
+	t.Run("Overwrite item of different type removes it", func(t *testing.T) {
+		resolver := newPasteConflictResolver(common.PasteConflictOverwrite, nil)
+		dst, skip, err := resolver.resolve(t.Context(), srcFile, dstFileAsDir)
+		require.NoError(t, err)
+		assert.False(t, skip)
+		assert.Equal(t, dstFileAsDir, dst)
+		assert.NoDirExists(t, dstFileAsDir)
+	})
+
+	t.Run("Ask with apply to all", func(t *testing.T) {
+		askCnt := 0
+		resolver := newPasteConflictResolver(common.PasteConflictAsk,
+			func(_ context.Context, _, _ string) (pasteConflictResp, error) {
+				askCnt++
+				return pasteConflictResp{action: conflictSkip, applyToAll: true}, nil
+			})
+		for range 3 {
+			_, skip, err := resolver.resolve(t.Context(), srcFile, dstFile)
+			require.NoError(t, err)
+			assert.True(t, skip)
+		}
+		assert.Equal(t, 1, askCnt, "User should be asked only once")
+	})
+}
+
+func TestPasteConflictDialog(t *testing.T) {
+	curTestDir := t.TempDir()
+	newReq := func(done <-chan struct{}) pasteConflictReq {
+		return pasteConflictReq{
+			src:  filepath.Join(curTestDir, "src"),
+			dst:  filepath.Join(curTestDir, "dst"),
+			resp: make(chan pasteConflictResp, 1),
+			done: done,
+		}
+	}
+
+	t.Run("Ask returns once the operation is cancelled", func(t *testing.T) {
+		m := defaultTestModel(curTestDir)
+		for _, listening := range []bool{false, true} {
+			ctx, cancel := context.WithCancel(t.Context())
+			errCh := make(chan error, 1)
+			go func() {
+				_, err := m.askPasteConflict(ctx, "src", "dst")
+				errCh <- err
+			}()
+			if listening {
+				// The request is received, but never answered
+				req := <-m.pasteConflictChan
+				assert.Equal(t, "dst", req.dst)
+			}
+			cancel()
+			select {
+			case err := <-errCh:
+				require.ErrorIs(t, err, context.Canceled)
+			case <-time.After(DefaultTestTimeout):
+				t.Fatalf("askPasteConflict did not return after cancel, listening : %v", listening)
+			}
+		}
+	})
+
+	t.Run("Conflict waits for the open dialog", func(t *testing.T) {
+		m := defaultTestModel(curTestDir)
+		m.notifyModel = notify.New(true, "Other", "Other dialog", notify.NoAction)
+		req := newReq(nil)
+		assert.Nil(t, m.openPasteConflictModal(req))
+		assert.False(t, m.notifyModel.IsMultiChoice(), "Open dialog should not be replaced")
+
+		m.notifyModelOpenKey(common.Hotkeys.Confirm[0])
+		require.True(t, m.notifyModel.IsOpen())
+		require.True(t, m.notifyModel.IsMultiChoice(), "Conflict dialog should open once the other one closes")
+
+		assert.NotNil(t, m.notifyModelOpenKey(common.Hotkeys.CancelTyping[0]))
+		assert.Equal(t, conflictSkip, (<-req.resp).action)
+		assert.Nil(t, m.pendingPasteConflict)
+	})
+
+	t.Run("Conflict of a cancelled operation is dropped", func(t *testing.T) {
+		m := defaultTestModel(curTestDir)
+		done := make(chan struct{})
+		close(done)
+		assert.NotNil(t, m.openPasteConflictModal(newReq(done)), "Listener should be re-armed")
+		assert.False(t, m.notifyModel.IsOpen())
+		assert.Nil(t, m.pendingPasteConflict)
+	})
+}
+
+func TestPasteWithConflicts(t *testing.T) {
+	curTestDir := t.TempDir()
+	sourceDir := filepath.Join(curTestDir, "source")
+	destDir := filepath.Join(curTestDir, "dest")
+	srcSubDir := filepath.Join(sourceDir, "subdir")
+	dstSubDir := filepath.Join(destDir, "subdir")
+	utils.SetupDirectories(t, sourceDir, destDir, srcSubDir, dstSubDir)
+	utils.SetupFilesWithData(t, []byte("new"), filepath.Join(sourceDir, "file.txt"),
+		filepath.Join(srcSubDir, "existing.txt"))
+	utils.SetupFiles(t, filepath.Join(srcSubDir, "only_in_src.txt"))
+	utils.SetupFilesWithData(t, []byte("old"), filepath.Join(destDir, "file.txt"),
+		filepath.Join(dstSubDir, "existing.txt"))
+
+	setPolicy := func(t *testing.T, policy string) {
+		t.Helper()
+		prevPolicy := common.Config.PasteConflictPolicy
+		common.Config.PasteConflictPolicy = policy
+		t.Cleanup(func() {
+			common.Config.PasteConflictPolicy = prevPolicy
+		})
+	}
+
+	t.Run("Cut directory with overwrite if newer merges and keeps skipped items", func(t *testing.T) {
+		setPolicy(t, common.PasteConflictOverwriteIfNewer)
+		older := time.Now().Add(-time.Hour)
+		require.NoError(t, os.Chtimes(filepath.Join(srcSubDir, "existing.txt"), older, older))
+		m := setupModelAndPerformOperation(t, sourceDir, false, "subdir", nil, true)
+		p := NewTestTeaProgWithEventLoop(t, m)
+		navigateToTargetDir(t, m, sourceDir, destDir)
+		p.SendKey(common.Hotkeys.PasteItems[0])
+
+		assert.Eventually(t, m.notifyModel.IsOpen, DefaultTestTimeout, DefaultTestTick,
+			"Overwriting existing items should be confirmed")
+		assert.Equal(t, common.PastePreflightTitle, m.notifyModel.GetTitle())
+		p.SendKey(common.Hotkeys.Confirm[0])
+		verifyPathNotExistsEventually(t, filepath.Join(srcSubDir, "only_in_src.txt"),
+			"Non conflicting item should be moved")
+		verifyDestinationFiles(t, dstSubDir, []string{"only_in_src.txt"})
+		assert.FileExists(t, filepath.Join(srcSubDir, "existing.txt"), "Skipped item should stay in source")
+		data, err := os.ReadFile(filepath.Join(dstSubDir, "existing.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "old", string(data))
+	})
+
+	t.Run("Ask and overwrite", func(t *testing.T) {
+		setPolicy(t, common.PasteConflictAsk)
+		m := setupModelAndPerformOperation(t, sourceDir, false, "file.txt", nil, false)
+		p := NewTestTeaProgWithEventLoop(t, m)
+		navigateToTargetDir(t, m, sourceDir, destDir)
+		p.SendKey(common.Hotkeys.PasteItems[0])
+
+		assert.Eventually(t, func() bool {
+			return p.getModel().notifyModel.IsOpen() && p.getModel().notifyModel.IsMultiChoice()
+		}, DefaultTestTimeout, DefaultTestTick, "Conflict dialog should open")
+		// The cursor starts on "Overwrite"
+		p.SendKey(common.Hotkeys.Confirm[0])
+
+		assert.Eventually(t, func() bool {
+			data, err := os.ReadFile(filepath.Join(destDir, "file.txt"))
+			return err == nil && string(data) == "new"
+		}, DefaultTestTimeout, DefaultTestTick, "Destination should be overwritten")
+		assert.NoFileExists(t, filepath.Join(destDir, "file(1).txt"))
+	})
+}
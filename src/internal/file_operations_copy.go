@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"context"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// How the content of a file was copied
+type copyStrategy string
+
+const (
+	// Shares the data blocks of the source, until they are modified
+	copyStrategyReflink copyStrategy = "reflink"
+	// Copied by the kernel, without going through superfile's memory
+	copyStrategyCopyFileRange copyStrategy = "copy_file_range"
+	// Only the data is copied, holes are kept as holes
+	copyStrategySparse    copyStrategy = "sparse"
+	copyStrategyReadWrite copyStrategy = "read_write"
+	// The link itself is copied, there is no content
+	copyStrategySymlink copyStrategy = "symlink"
+)
+
+// copyStats counts the files copied with each strategy, to log them once per process
+type copyStats map[copyStrategy]int
+
+// add counts a file copied with strategy. It does nothing if s is nil or strategy is empty.
+func (s copyStats) add(strategy copyStrategy) {
+	if s == nil || strategy == "" {
+		return
+	}
+	s[strategy]++
+}
+
+// String returns the counts sorted by strategy, like "read_write=2, reflink=1"
+func (s copyStats) String() string {
+	res := make([]string, 0, len(s))
+	for strategy, count := range s {
+		res = append(res, string(strategy)+"="+strconv.Itoa(count))
+	}
+	slices.Sort(res)
+	return strings.Join(res, ", ")
+}
+
+// copyWithReadWrite copies src to dst through a buffer. It works with any file,
+// and is the last fallback of copyContents.
+func copyWithReadWrite(ctx context.Context, dst io.Writer, src io.Reader, onProgress func(int64)) error {
+	_, err := io.Copy(dst, &copyReader{ctx: ctx, r: src, onRead: onProgress})
+	return err
+}
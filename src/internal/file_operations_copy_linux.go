@@ -0,0 +1,130 @@
+//go:build linux
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// Bytes copied per copy_file_range call. Small enough to report progress and
+// check for cancellation regularly.
+const copyFileRangeChunkSize = 8 * 1024 * 1024
+
+// copyContents copies the content of src to the empty file dst, with the fastest
+// strategy that works for these files : a reflink, then a sparse copy for sparse
+// files, then copy_file_range, and finally a copy through a buffer.
+func copyContents(ctx context.Context, dst, src *os.File, srcInfo os.FileInfo,
+	onProgress func(int64)) (copyStrategy, error) {
+	err := unix.IoctlFileClone(int(dst.Fd()), int(src.Fd()))
+	if err == nil {
+		reportProgress(onProgress, srcInfo.Size())
+		return copyStrategyReflink, nil
+	}
+	slog.Debug("Cannot reflink file", "path", src.Name(), "error", err)
+
+	if isSparse(srcInfo) {
+		handled, err := copySparse(ctx, dst, src, srcInfo.Size(), onProgress)
+		if handled {
+			return copyStrategySparse, err
+		}
+	}
+
+	handled, err := copyWithCopyFileRange(ctx, dst, src, onProgress)
+	if handled {
+		return copyStrategyCopyFileRange, err
+	}
+	return copyStrategyReadWrite, copyWithReadWrite(ctx, dst, src, onProgress)
+}
+
+// copyWithCopyFileRange copies src to dst in the kernel. handled is false if
+// copy_file_range is not supported for these files, in which case nothing was copied.
+// Files of procfs, sysfs and some FUSE filesystems report a size of 0, and copy_file_range
+// copies nothing from them without error, so they are left to the read/write copy too.
+func copyWithCopyFileRange(ctx context.Context, dst, src *os.File, onProgress func(int64)) (bool, error) {
+	var copied int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return true, err
+		}
+		n, err := unix.CopyFileRange(int(src.Fd()), nil, int(dst.Fd()), nil, copyFileRangeChunkSize, 0)
+		if err != nil {
+			if copied == 0 && isCopyUnsupported(err) {
+				slog.Debug("Cannot use copy_file_range", "path", src.Name(), "error", err)
+				return false, nil
+			}
+			return true, os.NewSyscallError("copy_file_range", err)
+		}
+		if n == 0 {
+			return copied > 0, nil
+		}
+		copied += int64(n)
+		reportProgress(onProgress, int64(n))
+	}
+}
+
+// copySparse copies only the data regions of src, leaving the holes in dst unallocated.
+// handled is false if the filesystem can't report holes, in which case nothing was copied.
+func copySparse(ctx context.Context, dst, src *os.File, size int64, onProgress func(int64)) (bool, error) {
+	fd := int(src.Fd())
+	var offset int64
+	for offset < size {
+		if err := ctx.Err(); err != nil {
+			return true, err
+		}
+		dataStart, err := unix.Seek(fd, offset, unix.SEEK_DATA)
+		if errors.Is(err, unix.ENXIO) {
+			// Only a hole is left
+			dataStart = size
+		} else if err != nil {
+			if offset == 0 && isCopyUnsupported(err) {
+				slog.Debug("Cannot find holes of sparse file", "path", src.Name(), "error", err)
+				return false, nil
+			}
+			return true, os.NewSyscallError("lseek", err)
+		}
+		dataEnd := size
+		if dataStart < size {
+			if dataEnd, err = unix.Seek(fd, dataStart, unix.SEEK_HOLE); err != nil {
+				return true, os.NewSyscallError("lseek", err)
+			}
+		}
+		// Holes count as copied
+		reportProgress(onProgress, dataStart-offset)
+
+		err = copyWithReadWrite(ctx, io.NewOffsetWriter(dst, dataStart),
+			io.NewSectionReader(src, dataStart, dataEnd-dataStart), onProgress)
+		if err != nil {
+			return true, err
+		}
+		offset = dataEnd
+	}
+	// Extends dst if it ends with a hole
+	return true, dst.Truncate(size)
+}
+
+// isSparse reports whether the file uses less disk space than its size
+func isSparse(info os.FileInfo) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	// Blocks are always 512 bytes, whatever the block size of the filesystem
+	return ok && stat.Blocks*512 < info.Size()
+}
+
+// isCopyUnsupported reports whether err means that a copy strategy can't
+// be used for these files, and that another one should be tried.
+func isCopyUnsupported(err error) bool {
+	return errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EXDEV) ||
+		errors.Is(err, unix.EINVAL) || errors.Is(err, unix.EOPNOTSUPP)
+}
+
+func reportProgress(onProgress func(int64), n int64) {
+	if onProgress != nil && n > 0 {
+		onProgress(n)
+	}
+}
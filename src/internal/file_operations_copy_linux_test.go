@@ -0,0 +1,142 @@
+//go:build linux
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yorukot/superfile/src/internal/utils"
+)
+
+func TestCopyContents(t *testing.T) {
+	curTestDir := t.TempDir()
+	data := bytes.Repeat([]byte("superfile"), 100000)
+	regularFile := filepath.Join(curTestDir, "regular")
+	utils.SetupFilesWithData(t, data, regularFile)
+
+	// 8 MiB, with data only in the middle
+	const sparseSize = 8 * 1024 * 1024
+	sparseFile := filepath.Join(curTestDir, "sparse")
+	f, err := os.Create(sparseFile)
+	require.NoError(t, err)
+	_, err = f.WriteAt(data, sparseSize/2)
+	require.NoError(t, err)
+	require.NoError(t, f.Truncate(sparseSize))
+	require.NoError(t, f.Close())
+
+	// Runs copy on opened files, and returns the content of the copy, and the progress reported
+	runCopy := func(t *testing.T, src string,
+		copy func(dst, src *os.File, info os.FileInfo, onProgress func(int64)) error) ([]byte, int64) {
+		t.Helper()
+		srcFile, err := os.Open(src)
+		require.NoError(t, err)
+		defer srcFile.Close()
+		info, err := srcFile.Stat()
+		require.NoError(t, err)
+		dst := filepath.Join(t.TempDir(), "dst")
+		dstFile, err := os.Create(dst)
+		require.NoError(t, err)
+		defer dstFile.Close()
+
+		var progress int64
+		require.NoError(t, copy(dstFile, srcFile, info, func(n int64) {
+			progress += n
+		}))
+		res, err := os.ReadFile(dst)
+		require.NoError(t, err)
+		return res, progress
+	}
+
+	expectedSparse, err := os.ReadFile(sparseFile)
+	require.NoError(t, err)
+
+	t.Run("copy_file_range", func(t *testing.T) {
+		res, progress := runCopy(t, regularFile, func(dst, src *os.File, _ os.FileInfo, onProgress func(int64)) error {
+			handled, err := copyWithCopyFileRange(context.Background(), dst, src, onProgress)
+			if !handled {
+				t.Skip("copy_file_range is not supported here")
+			}
+			return err
+		})
+		assert.Equal(t, data, res)
+		assert.Equal(t, int64(len(data)), progress)
+	})
+
+	t.Run("Sparse copy", func(t *testing.T) {
+		if info, err := os.Stat(sparseFile); err != nil || !isSparse(info) {
+			t.Skip("The filesystem doesn't support sparse files")
+		}
+		res, progress := runCopy(t, sparseFile, func(dst, src *os.File, info os.FileInfo, onProgress func(int64)) error {
+			handled, err := copySparse(context.Background(), dst, src, info.Size(), onProgress)
+			if !handled {
+				t.Skip("Holes can't be found on this filesystem")
+			}
+			if err == nil {
+				dstInfo, statErr := dst.Stat()
+				require.NoError(t, statErr)
+				assert.True(t, isSparse(dstInfo), "Copy should stay sparse")
+			}
+			return err
+		})
+		assert.Equal(t, expectedSparse, res)
+		assert.Equal(t, int64(sparseSize), progress)
+	})
+
+	t.Run("Fallback chain", func(t *testing.T) {
+		var strategy copyStrategy
+		res, progress := runCopy(t, sparseFile, func(dst, src *os.File, info os.FileInfo, onProgress func(int64)) error {
+			var err error
+			strategy, err = copyContents(context.Background(), dst, src, info, onProgress)
+			return err
+		})
+		assert.Equal(t, expectedSparse, res)
+		assert.Equal(t, int64(sparseSize), progress)
+		assert.NotEmpty(t, strategy)
+	})
+
+	t.Run("Pseudo file of size 0", func(t *testing.T) {
+		expected, err := os.ReadFile("/proc/version")
+		if err != nil || len(expected) == 0 {
+			t.Skip("/proc/version is not available")
+		}
+		res, _ := runCopy(t, "/proc/version", func(dst, src *os.File, info os.FileInfo, onProgress func(int64)) error {
+			_, err := copyContents(context.Background(), dst, src, info, onProgress)
+			return err
+		})
+		assert.Equal(t, expected, res, "Content should be copied, even though the file reports a size of 0")
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		srcFile, err := os.Open(regularFile)
+		require.NoError(t, err)
+		defer srcFile.Close()
+		dstFile, err := os.Create(filepath.Join(t.TempDir(), "dst"))
+		require.NoError(t, err)
+		defer dstFile.Close()
+		_, err = copyWithCopyFileRange(ctx, dstFile, srcFile, nil)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestCopyStats(t *testing.T) {
+	stats := copyStats{}
+	stats.add(copyStrategyReflink)
+	stats.add(copyStrategyReadWrite)
+	stats.add(copyStrategyReadWrite)
+	stats.add("")
+	assert.Equal(t, "read_write=2, reflink=1", stats.String())
+
+	var nilStats copyStats
+	assert.NotPanics(t, func() {
+		nilStats.add(copyStrategyReflink)
+	})
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package internal
+
+import (
+	"context"
+	"os"
+)
+
+// copyContents copies the content of src to the empty file dst. Other systems than Linux
+// always copy through a buffer.
+func copyContents(ctx context.Context, dst, src *os.File, _ os.FileInfo,
+	onProgress func(int64)) (copyStrategy, error) {
+	return copyStrategyReadWrite, copyWithReadWrite(ctx, dst, src, onProgress)
+}
@@ -0,0 +1,92 @@
+//go:build linux
+
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yorukot/superfile/src/internal/utils"
+)
+
+func TestIsSamePartition(t *testing.T) {
+	curTestDir := t.TempDir()
+	file := filepath.Join(curTestDir, "file.txt")
+	utils.SetupFiles(t, file)
+
+	testdata := []struct {
+		name     string
+		path1    string
+		path2    string
+		expected bool
+		hasErr   bool
+	}{
+		{name: "Same directory", path1: file, path2: filepath.Join(curTestDir, "other.txt"), expected: true},
+		{name: "Missing parents of destination", path1: file,
+			path2: filepath.Join(curTestDir, "a", "b", "file.txt"), expected: true},
+		{name: "Other filesystem", path1: file, path2: "/proc/version", expected: false},
+		{name: "Missing source", path1: filepath.Join(curTestDir, "missing"), path2: file, hasErr: true},
+	}
+	for _, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			sameDev, err := isSamePartition(tt.path1, tt.path2)
+			if tt.hasErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, sameDev)
+			assert.Equal(t, tt.expected, deviceKey(tt.path1) == deviceKey(tt.path2),
+				"Operations should be queued together only on the same device")
+		})
+	}
+}
+
+func TestMoveAcrossDevices(t *testing.T) {
+	otherDev, err := os.MkdirTemp("/dev/shm", "superfile-test-")
+	if err != nil {
+		t.Skip("/dev/shm is not available")
+	}
+	t.Cleanup(func() { os.RemoveAll(otherDev) })
+	srcDir := filepath.Join(t.TempDir(), "src")
+	utils.SetupDirectories(t, srcDir)
+	if sameDev, err := isSamePartition(srcDir, otherDev); err != nil || sameDev {
+		t.Skip("/dev/shm is on the same device as the test directory")
+	}
+	utils.SetupFilesWithData(t, []byte("Content of file"), filepath.Join(srcDir, "file.txt"))
+
+	dst := filepath.Join(otherDev, "src")
+	var copiedBytes int64
+	require.NoError(t, moveElement(context.Background(), srcDir, dst, nil, func(n int64) { copiedBytes += n }))
+	assert.NoDirExists(t, srcDir)
+	assert.Equal(t, int64(len("Content of file")), copiedBytes, "Progress should be reported in bytes")
+	data, err := os.ReadFile(filepath.Join(dst, "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "Content of file", string(data))
+}
+
+func TestCancelledMoveAcrossDevices(t *testing.T) {
+	otherDev, err := os.MkdirTemp("/dev/shm", "superfile-test-")
+	if err != nil {
+		t.Skip("/dev/shm is not available")
+	}
+	t.Cleanup(func() { os.RemoveAll(otherDev) })
+	srcDir := filepath.Join(t.TempDir(), "src")
+	utils.SetupDirectories(t, srcDir)
+	if sameDev, err := isSamePartition(srcDir, otherDev); err != nil || sameDev {
+		t.Skip("/dev/shm is on the same device as the test directory")
+	}
+	utils.SetupFiles(t, filepath.Join(srcDir, "file.txt"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dst := filepath.Join(otherDev, "src")
+	require.ErrorIs(t, moveElement(ctx, srcDir, dst, nil, nil), context.Canceled)
+	assert.FileExists(t, filepath.Join(srcDir, "file.txt"))
+	assert.NoDirExists(t, dst, "The partial copy should be removed")
+}
@@ -0,0 +1,20 @@
+//go:build !linux && !darwin
+
+package internal
+
+import "path/filepath"
+
+// sameDevice can't compare devices on other systems. Moves try a rename, and copy the
+// items if it fails with EXDEV.
+func sameDevice(_, _ string) (bool, error) {
+	return true, nil
+}
+
+// deviceKey identifies the device of path by its volume name, like "C:" on Windows, for
+// the operations writing to the same device to be queued together
+func deviceKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return filepath.VolumeName(path)
+}
@@ -0,0 +1,60 @@
+//go:build linux || darwin
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// sameDevice compares the device IDs of path1 and of the closest existing path to path2,
+// as path2 is usually the destination of a move, that doesn't exist yet
+func sameDevice(path1, path2 string) (bool, error) {
+	info1, err := os.Lstat(path1)
+	if err != nil {
+		return false, err
+	}
+	info2, err := statClosestExisting(path2)
+	if err != nil {
+		return false, err
+	}
+	stat1, ok1 := info1.Sys().(*syscall.Stat_t)
+	stat2, ok2 := info2.Sys().(*syscall.Stat_t)
+	if !ok1 || !ok2 {
+		return true, nil
+	}
+	return stat1.Dev == stat2.Dev, nil
+}
+
+// deviceKey identifies the device of path, or of its closest existing parent, for the
+// operations writing to the same device to be queued together
+func deviceKey(path string) string {
+	info, err := statClosestExisting(path)
+	if err != nil {
+		slog.Debug("Cannot get the device of a path", "path", path, "error", err)
+		return ""
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprint(stat.Dev)
+}
+
+func statClosestExisting(path string) (os.FileInfo, error) {
+	for {
+		info, err := os.Stat(path)
+		if !errors.Is(err, os.ErrNotExist) {
+			return info, err
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return nil, err
+		}
+		path = parent
+	}
+}
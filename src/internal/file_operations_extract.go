@@ -1,44 +1,481 @@
 package internal
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
 	"time"
 
 	"golift.io/xtractr"
 
 	"github.com/yorukot/superfile/src/config/icon"
+	"github.com/yorukot/superfile/src/internal/common"
 	"github.com/yorukot/superfile/src/internal/ui/processbar"
 )
 
-func extractCompressFile(src, dest string, processBar *processbar.Model) error {
-	p, err := processBar.SendAddProcessMsg(icon.ExtractFile+icon.Space+"unzip file", 1, true)
+// Prefix of the directories archives are extracted into, before their items are placed
+// in the destination
+const extractTempDirPrefix = ".superfile-extract-"
+
+// errExtractRetried is wrapped by the error of an extraction that is run again in a new
+// process, once the user gives a password. Its process is removed instead of failing.
+var errExtractRetried = errors.New("extraction is retried with a password")
+
+// extractMode is where the items of an archive are placed in the destination
+type extractMode int
+
+const (
+	// In a directory named after the archive, unless the archive only has a single
+	// top-level directory, that is placed as is
+	extractSmart extractMode = iota
+	// Directly in the destination, next to its other items
+	extractHere
+)
+
+// extractRequest is the extraction of archives into destDir, as one process
+type extractRequest struct {
+	archives []string
+	destDir  string
+	mode     extractMode
+}
+
+// extractArchives extracts the archives of req as one process. Each archive is extracted
+// into a directory of its own first, so that a cancelled or failed extraction leaves
+// nothing behind, and its items are then placed in req.destDir according to req.mode.
+// Encrypted archives are decrypted with their password in passwords, by archiveSetName.
+// On a password error, it returns the archives that are not extracted yet, starting with
+// the one that needs a password. They are extracted again in a new process once the user
+// gives the password, so the process only keeps the archives extracted before, and is
+// removed if there are none.
+func extractArchives(processBar *processbar.Model, req extractRequest, passwords map[string]string,
+	resolver *pasteConflictResolver) ([]string, error) {
+	// Passwords are checked before anything is extracted, when it is possible
+	for _, archive := range req.archives {
+		err := checkArchivePassword(archive, archiveFormatOf(archive), passwords[archiveSetName(archive)])
+		if err != nil {
+			return req.archives, err
+		}
+	}
+
+	var remaining []string
+	var passwordErr error
+	_, err := runExtractProcess(processBar, "Extracting "+filepath.Base(req.archives[0]), req.archives, req.destDir,
+		func(ctx context.Context) <-chan pasteTotals {
+			return getArchivesTotalsInBackground(ctx, req.archives)
+		},
+		func(ctx context.Context, progress *pasteProgress) error {
+			for i, archive := range req.archives {
+				err := extractAndPlace(ctx, archive, req.destDir, req.mode, passwords[archiveSetName(archive)],
+					resolver, progress)
+				if err != nil {
+					err = fmt.Errorf("cannot extract %s: %w", filepath.Base(archive), err)
+				}
+				if isArchivePasswordError(err) {
+					remaining = req.archives[i:]
+					if i == 0 {
+						return fmt.Errorf("%w: %w", errExtractRetried, err)
+					}
+					passwordErr = err
+					progress.setSources(req.archives[:i])
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	if passwordErr != nil {
+		return remaining, passwordErr
+	}
+	return remaining, err
+}
+
+// extractAndPlace extracts archive into a new directory inside destDir, and places its
+// items in destDir according to mode
+func extractAndPlace(ctx context.Context, archive string, destDir string, mode extractMode, password string,
+	resolver *pasteConflictResolver, progress *pasteProgress) error {
+	tmpDir, err := os.MkdirTemp(destDir, extractTempDirPrefix)
 	if err != nil {
-		return fmt.Errorf("cannot spawn process : %w", err)
+		return err
+	}
+	// It becomes the directory named after the archive, which shouldn't be private
+	if err = os.Chmod(tmpDir, 0o755); err != nil {
+		return err
 	}
+	// Nothing is left when the items are placed, except if it failed
+	defer removeExtractOutput(tmpDir)
 
-	x := &xtractr.XFile{
-		FilePath:  src,
-		OutputDir: dest,
-		FileMode:  0644,
-		DirMode:   0755,
+	if err = extractInto(ctx, archive, tmpDir, password, resolver, progress); err != nil {
+		return err
+	}
+	switch mode {
+	case extractSmart:
+		return placeExtractedSmart(archive, tmpDir, destDir)
+	case extractHere:
+		return placeExtractedHere(ctx, tmpDir, destDir, resolver)
 	}
+	return nil
+}
 
-	_, _, _, err = xtractr.ExtractFile(x)
+// placeExtractedSmart moves the items extracted into tmpDir to a directory named after
+// the archive, or places the only directory of the archive as is, to avoid a redundant
+// wrapper
+func placeExtractedSmart(archive string, tmpDir string, destDir string) error {
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		return err
+	}
+	src := tmpDir
+	name := common.FileNameWithoutExtension(filepath.Base(archive))
+	if len(entries) == 1 && entries[0].IsDir() {
+		src = filepath.Join(tmpDir, entries[0].Name())
+		name = entries[0].Name()
+	}
+	dst, err := renameIfDuplicate(filepath.Join(destDir, name))
+	if err != nil {
+		return err
+	}
+	return os.Rename(src, dst)
+}
+
+// placeExtractedHere moves the items extracted into tmpDir to destDir, resolving the
+// ones that already exist with resolver
+func placeExtractedHere(ctx context.Context, tmpDir string, destDir string,
+	resolver *pasteConflictResolver) error {
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		src := filepath.Join(tmpDir, entry.Name())
+		dst := filepath.Join(destDir, entry.Name())
+		// Directories are merged into existing ones, like the ones of the archive
+		if dstInfo, statErr := os.Lstat(dst); statErr == nil && dstInfo.IsDir() && entry.IsDir() {
+			if err = placeExtractedHere(ctx, src, dst, resolver); err != nil {
+				return err
+			}
+			continue
+		}
+		dst, skip, err := resolver.resolve(ctx, src, dst)
+		if err != nil {
+			return err
+		}
+		if skip {
+			continue
+		}
+		if err = os.RemoveAll(dst); err != nil {
+			return err
+		}
+		if err = os.Rename(src, dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+func removeExtractOutput(dir string) {
+	if err := os.RemoveAll(dir); err != nil {
+		slog.Error("Error removing output of extraction", "dir", dir, "error", err)
+	}
+}
+
+// runExtractProcess runs extract of the archives sources in a process called name, queued
+// with the other operations on the device of dest, whose totals are counted by totals. It
+// returns the state it ended with. If extract fails with errExtractRetried, the process is
+// removed instead.
+func runExtractProcess(processBar *processbar.Model, name string, sources []string, dest string,
+	totals func(ctx context.Context) <-chan pasteTotals,
+	extract func(ctx context.Context, progress *pasteProgress) error) (processbar.ProcessState, error) {
+	p, ctx, err := processBar.SendAddQueuedProcessMsg(icon.ExtractFile+icon.Space+name, 0, deviceKey(dest))
+	if errors.Is(err, context.Canceled) {
+		return processbar.Cancelled, err
+	}
 	if err != nil {
+		return processbar.Failed, fmt.Errorf("cannot spawn process : %w", err)
+	}
+	p.Sources = sources
+	p.Destination = dest
+	progress := newPasteProgress(&p, processBar, totals(ctx))
+
+	err = extract(ctx, progress)
+	switch {
+	case errors.Is(err, errExtractRetried):
+		progress.finish()
+		processBar.SendRemoveProcessMsg(p.ID)
+		return processbar.Cancelled, err
+	case errors.Is(err, context.Canceled):
+		p.State = processbar.Cancelled
+	case err != nil:
 		p.State = processbar.Failed
-		slog.Error("Error extracting", "path", src, "error", err)
-	} else {
+		p.ErrorMsg = err.Error()
+		slog.Error("Error extracting", "name", name, "error", err)
+	default:
 		p.State = processbar.Successful
-		p.Done = 1
 	}
-
+	progress.finish()
 	p.DoneTime = time.Now()
-	pSendErr := processBar.SendUpdateProcessMsg(p, true)
-	if pSendErr != nil {
+	if pSendErr := processBar.SendUpdateProcessMsg(p, true); pSendErr != nil {
 		slog.Error("Error sending process update", "error", pSendErr)
 	}
+	return p.State, err
+}
+
+// extractInto extracts all the entries of the archive src into dest
+func extractInto(ctx context.Context, src string, dest string, password string,
+	resolver *pasteConflictResolver, progress *pasteProgress) error {
+	format := archiveFormatOf(src)
+	if format != "" {
+		return extractArchive(ctx, src, format, password, dest, selectAllEntries, resolver, progress)
+	}
+	progress.setCurrentItem(icon.ExtractFile + icon.Space + filepath.Base(src))
+	if err := extractWithXtractr(ctx, src, dest, password); err != nil {
+		return err
+	}
+	// xtractr can't report progress, its archives count as a single file
+	progress.fileDone(0)
+	return nil
+}
+
+// getArchiveTotalsInBackground counts the entries and bytes chosen by selection of the
+// archive at src in a separate goroutine, so that the extraction can start right away
+func getArchiveTotalsInBackground(ctx context.Context, src string, format string,
+	selection archiveSelection) <-chan pasteTotals {
+	res := make(chan pasteTotals, 1)
+	go func() {
+		res <- countArchiveTotals(ctx, src, format, selection)
+	}()
+	return res
+}
+
+// getArchivesTotalsInBackground is like getArchiveTotalsInBackground, for all the
+// entries of several archives
+func getArchivesTotalsInBackground(ctx context.Context, archives []string) <-chan pasteTotals {
+	res := make(chan pasteTotals, 1)
+	go func() {
+		var totals pasteTotals
+		for _, archive := range archives {
+			format := archiveFormatOf(archive)
+			if format == "" {
+				totals.files++
+				continue
+			}
+			archiveTotals := countArchiveTotals(ctx, archive, format, selectAllEntries)
+			totals.files += archiveTotals.files
+			totals.bytes += archiveTotals.bytes
+		}
+		res <- totals
+	}()
+	return res
+}
+
+func countArchiveTotals(ctx context.Context, src string, format string, selection archiveSelection) pasteTotals {
+	entries, err := listArchive(ctx, src, format)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		slog.Error("Error while listing archive", "path", src, "error", err)
+	}
+	var totals pasteTotals
+	for _, entry := range entries {
+		if _, ok := selection(entry.name); !ok {
+			continue
+		}
+		totals.files++
+		if entry.info.Mode().IsRegular() {
+			totals.bytes += entry.info.Size()
+		}
+	}
+	return totals
+}
+
+// extractArchive extracts the entries of the archive src chosen by selection into dest,
+// keeping their permissions and modification times. Links are created once all other
+// entries are extracted, so that no entry can be written through a link of the archive.
+func extractArchive(ctx context.Context, src string, format string, password string, dest string,
+	selection archiveSelection, resolver *pasteConflictResolver, progress *pasteProgress) error {
+	var links []archiveEntry
+	var dirs []archiveEntry
+	err := walkArchive(ctx, src, format, password, func(entry archiveEntry, r io.Reader) error {
+		var ok bool
+		if entry.name, ok = selection(entry.name); !ok {
+			return nil
+		}
+		if entry.hardlink {
+			linkname := entry.linkname
+			if entry.linkname, ok = selection(linkname); !ok {
+				return fmt.Errorf("cannot extract %s: target %s of the hardlink is not extracted", entry.name, linkname)
+			}
+		}
+		progress.setCurrentItem(icon.ExtractFile + icon.Space + path.Base(entry.name))
+		target, err := archiveEntryPath(dest, entry.name)
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.info.IsDir():
+			if err = extractDir(target); err != nil {
+				return err
+			}
+			dirs = append(dirs, entry)
+			progress.fileDone(0)
+		case entry.linkname != "":
+			links = append(links, entry)
+		case !entry.info.Mode().IsRegular():
+			// Devices and named pipes could not be created without privileges anyway
+			slog.Warn("Skipping special file of archive", "archive", src, "entry", entry.name,
+				"mode", entry.info.Mode())
+			progress.addWarning(entry.name + ": devices and named pipes are not extracted")
+			progress.fileDone(0)
+		default:
+			err = extractFile(ctx, src, entry, r, target, resolver, progress)
+			if err != nil {
+				return fmt.Errorf("cannot extract %s: %w", entry.name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range links {
+		if err = extractLink(ctx, src, entry, dest, resolver); err != nil {
+			return fmt.Errorf("cannot extract %s: %w", entry.name, err)
+		}
+		progress.fileDone(0)
+	}
+
+	// Contents are done, so the times of directories are not changed anymore. Read-only
+	// directories get their mode only now, as they were written to.
+	for _, entry := range slices.Backward(dirs) {
+		target, _ := archiveEntryPath(dest, entry.name)
+		if err = os.Chmod(target, entry.info.Mode().Perm()); err != nil {
+			slog.Error("Error while setting mode of extracted directory", "path", target, "error", err)
+		}
+		if err = os.Chtimes(target, time.Time{}, entry.info.ModTime()); err != nil {
+			slog.Error("Error while setting time of extracted directory", "path", target, "error", err)
+		}
+	}
+	return nil
+}
+
+// Directories of the archive are merged into existing ones
+func extractDir(target string) error {
+	if info, err := os.Lstat(target); err == nil && !info.IsDir() {
+		if err = os.Remove(target); err != nil {
+			return err
+		}
+	}
+	return os.MkdirAll(target, 0o755)
+}
+
+func extractFile(ctx context.Context, src string, entry archiveEntry, r io.Reader, target string,
+	resolver *pasteConflictResolver, progress *pasteProgress) error {
+	size := entry.info.Size()
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	dst, skip, err := resolver.resolveInfo(ctx, filepath.Join(src, entry.name), entry.info, target)
+	if err != nil {
+		return err
+	}
+	if skip {
+		progress.fileDone(size)
+		return nil
+	}
+	// An existing file is replaced, and not written into, like with tar. It may be read-only,
+	// or a link to a file outside of the destination.
+	if err = os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	f, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	var copied int64
+	_, err = io.Copy(f, &copyReader{ctx: ctx, r: r, onRead: func(n int64) {
+		copied += n
+		progress.addBytes(n)
+	}})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		progress.fileFailed(copied)
+		return err
+	}
+	progress.fileDone(size - copied)
 
+	// The mode is set explicitly, as the umask would change it
+	if err = os.Chmod(dst, entry.info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, time.Time{}, entry.info.ModTime())
+}
+
+func extractLink(ctx context.Context, src string, entry archiveEntry, dest string,
+	resolver *pasteConflictResolver) error {
+	target, err := archiveEntryPath(dest, entry.name)
+	if err != nil {
+		return err
+	}
+	// The directories of the link could be links extracted before it
+	if err = checkInsideDest(dest, filepath.Dir(target)); err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	dst, skip, err := resolver.resolveInfo(ctx, filepath.Join(src, entry.name), entry.info, target)
+	if err != nil || skip {
+		return err
+	}
+	if err = os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if !entry.hardlink {
+		return os.Symlink(entry.linkname, dst)
+	}
+	// The target of a hardlink is another entry of the archive, which must not be reached
+	// through an extracted symlink, as the hardlink would then give access to any file
+	linkTarget, err := archiveEntryPath(dest, entry.linkname)
+	if err != nil {
+		return err
+	}
+	if err = checkInsideDest(dest, linkTarget); err != nil {
+		return err
+	}
+	return os.Link(linkTarget, dst)
+}
+
+// extractWithXtractr extracts the archives that superfile doesn't read itself, like rar
+// and 7z, with xtractr
+func extractWithXtractr(ctx context.Context, src, dest string, password string) error {
+	x := &xtractr.XFile{
+		FilePath:  src,
+		OutputDir: dest,
+		FileMode:  0644,
+		DirMode:   0755,
+		Password:  password,
+	}
+
+	// xtractr cannot be interrupted, so a cancellation only takes effect once it is done.
+	// It must not be writing anymore when the caller removes dest.
+	_, _, _, err := xtractr.ExtractFile(x)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	switch {
+	case err != nil && isPasswordError(err) && password == "":
+		return fmt.Errorf("%w: %w", errArchivePasswordRequired, err)
+	case err != nil && isPasswordError(err):
+		return fmt.Errorf("%w: %w", errArchiveWrongPassword, err)
+	}
 	return err
 }
@@ -0,0 +1,478 @@
+package internal
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+	"github.com/yorukot/superfile/src/internal/utils"
+)
+
+func TestExtractArchiveFormats(t *testing.T) {
+	processBar := processbar.New()
+	processBar.ListenForChannelUpdates()
+	t.Cleanup(processBar.SendStopListeningMsgBlocking)
+	srcDir := filepath.Join(t.TempDir(), "src")
+	utils.SetupDirectories(t, srcDir, filepath.Join(srcDir, "sub"))
+	utils.SetupFilesWithData(t, []byte("Content of script"), filepath.Join(srcDir, "script.sh"))
+	utils.SetupFilesWithData(t, []byte("Content of file"), filepath.Join(srcDir, "sub", "file.txt"))
+	require.NoError(t, os.Chmod(filepath.Join(srcDir, "script.sh"), 0o750))
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, os.Chtimes(filepath.Join(srcDir, "sub", "file.txt"), modTime, modTime))
+
+	formats := []string{common.CompressFormatZip, common.CompressFormatTar, common.CompressFormatTarGz,
+		common.CompressFormatTarXz, common.CompressFormatTarZst}
+	for _, format := range formats {
+		t.Run(format, func(t *testing.T) {
+			archive := filepath.Join(t.TempDir(), "src."+format)
+			require.NoError(t, compressSources([]string{srcDir}, archive, format, &processBar))
+			dest := t.TempDir()
+
+			_, err := extractArchives(&processBar, extractRequest{archives: []string{archive}, destDir: dest,
+				mode: extractHere}, nil, newPasteConflictResolver(common.PasteConflictAsk, nil))
+			require.NoError(t, err)
+
+			data, err := os.ReadFile(filepath.Join(dest, "src", "sub", "file.txt"))
+			require.NoError(t, err)
+			assert.Equal(t, "Content of file", string(data))
+			info, err := os.Stat(filepath.Join(dest, "src", "sub", "file.txt"))
+			require.NoError(t, err)
+			assert.True(t, modTime.Equal(info.ModTime()), "modification time should be kept, got %v", info.ModTime())
+			if runtime.GOOS != utils.OsWindows {
+				info, err = os.Stat(filepath.Join(dest, "src", "script.sh"))
+				require.NoError(t, err)
+				assert.Equal(t, os.FileMode(0o750), info.Mode().Perm(), "permissions should be kept")
+			}
+		})
+	}
+}
+
+func TestExtractArchiveUnsafePath(t *testing.T) {
+	processBar := processbar.New()
+	curTestDir := t.TempDir()
+	archive := filepath.Join(curTestDir, "evil.zip")
+	f, err := os.Create(archive)
+	require.NoError(t, err)
+	writer := zip.NewWriter(f)
+	w, err := writer.Create("../evil.txt")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("evil"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	require.NoError(t, f.Close())
+	dest := filepath.Join(curTestDir, "dest")
+	utils.SetupDirectories(t, dest)
+
+	_, err = extractArchives(&processBar, extractRequest{archives: []string{archive}, destDir: dest,
+		mode: extractHere}, nil, newPasteConflictResolver(common.PasteConflictAsk, nil))
+	require.ErrorIs(t, err, errUnsafeArchivePath)
+	assert.NoFileExists(t, filepath.Join(curTestDir, "evil.txt"))
+	assert.NoFileExists(t, filepath.Join(dest, "evil.txt"))
+}
+
+func TestExtractArchiveLinkEscape(t *testing.T) {
+	if runtime.GOOS == utils.OsWindows {
+		t.Skip("Creating symlinks needs privileges on windows")
+	}
+	curTestDir := t.TempDir()
+	outside := filepath.Join(curTestDir, "outside")
+	utils.SetupDirectories(t, outside)
+	utils.SetupFilesWithData(t, []byte("secret"), filepath.Join(outside, "secret.txt"))
+
+	testdata := []struct {
+		name    string
+		entries []*tar.Header
+	}{
+		{
+			name: "Hardlink through a symlink",
+			entries: []*tar.Header{
+				{Typeflag: tar.TypeSymlink, Name: "out", Linkname: outside},
+				{Typeflag: tar.TypeLink, Name: "secret.txt", Linkname: "out/secret.txt"},
+			},
+		},
+		{
+			name: "Symlink in a symlinked directory",
+			entries: []*tar.Header{
+				{Typeflag: tar.TypeSymlink, Name: "out", Linkname: outside},
+				{Typeflag: tar.TypeSymlink, Name: "out/evil", Linkname: "/"},
+			},
+		},
+	}
+	for i, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := filepath.Join(curTestDir, fmt.Sprintf("evil%d.tar", i))
+			f, err := os.Create(archive)
+			require.NoError(t, err)
+			writer := tar.NewWriter(f)
+			for _, header := range tt.entries {
+				header.Mode = 0o777
+				require.NoError(t, writer.WriteHeader(header))
+			}
+			require.NoError(t, writer.Close())
+			require.NoError(t, f.Close())
+			dest := filepath.Join(curTestDir, fmt.Sprintf("dest%d", i))
+			utils.SetupDirectories(t, dest)
+
+			processBar := processbar.New()
+			p := processbar.NewProcess("1", "extract", 0)
+			err = extractArchive(t.Context(), archive, common.CompressFormatTar, "", dest, selectAllEntries,
+				newPasteConflictResolver(common.PasteConflictAsk, nil), newPasteProgress(&p, &processBar, nil))
+			require.ErrorIs(t, err, errUnsafeArchivePath)
+			assert.NoFileExists(t, filepath.Join(dest, "secret.txt"))
+			assert.NoFileExists(t, filepath.Join(outside, "evil"))
+		})
+	}
+}
+
+func TestExtractArchiveSpecialFiles(t *testing.T) {
+	curTestDir := t.TempDir()
+	archive := filepath.Join(curTestDir, "special.tar")
+	f, err := os.Create(archive)
+	require.NoError(t, err)
+	writer := tar.NewWriter(f)
+	for _, header := range []*tar.Header{
+		{Typeflag: tar.TypeChar, Name: "null", Devmajor: 1, Devminor: 3},
+		{Typeflag: tar.TypeBlock, Name: "disk", Devmajor: 8},
+		{Typeflag: tar.TypeFifo, Name: "pipe"},
+	} {
+		header.Mode = 0o644
+		require.NoError(t, writer.WriteHeader(header))
+	}
+	require.NoError(t, writer.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "file.txt", Mode: 0o644, Size: 4}))
+	_, err = writer.Write([]byte("data"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	require.NoError(t, f.Close())
+	dest := filepath.Join(curTestDir, "dest")
+	utils.SetupDirectories(t, dest)
+
+	processBar := processbar.New()
+	p := processbar.NewProcess("1", "extract", 0)
+	err = extractArchive(t.Context(), archive, common.CompressFormatTar, "", dest, selectAllEntries,
+		newPasteConflictResolver(common.PasteConflictAsk, nil), newPasteProgress(&p, &processBar, nil))
+	require.NoError(t, err)
+	for _, name := range []string{"null", "disk", "pipe"} {
+		_, err = os.Lstat(filepath.Join(dest, name))
+		require.ErrorIs(t, err, os.ErrNotExist, "%s should not be extracted", name)
+	}
+	data, err := os.ReadFile(filepath.Join(dest, "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+	assert.Len(t, p.Warnings, 3)
+	assert.Equal(t, 4, p.Done)
+}
+
+func TestExtractArchiveConflict(t *testing.T) {
+	processBar := processbar.New()
+	srcDir := filepath.Join(t.TempDir(), "src")
+	utils.SetupDirectories(t, srcDir)
+	utils.SetupFilesWithData(t, []byte("new"), filepath.Join(srcDir, "file.txt"))
+	archive := filepath.Join(t.TempDir(), "src.tar")
+	require.NoError(t, compressSources([]string{srcDir}, archive, common.CompressFormatTar, &processBar))
+
+	testdata := []struct {
+		name     string
+		action   pasteConflictAction
+		expected string
+		kept     string
+	}{
+		{name: "Overwrite", action: conflictOverwrite, expected: "new"},
+		{name: "Skip", action: conflictSkip, expected: "old"},
+		{name: "Keep both", action: conflictKeepBoth, expected: "old", kept: "new"},
+	}
+	for _, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := t.TempDir()
+			existing := filepath.Join(dest, "src", "file.txt")
+			utils.SetupDirectories(t, filepath.Dir(existing))
+			utils.SetupFilesWithData(t, []byte("old"), existing)
+			asked := 0
+			resolver := newPasteConflictResolver(common.PasteConflictAsk,
+				func(_ context.Context, _, dst string) (pasteConflictResp, error) {
+					asked++
+					assert.Equal(t, existing, dst)
+					return pasteConflictResp{action: tt.action}, nil
+				})
+
+			_, err := extractArchives(&processBar, extractRequest{archives: []string{archive}, destDir: dest,
+				mode: extractHere}, nil, resolver)
+			require.NoError(t, err)
+			assert.Equal(t, 1, asked, "only the existing file should be a conflict")
+			data, err := os.ReadFile(existing)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(data))
+			if tt.kept != "" {
+				data, err = os.ReadFile(filepath.Join(dest, "src", "file(1).txt"))
+				require.NoError(t, err)
+				assert.Equal(t, tt.kept, string(data))
+			}
+		})
+	}
+}
+
+func TestExtractEncryptedZip(t *testing.T) {
+	processBar := processbar.New()
+	processBar.ListenForChannelUpdates()
+	t.Cleanup(processBar.SendStopListeningMsgBlocking)
+	expected := strings.Repeat("Secret content\n", 200)
+
+	// Both archives contain dir/file.txt, encrypted with the password "superfile"
+	for _, archive := range []string{"encrypted_zipcrypto.zip", "encrypted_aes.zip"} {
+		testdata := []struct {
+			name        string
+			password    string
+			expectedErr error
+		}{
+			{name: "No password", password: "", expectedErr: errArchivePasswordRequired},
+			{name: "Wrong password", password: "wrong", expectedErr: errArchiveWrongPassword},
+			{name: "Right password", password: "superfile"},
+		}
+		for _, tt := range testdata {
+			t.Run(archive+" "+tt.name, func(t *testing.T) {
+				dest := t.TempDir()
+				archivePath := filepath.Join("testdata", archive)
+				_, err := extractArchives(&processBar, extractRequest{archives: []string{archivePath}, destDir: dest,
+					mode: extractHere}, map[string]string{archiveSetName(archivePath): tt.password},
+					newPasteConflictResolver(common.PasteConflictAsk, nil))
+				if tt.expectedErr != nil {
+					require.ErrorIs(t, err, tt.expectedErr)
+					assert.NoFileExists(t, filepath.Join(dest, "dir", "file.txt"))
+					return
+				}
+				require.NoError(t, err)
+				data, err := os.ReadFile(filepath.Join(dest, "dir", "file.txt"))
+				require.NoError(t, err)
+				assert.Equal(t, expected, string(data))
+			})
+		}
+	}
+}
+
+func TestExtractRetriedWithPassword(t *testing.T) {
+	processBar := processbar.New()
+	finished := 0
+	processBar.AddFinishHook(func(processbar.Process) { finished++ })
+	processBar.ListenForChannelUpdates()
+	t.Cleanup(processBar.SendStopListeningMsgBlocking)
+
+	state, err := runExtractProcess(&processBar, "Extracting archive.rar", []string{"archive.rar"}, t.TempDir(),
+		func(context.Context) <-chan pasteTotals { return nil },
+		func(context.Context, *pasteProgress) error {
+			return fmt.Errorf("%w: %w", errExtractRetried, errArchivePasswordRequired)
+		})
+	require.ErrorIs(t, err, errArchivePasswordRequired)
+	assert.Equal(t, processbar.Cancelled, state)
+	assert.Zero(t, finished, "Process should be removed, and not finished as failed")
+}
+
+func TestReadEncryptedArchiveEntry(t *testing.T) {
+	for _, archive := range []string{"encrypted_zipcrypto.zip", "encrypted_aes.zip"} {
+		t.Run(archive, func(t *testing.T) {
+			archivePath := filepath.Join("testdata", archive)
+			var buf bytes.Buffer
+			err := readArchiveEntry(t.Context(), archivePath, "dir/file.txt", "", &buf)
+			require.ErrorIs(t, err, errArchivePasswordRequired)
+
+			buf.Reset()
+			require.NoError(t, readArchiveEntry(t.Context(), archivePath, "dir/file.txt", "superfile", &buf))
+			assert.Equal(t, strings.Repeat("Secret content\n", 200), buf.String())
+		})
+	}
+}
+
+func TestIsPasswordError(t *testing.T) {
+	testdata := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "Error of rar reader", err: fmt.Errorf("rardecode.OpenReader: %w", errors.New(rarPasswordErrorText)),
+			expected: true},
+		{name: "Other error mentioning the password", err: errors.New("file password.txt: checksum error")},
+		{name: "Other error", err: errors.New("unexpected EOF")},
+	}
+	for _, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isPasswordError(tt.err))
+		})
+	}
+}
+
+func TestArchiveSetName(t *testing.T) {
+	testdata := []struct {
+		path     string
+		expected string
+	}{
+		{path: "/x/archive.zip", expected: "/x/archive.zip"},
+		{path: "/x/archive.part1.rar", expected: "/x/archive"},
+		{path: "/x/archive.part12.rar", expected: "/x/archive"},
+		{path: "/x/archive.7z.001", expected: "/x/archive.7z"},
+		{path: "/x/archive.7z.002", expected: "/x/archive.7z"},
+	}
+	for _, tt := range testdata {
+		assert.Equal(t, filepath.FromSlash(tt.expected), archiveSetName(filepath.FromSlash(tt.path)))
+	}
+}
+
+func TestExtractArchives(t *testing.T) {
+	processBar := processbar.New()
+	srcDir := filepath.Join(t.TempDir(), "src")
+	utils.SetupDirectories(t, srcDir)
+	utils.SetupFilesWithData(t, []byte("new"), filepath.Join(srcDir, "file.txt"))
+	otherFile := filepath.Join(t.TempDir(), "other.txt")
+	utils.SetupFilesWithData(t, []byte("other"), otherFile)
+
+	archiveDir := t.TempDir()
+	// Only has the directory src
+	dirArchive := filepath.Join(archiveDir, "dir.zip")
+	require.NoError(t, compressSources([]string{srcDir}, dirArchive, common.CompressFormatZip, &processBar))
+	// Has src/file.txt and other.txt
+	mixedArchive := filepath.Join(archiveDir, "mixed.tar")
+	require.NoError(t, compressSources([]string{srcDir, otherFile}, mixedArchive, common.CompressFormatTar,
+		&processBar))
+
+	testdata := []struct {
+		name     string
+		archives []string
+		mode     extractMode
+		existing []string
+		action   pasteConflictAction
+		expected map[string]string
+	}{
+		{
+			name:     "Single directory is not wrapped",
+			archives: []string{dirArchive},
+			mode:     extractSmart,
+			expected: map[string]string{"src/file.txt": "new"},
+		},
+		{
+			name:     "Single directory with existing name",
+			archives: []string{dirArchive},
+			mode:     extractSmart,
+			existing: []string{"src/file.txt"},
+			expected: map[string]string{"src/file.txt": "old", "src(1)/file.txt": "new"},
+		},
+		{
+			name:     "Several items are wrapped",
+			archives: []string{mixedArchive},
+			mode:     extractSmart,
+			expected: map[string]string{"mixed/src/file.txt": "new", "mixed/other.txt": "other"},
+		},
+		{
+			name:     "Several archives",
+			archives: []string{dirArchive, mixedArchive},
+			mode:     extractSmart,
+			expected: map[string]string{"src/file.txt": "new", "mixed/src/file.txt": "new", "mixed/other.txt": "other"},
+		},
+		{
+			name:     "Here merges directories",
+			archives: []string{mixedArchive},
+			mode:     extractHere,
+			existing: []string{"src/kept.txt"},
+			expected: map[string]string{"src/file.txt": "new", "src/kept.txt": "old", "other.txt": "other"},
+		},
+		{
+			name:     "Here with conflict kept",
+			archives: []string{dirArchive},
+			mode:     extractHere,
+			existing: []string{"src/file.txt"},
+			action:   conflictKeepBoth,
+			expected: map[string]string{"src/file.txt": "old", "src/file(1).txt": "new"},
+		},
+		{
+			name:     "Here with conflict skipped",
+			archives: []string{dirArchive},
+			mode:     extractHere,
+			existing: []string{"src/file.txt"},
+			action:   conflictSkip,
+			expected: map[string]string{"src/file.txt": "old"},
+		},
+	}
+	for _, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := t.TempDir()
+			for _, item := range tt.existing {
+				utils.SetupDirectories(t, filepath.Join(dest, filepath.Dir(item)))
+				utils.SetupFilesWithData(t, []byte("old"), filepath.Join(dest, item))
+			}
+			resolver := newPasteConflictResolver(common.PasteConflictAsk,
+				func(_ context.Context, _, _ string) (pasteConflictResp, error) {
+					return pasteConflictResp{action: tt.action}, nil
+				})
+
+			remaining, err := extractArchives(&processBar,
+				extractRequest{archives: tt.archives, destDir: dest, mode: tt.mode}, nil, resolver)
+			require.NoError(t, err)
+			assert.Empty(t, remaining)
+
+			var files []string
+			require.NoError(t, filepath.WalkDir(dest, func(path string, d os.DirEntry, err error) error {
+				if err == nil && !d.IsDir() {
+					rel, _ := filepath.Rel(dest, path)
+					files = append(files, filepath.ToSlash(rel))
+				}
+				return err
+			}))
+			assert.ElementsMatch(t, slices.Collect(maps.Keys(tt.expected)), files)
+			for name, content := range tt.expected {
+				data, err := os.ReadFile(filepath.Join(dest, name))
+				require.NoError(t, err)
+				assert.Equal(t, content, string(data), name)
+			}
+		})
+	}
+
+	t.Run("Password of a later archive", func(t *testing.T) {
+		dest := t.TempDir()
+		encrypted := filepath.Join("testdata", "encrypted_aes.zip")
+		archives := []string{dirArchive, encrypted}
+		remaining, err := extractArchives(&processBar, extractRequest{archives: archives, destDir: dest},
+			nil, newPasteConflictResolver(common.PasteConflictAsk, nil))
+		require.ErrorIs(t, err, errArchivePasswordRequired)
+		assert.Equal(t, archives, remaining, "nothing should be extracted before the password is asked")
+		entries, err := os.ReadDir(dest)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
+
+func TestExtractArchivesCmdConflictPolicy(t *testing.T) {
+	prevPolicy := common.Config.PasteConflictPolicy
+	common.Config.PasteConflictPolicy = common.PasteConflictSkip
+	t.Cleanup(func() {
+		common.Config.PasteConflictPolicy = prevPolicy
+	})
+	curTestDir := t.TempDir()
+	archive := filepath.Join(curTestDir, "archive.zip")
+	setupZipWithoutDirs(t, archive, map[string]string{"file.txt": "new"})
+	dest := filepath.Join(curTestDir, "dest")
+	utils.SetupDirectories(t, dest)
+	utils.SetupFilesWithData(t, []byte("old"), filepath.Join(dest, "file.txt"))
+
+	m := defaultTestModel(curTestDir)
+	m.processBarModel.ListenForChannelUpdates()
+	t.Cleanup(m.processBarModel.SendStopListeningMsgBlocking)
+	msg := m.getExtractArchivesCmd(extractRequest{archives: []string{archive}, destDir: dest, mode: extractHere})()
+	assert.IsType(t, ExtractOperationMsg{}, msg)
+
+	data, err := os.ReadFile(filepath.Join(dest, "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(data), "Existing item should be skipped, as per the policy")
+	assert.NoFileExists(t, filepath.Join(dest, "file(1).txt"))
+}
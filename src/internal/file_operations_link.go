@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/yorukot/superfile/src/internal/journal"
+)
+
+// How pasted items link to the items of the clipboard
+type linkType int
+
+const (
+	symlinkAbsolute linkType = iota
+	symlinkRelative
+	hardlink
+)
+
+func (l linkType) String() string {
+	switch l {
+	case symlinkAbsolute:
+		return "symlink"
+	case symlinkRelative:
+		return "relative symlink"
+	case hardlink:
+		return "hardlink"
+	}
+	return "unknown link"
+}
+
+func (l linkType) journalOpType() journal.OpType {
+	if l == hardlink {
+		return journal.Hardlink
+	}
+	return journal.Symlink
+}
+
+// createLink creates a link of type l at dst to src. It returns the target of the link
+// as written, which is relative to the directory of dst for relative symlinks.
+func createLink(src string, dst string, l linkType) (string, error) {
+	switch l {
+	case symlinkRelative:
+		target, err := filepath.Rel(filepath.Dir(dst), src)
+		if err != nil {
+			return "", fmt.Errorf("cannot get path of %s relative to %s: %w", src, filepath.Dir(dst), err)
+		}
+		return target, os.Symlink(target, dst)
+	case hardlink:
+		return src, createHardlink(src, dst)
+	case symlinkAbsolute:
+	}
+	return src, os.Symlink(src, dst)
+}
+
+func createHardlink(src string, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("cannot hardlink %s: hardlinks to directories are not supported", filepath.Base(src))
+	}
+	err = os.Link(src, dst)
+	if errors.Is(err, syscall.EXDEV) {
+		return fmt.Errorf("cannot hardlink %s into %s: hardlinks only work within a single device or "+
+			"filesystem, use a symlink instead: %w", filepath.Base(src), filepath.Dir(dst), err)
+	}
+	return err
+}
+
+// removeLinkConflict removes dst, that a link is about to overwrite. Directories are
+// only removed if they are empty, a link never replaces their contents.
+func removeLinkConflict(dst string) error {
+	info, err := os.Lstat(dst)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err = os.Remove(dst); err != nil && info.IsDir() {
+		return fmt.Errorf("cannot replace directory %s with a link: %w", dst, err)
+	}
+	return err
+}
@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/journal"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+	"github.com/yorukot/superfile/src/internal/utils"
+)
+
+func TestLinkOperation(t *testing.T) {
+	if runtime.GOOS == utils.OsWindows {
+		t.Skip("Creating symlinks needs extra privileges on Windows")
+	}
+	curTestDir := t.TempDir()
+	srcDir := filepath.Join(curTestDir, "src")
+	file1 := filepath.Join(srcDir, "file1.txt")
+	dir1 := filepath.Join(srcDir, "dir1")
+	utils.SetupDirectories(t, srcDir, dir1)
+	utils.SetupFilesWithData(t, []byte("f1"), file1)
+
+	testdata := []struct {
+		name          string
+		items         []string
+		linkType      linkType
+		policy        string
+		existing      []string
+		expectedState processbar.ProcessState
+		// Expected link targets, relative to the destination directory
+		expectedTargets map[string]string
+	}{
+		{
+			name:            "Absolute symlinks",
+			items:           []string{file1, dir1},
+			linkType:        symlinkAbsolute,
+			expectedState:   processbar.Successful,
+			expectedTargets: map[string]string{"file1.txt": file1, "dir1": dir1},
+		},
+		{
+			name:            "Relative symlinks",
+			items:           []string{file1, dir1},
+			linkType:        symlinkRelative,
+			expectedState:   processbar.Successful,
+			expectedTargets: map[string]string{"file1.txt": "../../src/file1.txt", "dir1": "../../src/dir1"},
+		},
+		{
+			name:            "Conflict keeps both",
+			items:           []string{file1},
+			linkType:        symlinkAbsolute,
+			policy:          common.PasteConflictRename,
+			existing:        []string{"file1.txt"},
+			expectedState:   processbar.Successful,
+			expectedTargets: map[string]string{"file1(1).txt": file1},
+		},
+		{
+			name:            "Conflict overwritten",
+			items:           []string{file1},
+			linkType:        symlinkRelative,
+			policy:          common.PasteConflictOverwrite,
+			existing:        []string{"file1.txt"},
+			expectedState:   processbar.Successful,
+			expectedTargets: map[string]string{"file1.txt": "../../src/file1.txt"},
+		},
+		{
+			name:          "Hardlink of directory fails",
+			items:         []string{dir1},
+			linkType:      hardlink,
+			expectedState: processbar.Failed,
+		},
+	}
+
+	for i, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			dstDir := filepath.Join(curTestDir, "dst", string(rune('a'+i)))
+			utils.SetupDirectories(t, dstDir)
+			for _, name := range tt.existing {
+				utils.SetupFilesWithData(t, []byte("existing"), filepath.Join(dstDir, name))
+			}
+			processBarModel := processbar.New()
+			resolver := newPasteConflictResolver(tt.policy, nil)
+			opJournal := journal.New("")
+
+			state, err := linkOperation(&processBarModel, dstDir, tt.items, tt.linkType, resolver, opJournal)
+			assert.Equal(t, tt.expectedState, state)
+			if tt.expectedState == processbar.Failed {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			for name, target := range tt.expectedTargets {
+				actual, err := os.Readlink(filepath.Join(dstDir, name))
+				require.NoError(t, err)
+				assert.Equal(t, target, actual)
+			}
+			entry, ok := opJournal.PopUndo()
+			require.True(t, ok)
+			assert.Equal(t, journal.Symlink, entry.Type)
+			assert.Len(t, entry.Items, len(tt.items))
+		})
+	}
+
+	t.Run("Hardlink and undo", func(t *testing.T) {
+		dstDir := filepath.Join(curTestDir, "dst", "hardlink")
+		utils.SetupDirectories(t, dstDir)
+		processBarModel := processbar.New()
+		opJournal := journal.New("")
+		state, err := linkOperation(&processBarModel, dstDir, []string{file1}, hardlink,
+			newPasteConflictResolver(common.PasteConflictRename, nil), opJournal)
+		require.NoError(t, err)
+		assert.Equal(t, processbar.Successful, state)
+
+		srcInfo, err := os.Stat(file1)
+		require.NoError(t, err)
+		dstInfo, err := os.Lstat(filepath.Join(dstDir, "file1.txt"))
+		require.NoError(t, err)
+		assert.True(t, os.SameFile(srcInfo, dstInfo))
+
+		entry, ok := opJournal.PopUndo()
+		require.True(t, ok)
+		_, _, err = undoEntry(entry)
+		require.NoError(t, err)
+		assert.NoFileExists(t, filepath.Join(dstDir, "file1.txt"))
+		assert.FileExists(t, file1)
+	})
+}
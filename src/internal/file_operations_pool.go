@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"sync"
+)
+
+// copyPool runs the file copies of a process on a bounded number of goroutines.
+// Errors are reported in submission order, like if the jobs ran one after another :
+// once a job fails, the jobs submitted after it are not started anymore, and wait()
+// returns the error of the first failed job.
+// Jobs must only be submitted from a single goroutine.
+type copyPool struct {
+	jobs    chan copyJob
+	workers sync.WaitGroup
+	// Jobs that are submitted but not done yet
+	pending sync.WaitGroup
+	nextIdx int
+
+	mu        sync.Mutex
+	failedIdx int
+	err       error
+}
+
+type copyJob struct {
+	idx int
+	run func() error
+}
+
+// newCopyPool starts a pool with the given count of workers. With one worker or
+// less, jobs are run right away by submit().
+func newCopyPool(workers int) *copyPool {
+	cp := &copyPool{failedIdx: -1}
+	if workers <= 1 {
+		return cp
+	}
+	cp.jobs = make(chan copyJob, workers)
+	cp.workers.Add(workers)
+	for range workers {
+		go func() {
+			defer cp.workers.Done()
+			for job := range cp.jobs {
+				cp.runJob(job)
+			}
+		}()
+	}
+	return cp
+}
+
+func (cp *copyPool) runJob(job copyJob) {
+	defer cp.pending.Done()
+	if cp.failedBefore(job.idx) {
+		return
+	}
+	if err := job.run(); err != nil {
+		cp.setError(job.idx, err)
+	}
+}
+
+// submit queues run. It blocks while all workers are busy.
+func (cp *copyPool) submit(run func() error) {
+	job := copyJob{idx: cp.nextIdx, run: run}
+	cp.nextIdx++
+	cp.pending.Add(1)
+	if cp.jobs == nil {
+		cp.runJob(job)
+		return
+	}
+	cp.jobs <- job
+}
+
+// fail records an error of the submitter, ordered after all the jobs submitted so far.
+// It does nothing if err is nil.
+func (cp *copyPool) fail(err error) {
+	if err == nil {
+		return
+	}
+	cp.setError(cp.nextIdx, err)
+	cp.nextIdx++
+}
+
+// failed reports whether a job has failed, in which case there is no point submitting more
+func (cp *copyPool) failed() bool {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return cp.err != nil
+}
+
+// flush waits until all submitted jobs are done
+func (cp *copyPool) flush() {
+	cp.pending.Wait()
+}
+
+// wait stops the workers once all submitted jobs are done, and returns the error
+// of the first failed job. The pool can't be used anymore after that.
+func (cp *copyPool) wait() error {
+	if cp.jobs != nil {
+		close(cp.jobs)
+		cp.workers.Wait()
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return cp.err
+}
+
+func (cp *copyPool) failedBefore(idx int) bool {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return cp.err != nil && cp.failedIdx < idx
+}
+
+func (cp *copyPool) setError(idx int, err error) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	if cp.err == nil || idx < cp.failedIdx {
+		cp.failedIdx = idx
+		cp.err = err
+	}
+}
@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+	"github.com/yorukot/superfile/src/internal/utils"
+)
+
+func TestCopyPool(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("Runs all jobs with %d workers", workers), func(t *testing.T) {
+			pool := newCopyPool(workers)
+			var done atomic.Int32
+			for range 20 {
+				pool.submit(func() error {
+					done.Add(1)
+					return nil
+				})
+			}
+			require.NoError(t, pool.wait())
+			assert.Equal(t, int32(20), done.Load())
+		})
+	}
+
+	t.Run("Reports the first error in submission order", func(t *testing.T) {
+		pool := newCopyPool(4)
+		release := make(chan struct{})
+		errFirst := errors.New("first")
+		// The first job fails after the second one
+		pool.submit(func() error {
+			<-release
+			return errFirst
+		})
+		pool.submit(func() error {
+			defer close(release)
+			return errors.New("second")
+		})
+		require.ErrorIs(t, pool.wait(), errFirst)
+		assert.True(t, pool.failed())
+	})
+
+	t.Run("Jobs after a failure are not started", func(t *testing.T) {
+		pool := newCopyPool(1)
+		pool.fail(errors.New("walk error"))
+		started := false
+		pool.submit(func() error {
+			started = true
+			return nil
+		})
+		require.Error(t, pool.wait())
+		assert.False(t, started)
+	})
+
+	t.Run("Flush waits for the pending jobs", func(t *testing.T) {
+		pool := newCopyPool(2)
+		var done atomic.Bool
+		pool.submit(func() error {
+			time.Sleep(10 * time.Millisecond)
+			done.Store(true)
+			return nil
+		})
+		pool.flush()
+		assert.True(t, done.Load())
+		require.NoError(t, pool.wait())
+	})
+}
+
+func TestParallelPaste(t *testing.T) {
+	curTestDir := t.TempDir()
+	srcDir := filepath.Join(curTestDir, "src")
+	dstDir := filepath.Join(curTestDir, "dst")
+	utils.SetupDirectories(t, srcDir, dstDir)
+	var files []string
+	for _, dir := range []string{"a", "b", filepath.Join("b", "c")} {
+		utils.SetupDirectories(t, filepath.Join(srcDir, dir))
+		for _, name := range []string{"1.txt", "2.txt", "3.txt"} {
+			files = append(files, filepath.Join(dir, name))
+			utils.SetupFilesWithData(t, []byte(name), filepath.Join(srcDir, dir, name))
+		}
+	}
+	utils.SetupFilesWithData(t, []byte("src a(1)"), filepath.Join(srcDir, "a(1).txt"))
+	utils.SetupFilesWithData(t, []byte("src a"), filepath.Join(srcDir, "a.txt"))
+	utils.SetupDirectories(t, filepath.Join(dstDir, "src"))
+	utils.SetupFilesWithData(t, []byte("existing"), filepath.Join(dstDir, "src", "a.txt"))
+
+	prevWorkers := common.Config.CopyWorkers
+	common.Config.CopyWorkers = 4
+	t.Cleanup(func() {
+		common.Config.CopyWorkers = prevWorkers
+	})
+
+	processBarModel := processbar.New()
+	p := processbar.NewProcess("1", "paste", 0)
+	resolver := newPasteConflictResolver(common.PasteConflictRename, nil)
+	err := pasteDir(t.Context(), srcDir, filepath.Join(dstDir, "src"), false,
+		newPasteProgress(&p, &processBarModel, nil), resolver)
+	require.NoError(t, err)
+
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(dstDir, "src", file))
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Base(file), string(data))
+	}
+	assert.Equal(t, len(files)+2, p.Done)
+	// a(1).txt is being copied when a.txt is renamed to avoid the conflict
+	for name, expected := range map[string]string{"a.txt": "existing", "a(1).txt": "src a(1)", "a(2).txt": "src a"} {
+		data, err := os.ReadFile(filepath.Join(dstDir, "src", name))
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(data))
+	}
+}
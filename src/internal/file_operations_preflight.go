@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/shirou/gopsutil/v4/disk"
+
+	"github.com/yorukot/superfile/src/internal/common"
+)
+
+var errPreflightSizeExceeded = errors.New("paste exceeds the confirmation size")
+
+// pastePreflight is checked before a paste starts, so that a paste which can't
+// succeed, or a very large one, is confirmed before anything is written
+type pastePreflight struct {
+	items []string
+	// Items moved on the same partition, which are only renamed
+	moved map[string]bool
+	// Set once the items are walked, which is only needed to check their size
+	walked bool
+	// Set if the walk stopped once the copied bytes exceeded the confirmation size, as the
+	// paste is confirmed whatever the rest of the items are
+	sizeExceeded bool
+	// Files and bytes of the items, counted like getTotalFilesCntAndSize does. Only
+	// set if hasTotals, as an item that can't be walked isn't fully counted.
+	totals    pasteTotals
+	hasTotals bool
+	// Bytes to write to the destination. Moved items aren't counted.
+	copyBytes int64
+	// Free bytes of the destination filesystem, if hasFreeBytes
+	freeBytes    int64
+	hasFreeBytes bool
+	// Items whose name already exists in the destination
+	conflicts []string
+	// Files and directories that can't be read, so that they can't be copied
+	unreadable []string
+}
+
+// getPastePreflight checks the paste of items into dest, without walking them
+func getPastePreflight(dest string, items []string, cut bool) pastePreflight {
+	res := pastePreflight{items: items, moved: make(map[string]bool)}
+	for _, item := range items {
+		if _, err := os.Lstat(filepath.Join(dest, filepath.Base(item))); err == nil {
+			res.conflicts = append(res.conflicts, item)
+		}
+		if cut {
+			if same, err := isSamePartition(item, dest); err == nil && same {
+				res.moved[item] = true
+			}
+		}
+	}
+
+	if usage, err := disk.Usage(dest); err != nil {
+		slog.Error("Cannot get the free space of the paste destination", "dest", dest, "error", err)
+	} else {
+		res.freeBytes = int64(min(usage.Free, math.MaxInt64))
+		res.hasFreeBytes = true
+	}
+	return res
+}
+
+// needsWalk reports whether the items have to be walked to tell if the paste needs to
+// be confirmed, for their size or the free space. Moved items need neither.
+func (pf pastePreflight) needsWalk(confirmSize int64) bool {
+	return (confirmSize > 0 || pf.hasFreeBytes) && len(pf.moved) < len(pf.items)
+}
+
+// walkItems counts the files and bytes of the items, and the ones that can't be read.
+// It stops once the copied bytes exceed confirmSize, if it isn't 0.
+func (pf *pastePreflight) walkItems(ctx context.Context, cut bool, confirmSize int64) {
+	pf.walked = true
+	pf.hasTotals = true
+	symlinkPolicy := getSymlinkPolicy(cut)
+	for _, item := range pf.items {
+		err := pf.addItem(ctx, item, symlinkPolicy, !pf.moved[item], confirmSize)
+		if errors.Is(err, errPreflightSizeExceeded) {
+			pf.sizeExceeded = true
+			pf.hasTotals = false
+			return
+		}
+		if err != nil {
+			slog.Error("Error while checking paste item", "item", item, "error", err)
+			pf.hasTotals = false
+		}
+	}
+}
+
+// addItem counts the files and bytes of item. If it is copied, the bytes to write and
+// the files that can't be read are counted too.
+func (pf *pastePreflight) addItem(ctx context.Context, item string, symlinkPolicy string, copied bool,
+	confirmSize int64) error {
+	return walkWithSymlinkPolicy(item, symlinkPolicy, func(path string, info os.FileInfo) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// Copying a symlink only creates it
+		copiedData := copied && !isSymlink(info)
+		if copiedData {
+			if err := checkReadable(path, info.IsDir()); err != nil {
+				slog.Debug("Paste item can't be read", "path", path, "error", err)
+				pf.unreadable = append(pf.unreadable, path)
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				copiedData = false
+			}
+		}
+		if !info.IsDir() {
+			pf.totals.files++
+			pf.totals.bytes += info.Size()
+			if copiedData {
+				pf.copyBytes += info.Size()
+			}
+		}
+		if confirmSize > 0 && pf.copyBytes > confirmSize {
+			return errPreflightSizeExceeded
+		}
+		return nil
+	})
+}
+
+func checkReadable(path string, isDir bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if isDir {
+		// Opening a directory without the read permission succeeds, listing it doesn't
+		_, err = f.Readdirnames(1)
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+	}
+	return err
+}
+
+func (pf pastePreflight) lacksSpace() bool {
+	return pf.hasFreeBytes && pf.copyBytes > pf.freeBytes
+}
+
+// needsConfirm reports whether the paste should be confirmed before it starts. Name
+// conflicts only need it if conflictPolicy overwrites the existing items without asking.
+func (pf pastePreflight) needsConfirm(confirmSize int64, conflictPolicy string) bool {
+	overwrites := conflictPolicy == common.PasteConflictOverwrite ||
+		conflictPolicy == common.PasteConflictOverwriteIfNewer
+	return pf.lacksSpace() || len(pf.unreadable) > 0 || (confirmSize > 0 && pf.copyBytes > confirmSize) ||
+		(overwrites && len(pf.conflicts) > 0)
+}
+
+// summary returns the content and the lines of the dialog confirming the paste
+func (pf pastePreflight) summary() (string, []string) {
+	content := fmt.Sprintf("%d items will be pasted", len(pf.items))
+	switch {
+	case pf.sizeExceeded:
+		content = "More than " + common.FormatFileSize(pf.copyBytes) + " will be pasted"
+	case pf.walked:
+		content = fmt.Sprintf("%d files (%s) will be pasted", pf.totals.files, common.FormatFileSize(pf.totals.bytes))
+	}
+	if pf.lacksSpace() {
+		content = fmt.Sprintf("Not enough free space: %s to paste, %s free", common.FormatFileSize(pf.copyBytes),
+			common.FormatFileSize(pf.freeBytes))
+	}
+	lines := make([]string, 0, 1+len(pf.unreadable)+len(pf.conflicts))
+	if pf.hasFreeBytes {
+		lines = append(lines, "Free space on the destination: "+common.FormatFileSize(pf.freeBytes))
+	}
+	for _, path := range pf.unreadable {
+		lines = append(lines, "Can't be read: "+path)
+	}
+	for _, path := range pf.conflicts {
+		lines = append(lines, "Already exists: "+filepath.Base(path))
+	}
+	return content, lines
+}
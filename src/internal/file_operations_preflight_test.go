@@ -0,0 +1,194 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/notify"
+	"github.com/yorukot/superfile/src/internal/utils"
+)
+
+func TestGetPastePreflight(t *testing.T) {
+	curTestDir := t.TempDir()
+	sourceDir := filepath.Join(curTestDir, "source")
+	destDir := filepath.Join(curTestDir, "dest")
+	subDir := filepath.Join(sourceDir, "subdir")
+	utils.SetupDirectories(t, sourceDir, destDir, subDir)
+	utils.SetupFilesWithData(t, []byte("12345"), filepath.Join(sourceDir, "file1.txt"),
+		filepath.Join(subDir, "file2.txt"))
+	utils.SetupFiles(t, filepath.Join(destDir, "file1.txt"))
+	items := []string{filepath.Join(sourceDir, "file1.txt"), subDir}
+
+	t.Run("Copy", func(t *testing.T) {
+		pf := getPastePreflight(destDir, items, false)
+		require.True(t, pf.needsWalk(0))
+		pf.walkItems(context.Background(), false, 0)
+		assert.True(t, pf.hasTotals)
+		assert.Equal(t, pasteTotals{files: 2, bytes: 10}, pf.totals)
+		assert.Equal(t, int64(10), pf.copyBytes)
+		assert.Equal(t, []string{items[0]}, pf.conflicts)
+		assert.Empty(t, pf.unreadable)
+		assert.True(t, pf.hasFreeBytes)
+		assert.False(t, pf.lacksSpace())
+	})
+
+	t.Run("Move on the same partition needs no walk", func(t *testing.T) {
+		pf := getPastePreflight(destDir, items, true)
+		assert.False(t, pf.needsWalk(1), "moved items need no space")
+		assert.Equal(t, []string{items[0]}, pf.conflicts)
+
+		pf.walkItems(context.Background(), true, 0)
+		assert.Equal(t, int64(0), pf.copyBytes)
+		assert.Equal(t, pasteTotals{files: 2, bytes: 10}, pf.totals, "moved files are still pasted")
+	})
+
+	t.Run("Walk stops once the confirmation size is exceeded", func(t *testing.T) {
+		pf := getPastePreflight(destDir, items, false)
+		pf.walkItems(context.Background(), false, 4)
+		assert.True(t, pf.sizeExceeded)
+		assert.False(t, pf.hasTotals, "the rest of the items aren't counted")
+		assert.Equal(t, int64(5), pf.copyBytes)
+		assert.True(t, pf.needsConfirm(4, common.PasteConflictAsk))
+	})
+
+	t.Run("Unreadable files", func(t *testing.T) {
+		if runtime.GOOS == utils.OsWindows || os.Geteuid() == 0 {
+			t.Skip("Permissions don't stop reading files on windows or as root")
+		}
+		unreadable := filepath.Join(subDir, "unreadable.txt")
+		utils.SetupFiles(t, unreadable)
+		require.NoError(t, os.Chmod(unreadable, 0o200))
+		t.Cleanup(func() { _ = os.Remove(unreadable) })
+
+		pf := getPastePreflight(destDir, items, false)
+		pf.walkItems(context.Background(), false, 0)
+		assert.Equal(t, []string{unreadable}, pf.unreadable)
+		assert.True(t, pf.needsConfirm(0, common.PasteConflictAsk))
+	})
+}
+
+func TestPastePreflightNeedsConfirm(t *testing.T) {
+	conflicts := []string{"/a"}
+	testdata := []struct {
+		name           string
+		pf             pastePreflight
+		confirmSize    int64
+		conflictPolicy string
+		expected       bool
+	}{
+		{"Small paste", pastePreflight{copyBytes: 10, freeBytes: 100, hasFreeBytes: true}, 50,
+			common.PasteConflictAsk, false},
+		{"Conflicts asked for", pastePreflight{copyBytes: 10, conflicts: conflicts}, 50, common.PasteConflictAsk, false},
+		{"Conflicts renamed", pastePreflight{conflicts: conflicts}, 0, common.PasteConflictRename, false},
+		{"Conflicts overwritten", pastePreflight{conflicts: conflicts}, 0, common.PasteConflictOverwrite, true},
+		{"Conflicts overwritten if newer", pastePreflight{conflicts: conflicts}, 0,
+			common.PasteConflictOverwriteIfNewer, true},
+		{"Large paste", pastePreflight{copyBytes: 60, freeBytes: 100, hasFreeBytes: true}, 50,
+			common.PasteConflictAsk, true},
+		{"No size limit", pastePreflight{copyBytes: 60, freeBytes: 100, hasFreeBytes: true}, 0,
+			common.PasteConflictAsk, false},
+		{"Not enough space", pastePreflight{copyBytes: 200, freeBytes: 100, hasFreeBytes: true}, 0,
+			common.PasteConflictAsk, true},
+		{"Free space unknown", pastePreflight{copyBytes: 200}, 0, common.PasteConflictAsk, false},
+		{"Unreadable files", pastePreflight{unreadable: []string{"/a"}}, 0, common.PasteConflictAsk, true},
+	}
+	for _, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.pf.needsConfirm(tt.confirmSize, tt.conflictPolicy))
+		})
+	}
+}
+
+func TestPasteConfirmedAfterPreflight(t *testing.T) {
+	confirmSize := common.Config.PasteConfirmSizeMB
+	common.Config.PasteConfirmSizeMB = 1
+	t.Cleanup(func() { common.Config.PasteConfirmSizeMB = confirmSize })
+
+	curTestDir := t.TempDir()
+	sourceDir := filepath.Join(curTestDir, "source")
+	destDir := filepath.Join(curTestDir, "dest")
+	utils.SetupDirectories(t, sourceDir, destDir)
+	utils.SetupFilesWithData(t, bytes.Repeat([]byte("a"), 2*1024*1024), filepath.Join(sourceDir, "large.bin"))
+
+	m := setupModelAndPerformOperation(t, sourceDir, false, "large.bin", nil, false)
+	p := NewTestTeaProgWithEventLoop(t, m)
+	navigateToTargetDir(t, m, sourceDir, destDir)
+	p.SendKey(common.Hotkeys.PasteItems[0])
+
+	assert.Eventually(t, m.notifyModel.IsOpen, DefaultTestTimeout, DefaultTestTick, "Notify model never opened")
+	assert.Equal(t, common.PastePreflightTitle, m.notifyModel.GetTitle())
+	assert.Equal(t, notify.PastePreflightAction, m.notifyModel.GetConfirmAction())
+	assert.NoFileExists(t, filepath.Join(destDir, "large.bin"), "Nothing should be pasted before the confirmation")
+
+	p.SendKey(common.Hotkeys.Confirm[0])
+	verifyDestinationFiles(t, destDir, []string{"large.bin"})
+}
+
+func TestPastePreflightMsg(t *testing.T) {
+	curTestDir := t.TempDir()
+	req := pasteRequest{dest: curTestDir, items: []string{filepath.Join(curTestDir, "a.txt")}}
+
+	t.Run("Totals are passed to the paste", func(t *testing.T) {
+		m := defaultTestModel(curTestDir)
+		m.pendingPaste = &req
+		pf := pastePreflight{totals: pasteTotals{files: 2, bytes: 10}, hasTotals: true}
+		cmd := NewPastePreflightMsg(pf, nil, 0).ApplyToModel(m)
+		assert.NotNil(t, cmd, "the paste should start without confirmation")
+		assert.Nil(t, m.pendingPaste)
+		assert.Equal(t, &pasteTotals{files: 2, bytes: 10}, req.totals, "the paste should use the counted totals")
+	})
+
+	t.Run("Partial totals are counted while pasting", func(t *testing.T) {
+		m := defaultTestModel(curTestDir)
+		m.pendingPaste = &pasteRequest{dest: curTestDir}
+		pending := m.pendingPaste
+		cmd := NewPastePreflightMsg(pastePreflight{totals: pasteTotals{files: 1}}, nil, 0).ApplyToModel(m)
+		assert.NotNil(t, cmd)
+		assert.Nil(t, pending.totals)
+	})
+
+	t.Run("Cancelled preflight", func(t *testing.T) {
+		m := defaultTestModel(curTestDir)
+		m.pendingPaste = &pasteRequest{dest: curTestDir}
+		cmd := NewPastePreflightMsg(pastePreflight{}, context.Canceled, 0).ApplyToModel(m)
+		assert.Nil(t, cmd)
+		assert.Nil(t, m.pendingPaste)
+		assert.False(t, m.notifyModel.IsOpen())
+	})
+
+	t.Run("Confirmation keeps the totals", func(t *testing.T) {
+		m := defaultTestModel(curTestDir)
+		m.pendingPaste = &pasteRequest{dest: curTestDir}
+		pf := pastePreflight{totals: pasteTotals{files: 1, bytes: 200}, hasTotals: true, copyBytes: 200,
+			freeBytes: 100, hasFreeBytes: true}
+		assert.Nil(t, NewPastePreflightMsg(pf, nil, 0).ApplyToModel(m))
+		require.NotNil(t, m.pendingPaste)
+		assert.Equal(t, &pasteTotals{files: 1, bytes: 200}, m.pendingPaste.totals)
+		assert.Equal(t, notify.PastePreflightAction, m.notifyModel.GetConfirmAction())
+	})
+}
+
+func TestPasteWhilePreflightPending(t *testing.T) {
+	curTestDir := t.TempDir()
+	sourceDir := filepath.Join(curTestDir, "source")
+	utils.SetupDirectories(t, sourceDir)
+	utils.SetupFiles(t, filepath.Join(sourceDir, "a.txt"))
+
+	m := defaultTestModel(curTestDir)
+	m.copyItems.items = []string{filepath.Join(sourceDir, "a.txt")}
+	pending := &pasteRequest{dest: curTestDir}
+	m.pendingPaste = pending
+
+	assert.Nil(t, m.getPasteItemCmd())
+	assert.Same(t, pending, m.pendingPaste, "the pending paste shouldn't be replaced")
+	assert.True(t, m.notifyModel.IsOpen())
+	assert.Equal(t, common.PasteFailedTitle, m.notifyModel.GetTitle())
+}
@@ -0,0 +1,178 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+)
+
+// Minimum interval between two progress updates of a process. Copying a large
+// file reports progress many times per second, which would flood the processbar channel.
+const progressUpdateInterval = 100 * time.Millisecond
+
+type pasteTotals struct {
+	files int
+	bytes int64
+}
+
+// getPasteTotalsInBackground counts the files and bytes of items in a separate goroutine,
+// so that the paste can start right away. The result is sent on the returned channel.
+func getPasteTotalsInBackground(ctx context.Context, items []string, symlinkPolicy string) <-chan pasteTotals {
+	res := make(chan pasteTotals, 1)
+	go func() {
+		res <- getTotalFilesCntAndSize(ctx, items, symlinkPolicy)
+	}()
+	return res
+}
+
+// getKnownTotals returns totals on a channel, like getPasteTotalsInBackground, for the
+// pastes whose totals are already counted
+func getKnownTotals(totals pasteTotals) <-chan pasteTotals {
+	res := make(chan pasteTotals, 1)
+	res <- totals
+	return res
+}
+
+// pasteProgress updates a paste process as it progresses, and sends throttled
+// updates to the process bar. Its methods can be called by the copy workers of the
+// paste concurrently. p must not be accessed directly while pasteDir() runs.
+type pasteProgress struct {
+	mu              sync.Mutex
+	p               *processbar.Process
+	processBarModel *processbar.Model
+	// nil once the totals are received
+	totals   <-chan pasteTotals
+	lastSent time.Time
+	stats    copyStats
+}
+
+func newPasteProgress(p *processbar.Process, processBarModel *processbar.Model,
+	totals <-chan pasteTotals) *pasteProgress {
+	p.TotalPending = totals != nil
+	return &pasteProgress{
+		p:               p,
+		processBarModel: processBarModel,
+		totals:          totals,
+		stats:           copyStats{},
+	}
+}
+
+// Pick up the result of the background count, if it is done. pp.mu must be held.
+func (pp *pasteProgress) updateTotals() {
+	if pp.totals == nil {
+		return
+	}
+	select {
+	case t := <-pp.totals:
+		pp.p.Total = t.files
+		pp.p.TotalBytes = t.bytes
+		pp.p.TotalPending = false
+		pp.totals = nil
+	default:
+	}
+}
+
+func (pp *pasteProgress) addBytes(n int64) {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	pp.p.DoneBytes += n
+	pp.sendUpdate()
+}
+
+// fileDone marks a file as done. remainingBytes is the part of its size that
+// was not reported via addBytes() yet.
+func (pp *pasteProgress) fileDone(remainingBytes int64) {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	pp.p.Done++
+	pp.p.DoneBytes += remainingBytes
+	pp.sendUpdate()
+}
+
+// fileFailed removes the bytes reported for a file that could not be pasted, as the
+// partial copy doesn't count
+func (pp *pasteProgress) fileFailed(reportedBytes int64) {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	pp.p.DoneBytes -= reportedBytes
+}
+
+// setCurrentItem shows name as the item being pasted
+func (pp *pasteProgress) setCurrentItem(name string) {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	pp.p.Name = name
+}
+
+// setSources sets the items the process read, when it stopped before reading all of them
+func (pp *pasteProgress) setSources(sources []string) {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	pp.p.Sources = sources
+}
+
+// addWarning records a problem that didn't make the process fail
+func (pp *pasteProgress) addWarning(warning string) {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	pp.p.Warnings = append(pp.p.Warnings, warning)
+}
+
+// addWarnings records the attributes that could not be preserved, if err is an *attrPreserveError
+func (pp *pasteProgress) addWarnings(err error) {
+	var attrErr *attrPreserveError
+	if errors.As(err, &attrErr) {
+		pp.mu.Lock()
+		defer pp.mu.Unlock()
+		pp.p.Warnings = append(pp.p.Warnings, attrErr.failures...)
+	}
+}
+
+func (pp *pasteProgress) addStrategy(strategy copyStrategy) {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	pp.stats.add(strategy)
+}
+
+// update sends the current state of the process, unless an update was sent recently
+func (pp *pasteProgress) update() {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	pp.sendUpdate()
+}
+
+// sendUpdate is like update(), but pp.mu must be held
+func (pp *pasteProgress) sendUpdate() {
+	pp.updateTotals()
+	if time.Since(pp.lastSent) < progressUpdateInterval {
+		return
+	}
+	pp.lastSent = time.Now()
+	pp.processBarModel.TrySendingUpdateProcessMsg(*pp.p)
+}
+
+// finish makes the totals final, once the process has stopped, and logs how the files
+// were copied. It doesn't send any update.
+func (pp *pasteProgress) finish() {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	if len(pp.stats) > 0 {
+		slog.Info("Paste copy strategies", "id", pp.p.ID, "strategies", pp.stats.String(),
+			"duration", time.Since(pp.p.StartTime))
+	}
+	pp.updateTotals()
+	if pp.p.TotalPending {
+		slog.Debug("Paste finished before the count of its files", "id", pp.p.ID)
+		pp.p.Total = pp.p.Done
+		pp.p.TotalBytes = pp.p.DoneBytes
+		pp.p.TotalPending = false
+	}
+	if pp.p.State == processbar.Successful {
+		pp.p.Done = pp.p.Total
+		pp.p.DoneBytes = pp.p.TotalBytes
+	}
+}
@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// renameItem is a pending rename of src to dst
+type renameItem struct {
+	src string
+	dst string
+}
+
+// parseBulkRename returns the renames made by editing the names of items, one per line,
+// into content. Lines are names of items in dir. Unchanged lines are left out.
+func parseBulkRename(dir string, items []string, content string) ([]renameItem, error) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if len(lines) != len(items) {
+		return nil, fmt.Errorf("expected one line for each of the %d items, got %d lines", len(items), len(lines))
+	}
+
+	var res []renameItem
+	for i, line := range lines {
+		if line == "" {
+			return nil, fmt.Errorf("line %d is empty", i+1)
+		}
+		// Items are only renamed, and never moved to another directory
+		if strings.ContainsAny(line, "/"+string(filepath.Separator)) {
+			return nil, fmt.Errorf("line %d: %s can't contain a path separator", i+1, line)
+		}
+		if line == "." || line == ".." {
+			return nil, fmt.Errorf("line %d: %s is not a valid name", i+1, line)
+		}
+		dst := filepath.Join(dir, line)
+		if dst != filepath.Clean(items[i]) {
+			res = append(res, renameItem{src: items[i], dst: dst})
+		}
+	}
+	if err := checkRenames(res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// checkRenames returns an error if applying renames would give the same name to two
+// items, or would overwrite an item that is not renamed itself
+func checkRenames(renames []renameItem) error {
+	srcs := make(map[string]bool, len(renames))
+	for _, r := range renames {
+		srcs[r.src] = true
+	}
+	dsts := make(map[string]string, len(renames))
+	for _, r := range renames {
+		if other, ok := dsts[r.dst]; ok {
+			return fmt.Errorf("both %s and %s would be renamed to %s",
+				filepath.Base(other), filepath.Base(r.src), filepath.Base(r.dst))
+		}
+		dsts[r.dst] = r.src
+		if srcs[r.dst] {
+			continue
+		}
+		dstInfo, err := os.Lstat(r.dst)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("cannot check %s: %w", r.dst, err)
+		}
+		// Changing only the case of a name on a case insensitive filesystem
+		if srcInfo, err := os.Lstat(r.src); err == nil && os.SameFile(srcInfo, dstInfo) {
+			continue
+		}
+		return fmt.Errorf("%s already exists", r.dst)
+	}
+	return nil
+}
+
+// renameAll applies renames, after checking them with checkRenames. An item can be renamed
+// to the current name of another one, like for swaps, as all items are first moved to a
+// temporary name. If a rename fails, the ones already done are reverted.
+func renameAll(renames []renameItem) error {
+	if err := checkRenames(renames); err != nil {
+		return err
+	}
+	tmpSuffix := ".spf-rename-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	tmpPaths := make([]string, len(renames))
+	for i, r := range renames {
+		tmpPaths[i] = r.src + tmpSuffix
+		if err := os.Rename(r.src, tmpPaths[i]); err != nil {
+			revertRenames(renames[:i], tmpPaths[:i], nil)
+			return err
+		}
+	}
+	for i, r := range renames {
+		if err := os.Rename(tmpPaths[i], r.dst); err != nil {
+			revertRenames(renames, tmpPaths, renames[:i])
+			return err
+		}
+	}
+	return nil
+}
+
+// revertRenames moves back items that renameAll moved to tmpPaths, or to their
+// destination for the done ones. Failures are only logged, as nothing more can be done.
+func revertRenames(renames []renameItem, tmpPaths []string, done []renameItem) {
+	for i := len(done) - 1; i >= 0; i-- {
+		if err := os.Rename(done[i].dst, tmpPaths[i]); err != nil {
+			slog.Error("Cannot revert rename", "path", done[i].dst, "error", err)
+		}
+	}
+	for i, r := range renames {
+		if err := os.Rename(tmpPaths[i], r.src); err != nil {
+			slog.Error("Cannot revert rename", "path", tmpPaths[i], "error", err)
+		}
+	}
+}
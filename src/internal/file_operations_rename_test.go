@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yorukot/superfile/src/internal/journal"
+	"github.com/yorukot/superfile/src/internal/utils"
+)
+
+func TestParseBulkRename(t *testing.T) {
+	curTestDir := t.TempDir()
+	file1 := filepath.Join(curTestDir, "file1.txt")
+	file2 := filepath.Join(curTestDir, "file2.txt")
+	other := filepath.Join(curTestDir, "other.txt")
+	utils.SetupFiles(t, file1, file2, other)
+	items := []string{file1, file2}
+
+	testdata := []struct {
+		name     string
+		content  string
+		expected []renameItem
+		errorMsg string
+	}{
+		{
+			name:     "Unchanged",
+			content:  "file1.txt\nfile2.txt\n",
+			expected: nil,
+		},
+		{
+			name:     "One renamed, CRLF and no trailing newline",
+			content:  "file1.txt\r\nnew.txt",
+			expected: []renameItem{{src: file2, dst: filepath.Join(curTestDir, "new.txt")}},
+		},
+		{
+			name:     "Swap",
+			content:  "file2.txt\nfile1.txt\n",
+			expected: []renameItem{{src: file1, dst: file2}, {src: file2, dst: file1}},
+		},
+		{
+			name:     "Line count mismatch",
+			content:  "file1.txt\n",
+			errorMsg: "expected one line",
+		},
+		{
+			name:     "Empty line",
+			content:  "file1.txt\n\n",
+			errorMsg: "line 2 is empty",
+		},
+		{
+			name:     "Outside of directory",
+			content:  "../file1.txt\nfile2.txt\n",
+			errorMsg: "line 1: ../file1.txt can't contain a path separator",
+		},
+		{
+			name:     "Into a subdirectory",
+			content:  "file1.txt\nsub/file2.txt\n",
+			errorMsg: "line 2: sub/file2.txt can't contain a path separator",
+		},
+		{
+			name:     "Parent directory",
+			content:  "..\nfile2.txt\n",
+			errorMsg: "line 1: .. is not a valid name",
+		},
+		{
+			name:     "Existing item",
+			content:  "other.txt\nfile2.txt\n",
+			errorMsg: "already exists",
+		},
+		{
+			name:     "Same new name",
+			content:  "new.txt\nnew.txt\n",
+			errorMsg: "would be renamed to",
+		},
+	}
+
+	for _, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			renames, err := parseBulkRename(curTestDir, items, tt.content)
+			if tt.errorMsg != "" {
+				require.ErrorContains(t, err, tt.errorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, renames)
+		})
+	}
+}
+
+func TestRenameAll(t *testing.T) {
+	curTestDir := t.TempDir()
+	fileA := filepath.Join(curTestDir, "a.txt")
+	fileB := filepath.Join(curTestDir, "b.txt")
+	fileC := filepath.Join(curTestDir, "c.txt")
+	assertContent := func(t *testing.T, path string, expected string) {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(data))
+	}
+	setup := func(t *testing.T) {
+		t.Helper()
+		utils.SetupFilesWithData(t, []byte("a"), fileA)
+		utils.SetupFilesWithData(t, []byte("b"), fileB)
+		utils.SetupFilesWithData(t, []byte("c"), fileC)
+	}
+
+	t.Run("Cycle", func(t *testing.T) {
+		setup(t)
+		err := renameAll([]renameItem{{src: fileA, dst: fileB}, {src: fileB, dst: fileC}, {src: fileC, dst: fileA}})
+		require.NoError(t, err)
+		assertContent(t, fileB, "a")
+		assertContent(t, fileC, "b")
+		assertContent(t, fileA, "c")
+	})
+
+	t.Run("Undo and redo swap", func(t *testing.T) {
+		setup(t)
+		renames := []renameItem{{src: fileA, dst: fileB}, {src: fileB, dst: fileA}}
+		require.NoError(t, renameAll(renames))
+		e := journal.Entry{Type: journal.Rename, Items: []journal.Item{{Src: fileA, Dst: fileB}, {Src: fileB, Dst: fileA}}}
+
+		done, _, err := undoEntry(e)
+		require.NoError(t, err)
+		assert.Equal(t, e, done)
+		assertContent(t, fileA, "a")
+		assertContent(t, fileB, "b")
+
+		_, _, err = redoEntry(done)
+		require.NoError(t, err)
+		assertContent(t, fileA, "b")
+		assertContent(t, fileB, "a")
+	})
+
+	t.Run("Failure reverts", func(t *testing.T) {
+		setup(t)
+		missingDir := filepath.Join(curTestDir, "missing", "b.txt")
+		err := renameAll([]renameItem{{src: fileA, dst: fileB}, {src: fileB, dst: missingDir}})
+		require.Error(t, err)
+		assertContent(t, fileA, "a")
+		assertContent(t, fileB, "b")
+		entries, err := os.ReadDir(curTestDir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 3, "temporary names should not be left behind")
+	})
+}
@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/yorukot/superfile/src/internal/common"
+)
+
+// Identifies a file independently of its path, to detect symlink loops
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// getSymlinkPolicy returns the symlink policy to use for a paste. Moving always moves
+// the links themselves. Following them would move the content of their targets, and
+// skipping them would leave them behind in the moved directories.
+func getSymlinkPolicy(cut bool) string {
+	if cut {
+		return common.SymlinkCopyLink
+	}
+	return common.Config.SymlinkPolicy
+}
+
+// walkWithSymlinkPolicy walks the file tree rooted at root like filepath.Walk, but
+// symlinks, including root itself, are handled as per the policy :
+//   - copy_link : fn gets the info of the link itself, and it isn't walked into.
+//   - follow : fn gets the info of the link's target, and directories are walked into.
+//     Links to one of their own parent directories are skipped, as they would loop forever.
+//     Dangling links are handled like with copy_link.
+//   - skip : fn is not called for links.
+//
+// Unlike filepath.Walk, errors are returned right away instead of being passed to fn.
+// fn can return filepath.SkipDir and filepath.SkipAll like with filepath.Walk.
+func walkWithSymlinkPolicy(root string, policy string, fn walkFunc) error {
+	info, err := os.Lstat(root)
+	if err != nil {
+		return err
+	}
+	w := symlinkWalker{policy: policy, fn: fn}
+	err = w.walk(root, info)
+	if errors.Is(err, filepath.SkipDir) || errors.Is(err, filepath.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+type walkFunc func(path string, info os.FileInfo) error
+
+type symlinkWalker struct {
+	policy string
+	fn     walkFunc
+	// Directories from the root to the one being walked
+	ancestors []fileID
+}
+
+func (w *symlinkWalker) walk(path string, info os.FileInfo) error {
+	if isSymlink(info) {
+		switch w.policy {
+		case common.SymlinkSkip:
+			slog.Debug("Skipping symlink", "path", path)
+			return nil
+		case common.SymlinkFollow:
+			targetInfo, err := os.Stat(path)
+			if err != nil {
+				slog.Debug("Cannot follow symlink, using the link itself", "path", path, "error", err)
+				break
+			}
+			info = targetInfo
+		}
+	}
+	if !info.IsDir() {
+		return w.fn(path, info)
+	}
+
+	id, hasID := getFileID(info)
+	if hasID && slices.Contains(w.ancestors, id) {
+		slog.Warn("Skipping symlink to one of its parent directories", "path", path)
+		return nil
+	}
+	err := w.fn(path, info)
+	if errors.Is(err, filepath.SkipDir) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return fmt.Errorf("failed to read directory: %w", err)
+	}
+	if hasID {
+		w.ancestors = append(w.ancestors, id)
+		defer func() {
+			w.ancestors = w.ancestors[:len(w.ancestors)-1]
+		}()
+	}
+	for _, entry := range entries {
+		entryPath := filepath.Join(path, entry.Name())
+		entryInfo, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("failed to get entry info: %w", err)
+		}
+		err = w.walk(entryPath, entryInfo)
+		// Returned for a file, to skip the rest of its directory
+		if errors.Is(err, filepath.SkipDir) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isSymlink(info os.FileInfo) bool {
+	return info.Mode()&os.ModeSymlink != 0
+}
+
+// copySymlink creates a symlink at dst with the same target as the symlink at src
+func copySymlink(src, dst string) error {
+	target, err := os.Readlink(src)
+	if err != nil {
+		return fmt.Errorf("failed to read symlink: %w", err)
+	}
+	if err = os.Symlink(target, dst); err != nil {
+		return fmt.Errorf("failed to create symlink: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+//go:build !windows
+
+package internal
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+	"github.com/yorukot/superfile/src/internal/utils"
+)
+
+// setupSymlinkTree creates src/file.txt, src/dir/nested.txt and the links
+// src/file_link -> file.txt and src/dir/loop -> .. , and returns src
+func setupSymlinkTree(t *testing.T) string {
+	t.Helper()
+	srcDir := filepath.Join(t.TempDir(), "src")
+	utils.SetupDirectories(t, srcDir, filepath.Join(srcDir, "dir"))
+	utils.SetupFilesWithData(t, []byte("data"), filepath.Join(srcDir, "file.txt"),
+		filepath.Join(srcDir, "dir", "nested.txt"))
+	require.NoError(t, os.Symlink("file.txt", filepath.Join(srcDir, "file_link")))
+	require.NoError(t, os.Symlink("..", filepath.Join(srcDir, "dir", "loop")))
+	return srcDir
+}
+
+func TestWalkWithSymlinkPolicy(t *testing.T) {
+	srcDir := setupSymlinkTree(t)
+
+	testdata := []struct {
+		name     string
+		policy   string
+		expected map[string]os.FileMode
+	}{
+		{
+			name:   "Copy link",
+			policy: common.SymlinkCopyLink,
+			expected: map[string]os.FileMode{
+				".": os.ModeDir, "dir": os.ModeDir, "dir/loop": os.ModeSymlink,
+				"dir/nested.txt": 0, "file.txt": 0, "file_link": os.ModeSymlink,
+			},
+		},
+		{
+			name:   "Follow skips loops",
+			policy: common.SymlinkFollow,
+			expected: map[string]os.FileMode{
+				".": os.ModeDir, "dir": os.ModeDir,
+				"dir/nested.txt": 0, "file.txt": 0, "file_link": 0,
+			},
+		},
+		{
+			name:   "Skip",
+			policy: common.SymlinkSkip,
+			expected: map[string]os.FileMode{
+				".": os.ModeDir, "dir": os.ModeDir, "dir/nested.txt": 0, "file.txt": 0,
+			},
+		},
+	}
+
+	for _, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			walked := map[string]os.FileMode{}
+			err := walkWithSymlinkPolicy(srcDir, tt.policy, func(path string, info os.FileInfo) error {
+				rel, err := filepath.Rel(srcDir, path)
+				require.NoError(t, err)
+				walked[rel] = info.Mode().Type()
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, walked)
+		})
+	}
+
+	t.Run("Follow a symlink root", func(t *testing.T) {
+		link := filepath.Join(t.TempDir(), "link")
+		require.NoError(t, os.Symlink(filepath.Join(srcDir, "dir"), link))
+		count, size, err := countFilesAndSize(context.Background(), link, common.SymlinkFollow)
+		require.NoError(t, err)
+		// dir/loop leads to src, which is walked but without dir again
+		assert.Equal(t, 3, count)
+		assert.Equal(t, int64(12), size)
+	})
+}
+
+func TestPasteSymlinks(t *testing.T) {
+	srcDir := setupSymlinkTree(t)
+
+	paste := func(t *testing.T, policy string) string {
+		t.Helper()
+		prevPolicy := common.Config.SymlinkPolicy
+		common.Config.SymlinkPolicy = policy
+		t.Cleanup(func() {
+			common.Config.SymlinkPolicy = prevPolicy
+		})
+		processBarModel := processbar.New()
+		p := processbar.NewProcess("1", "paste", 0)
+		dst := filepath.Join(t.TempDir(), "dst")
+		resolver := newPasteConflictResolver(common.PasteConflictRename, nil)
+		err := pasteDir(context.Background(), srcDir, dst, false, newPasteProgress(&p, &processBarModel, nil), resolver)
+		require.NoError(t, err)
+		return dst
+	}
+
+	t.Run("Copy link", func(t *testing.T) {
+		dst := paste(t, common.SymlinkCopyLink)
+		target, err := os.Readlink(filepath.Join(dst, "file_link"))
+		require.NoError(t, err)
+		assert.Equal(t, "file.txt", target)
+		target, err = os.Readlink(filepath.Join(dst, "dir", "loop"))
+		require.NoError(t, err)
+		assert.Equal(t, "..", target)
+	})
+
+	t.Run("Follow", func(t *testing.T) {
+		dst := paste(t, common.SymlinkFollow)
+		info, err := os.Lstat(filepath.Join(dst, "file_link"))
+		require.NoError(t, err)
+		assert.True(t, info.Mode().IsRegular())
+		assert.NoFileExists(t, filepath.Join(dst, "dir", "loop"))
+	})
+
+	t.Run("Skip", func(t *testing.T) {
+		dst := paste(t, common.SymlinkSkip)
+		assert.NoFileExists(t, filepath.Join(dst, "file_link"))
+		assert.FileExists(t, filepath.Join(dst, "file.txt"))
+	})
+
+	t.Run("Overwriting a file with a link", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), "file_link")
+		utils.SetupFilesWithData(t, []byte("old"), dst)
+		info, err := os.Lstat(filepath.Join(srcDir, "file_link"))
+		require.NoError(t, err)
+		strategy, err := copyFile(context.Background(), filepath.Join(srcDir, "file_link"), dst, info, nil)
+		require.NoError(t, err)
+		assert.Equal(t, copyStrategySymlink, strategy)
+		target, err := os.Readlink(dst)
+		require.NoError(t, err)
+		assert.Equal(t, "file.txt", target)
+	})
+}
+
+func TestZipSymlinks(t *testing.T) {
+	srcDir := setupSymlinkTree(t)
+	zipFile := filepath.Join(t.TempDir(), "src.zip")
+	processBarModel := processbar.New()
+	require.NoError(t, compressSources([]string{srcDir}, zipFile, common.CompressFormatZip, &processBarModel))
+
+	reader, err := zip.OpenReader(zipFile)
+	require.NoError(t, err)
+	defer reader.Close()
+	for _, f := range reader.File {
+		if f.Name != filepath.Join("src", "file_link") {
+			continue
+		}
+		assert.Equal(t, os.ModeSymlink, f.Mode().Type())
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		assert.Equal(t, "file.txt", string(data))
+		return
+	}
+	t.Fatal("Symlink entry not found in the archive")
+}
+
+func TestTarSymlinks(t *testing.T) {
+	srcDir := setupSymlinkTree(t)
+	tarFile := filepath.Join(t.TempDir(), "src.tar")
+	processBarModel := processbar.New()
+	require.NoError(t, compressSources([]string{srcDir}, tarFile, common.CompressFormatTar, &processBarModel))
+
+	f, err := os.Open(tarFile)
+	require.NoError(t, err)
+	defer f.Close()
+	reader := tar.NewReader(f)
+	for {
+		header, err := reader.Next()
+		require.NoError(t, err, "Symlink entry not found in the archive")
+		if header.Name != "src/file_link" {
+			continue
+		}
+		assert.Equal(t, byte(tar.TypeSymlink), header.Typeflag)
+		assert.Equal(t, "file.txt", header.Linkname)
+		break
+	}
+
+	dest := t.TempDir()
+	_, err = extractArchives(&processBarModel, extractRequest{archives: []string{tarFile}, destDir: dest,
+		mode: extractHere}, nil, newPasteConflictResolver(common.PasteConflictAsk, nil))
+	require.NoError(t, err)
+	target, err := os.Readlink(filepath.Join(dest, "src", "file_link"))
+	require.NoError(t, err)
+	assert.Equal(t, "file.txt", target)
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package internal
+
+import "os"
+
+// FileInfo doesn't provide a file index on Windows, so symlink loops are not detected
+func getFileID(_ os.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
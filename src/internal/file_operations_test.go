@@ -0,0 +1,189 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+	"github.com/yorukot/superfile/src/internal/utils"
+)
+
+func TestCancelledCopy(t *testing.T) {
+	curTestDir := t.TempDir()
+	srcDir := filepath.Join(curTestDir, "src")
+	dstDir := filepath.Join(curTestDir, "dst")
+	srcFile := filepath.Join(srcDir, "file.txt")
+	utils.SetupDirectories(t, srcDir, dstDir, filepath.Join(srcDir, "subdir"))
+	utils.SetupFilesWithData(t, []byte("data"), srcFile, filepath.Join(srcDir, "subdir", "file2.txt"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	t.Run("copyFile removes partial destination", func(t *testing.T) {
+		info, err := os.Stat(srcFile)
+		require.NoError(t, err)
+		dst := filepath.Join(dstDir, "file.txt")
+		_, err = copyFile(ctx, srcFile, dst, info, nil)
+		require.ErrorIs(t, err, context.Canceled)
+		assert.NoFileExists(t, dst)
+	})
+
+	t.Run("pasteDir stops and keeps source on cut", func(t *testing.T) {
+		processBarModel := processbar.New()
+		p := processbar.NewProcess("1", "paste", 2)
+		dst := filepath.Join(dstDir, "src")
+		resolver := newPasteConflictResolver(common.PasteConflictRename, nil)
+		err := pasteDir(ctx, srcDir, dst, true, newPasteProgress(&p, &processBarModel, nil), resolver)
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 0, p.Done)
+		assert.FileExists(t, srcFile)
+		assert.NoDirExists(t, dst)
+	})
+}
+
+func TestPasteProgress(t *testing.T) {
+	curTestDir := t.TempDir()
+	srcDir := filepath.Join(curTestDir, "src")
+	dstDir := filepath.Join(curTestDir, "dst")
+	subDir := filepath.Join(srcDir, "subdir")
+	utils.SetupDirectories(t, srcDir, dstDir, subDir)
+	utils.SetupFilesWithData(t, make([]byte, 1000), filepath.Join(srcDir, "file1"))
+	utils.SetupFilesWithData(t, make([]byte, 500), filepath.Join(subDir, "file2"))
+
+	t.Run("copyFile reports bytes", func(t *testing.T) {
+		src := filepath.Join(srcDir, "file1")
+		info, err := os.Stat(src)
+		require.NoError(t, err)
+		var copied int64
+		_, err = copyFile(context.Background(), src, filepath.Join(dstDir, "file1"), info, func(n int64) {
+			copied += n
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1000), copied)
+	})
+
+	t.Run("Totals are counted in background", func(t *testing.T) {
+		totals := <-getPasteTotalsInBackground(context.Background(), []string{srcDir}, common.SymlinkCopyLink)
+		assert.Equal(t, pasteTotals{files: 2, bytes: 1500}, totals)
+	})
+
+	t.Run("pasteDir tracks files and bytes", func(t *testing.T) {
+		processBarModel := processbar.New()
+		p := processbar.NewProcess("1", "paste", 0)
+		totals := make(chan pasteTotals, 1)
+		progress := newPasteProgress(&p, &processBarModel, totals)
+		assert.True(t, p.TotalPending)
+
+		totals <- pasteTotals{files: 2, bytes: 1500}
+		resolver := newPasteConflictResolver(common.PasteConflictRename, nil)
+		err := pasteDir(context.Background(), srcDir, filepath.Join(dstDir, "src"), false, progress, resolver)
+		require.NoError(t, err)
+
+		assert.False(t, p.TotalPending)
+		assert.Equal(t, 2, p.Total)
+		assert.Equal(t, int64(1500), p.TotalBytes)
+		assert.Equal(t, 2, p.Done)
+		assert.Equal(t, int64(1500), p.DoneBytes)
+	})
+
+	t.Run("finish without totals", func(t *testing.T) {
+		processBarModel := processbar.New()
+		p := processbar.NewProcess("1", "paste", 0)
+		progress := newPasteProgress(&p, &processBarModel, make(chan pasteTotals))
+		progress.fileDone(100)
+		p.State = processbar.Successful
+		progress.finish()
+		assert.False(t, p.TotalPending)
+		assert.Equal(t, 1, p.Total)
+		assert.Equal(t, int64(100), p.TotalBytes)
+	})
+}
+
+func TestPreserveAttributes(t *testing.T) {
+	curTestDir := t.TempDir()
+	srcDir := filepath.Join(curTestDir, "src")
+	subDir := filepath.Join(srcDir, "subdir")
+	dstDir := filepath.Join(curTestDir, "dst")
+	srcFile := filepath.Join(subDir, "file.txt")
+	utils.SetupDirectories(t, srcDir, subDir, dstDir)
+	utils.SetupFilesWithData(t, []byte("data"), srcFile)
+
+	older := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chmod(srcFile, 0o640))
+	for _, path := range []string{srcFile, subDir, srcDir} {
+		require.NoError(t, os.Chtimes(path, older, older))
+	}
+
+	setPreserve := func(t *testing.T, preserve bool) {
+		t.Helper()
+		prev := common.Config.PreserveAttributes
+		common.Config.PreserveAttributes = preserve
+		t.Cleanup(func() {
+			common.Config.PreserveAttributes = prev
+		})
+	}
+
+	verifyAttrs := func(t *testing.T, dst string) {
+		t.Helper()
+		for _, rel := range []string{"", "subdir", filepath.Join("subdir", "file.txt")} {
+			info, err := os.Stat(filepath.Join(dst, rel))
+			require.NoError(t, err)
+			assert.True(t, info.ModTime().Equal(older), "mtime of %q should be preserved", rel)
+		}
+		info, err := os.Stat(filepath.Join(dst, "subdir", "file.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+	}
+
+	t.Run("copyDir", func(t *testing.T) {
+		setPreserve(t, true)
+		info, err := os.Stat(srcDir)
+		require.NoError(t, err)
+		dst := filepath.Join(dstDir, "copy")
+		require.NoError(t, copyDir(context.Background(), srcDir, dst, info, nil, nil))
+		verifyAttrs(t, dst)
+	})
+
+	t.Run("pasteDir restores directories after their children", func(t *testing.T) {
+		setPreserve(t, true)
+		processBarModel := processbar.New()
+		p := processbar.NewProcess("1", "paste", 0)
+		dst := filepath.Join(dstDir, "paste")
+		resolver := newPasteConflictResolver(common.PasteConflictRename, nil)
+		err := pasteDir(context.Background(), srcDir, dst, false,
+			newPasteProgress(&p, &processBarModel, nil), resolver)
+		require.NoError(t, err)
+		verifyAttrs(t, dst)
+		assert.Empty(t, p.Warnings)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		setPreserve(t, false)
+		info, err := os.Stat(srcFile)
+		require.NoError(t, err)
+		dst := filepath.Join(dstDir, "file.txt")
+		_, err = copyFile(context.Background(), srcFile, dst, info, nil)
+		require.NoError(t, err)
+		dstInfo, err := os.Stat(dst)
+		require.NoError(t, err)
+		assert.False(t, dstInfo.ModTime().Equal(older))
+	})
+
+	t.Run("Warnings are recorded in the process", func(t *testing.T) {
+		p := processbar.NewProcess("1", "paste", 0)
+		progress := newPasteProgress(&p, nil, nil)
+		warnings := &attrPreserveError{}
+		warnings.add(&attrPreserveError{failures: []string{"a (timestamps : failed)"}})
+		warnings.add(errors.New("not an attribute error"))
+		progress.addWarnings(warnings.orNil())
+		assert.Equal(t, []string{"a (timestamps : failed)"}, p.Warnings)
+	})
+}
@@ -0,0 +1,317 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rkoesters/xdg/trash"
+
+	variable "github.com/yorukot/superfile/src/config"
+	"github.com/yorukot/superfile/src/internal/utils"
+)
+
+const (
+	trashInfoExt = ".trashinfo"
+	// DeletionDate format of the freedesktop.org trash specification
+	trashInfoDateFormat = "2006-01-02T15:04:05"
+	// Deletion date format of the trash view
+	trashDateDisplayFormat = "2006-01-02 15:04"
+	// Below this, the original location of trashed items is not worth showing
+	minTrashPathWidth = 10
+	// Prefix of the trash directories of volumes, followed by the user ID
+	volumeTrashPrefix = ".Trash-"
+)
+
+// trashInfo is the content of a .trashinfo file of the freedesktop.org trash
+type trashInfo struct {
+	originalPath string
+	deletionDate time.Time
+}
+
+func readTrashInfo(path string) (trashInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return trashInfo{}, err
+	}
+	defer f.Close()
+
+	var res trashInfo
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "Path":
+			// The path is supposed to be URL escaped, but not all tools do it
+			res.originalPath = value
+			if unescaped, err := url.PathUnescape(value); err == nil {
+				res.originalPath = unescaped
+			}
+		case "DeletionDate":
+			res.deletionDate, _ = time.ParseInLocation(trashInfoDateFormat, value, time.Local)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return trashInfo{}, err
+	}
+	if res.originalPath == "" {
+		return trashInfo{}, fmt.Errorf("no path in trash info file %s", path)
+	}
+	return res, nil
+}
+
+// findInLinuxTrash returns the location in the trash of the most recently trashed
+// item that was at originalPath
+func findInLinuxTrash(originalPath string) (string, error) {
+	entries, err := os.ReadDir(variable.LinuxTrashDirectoryInfo)
+	if err != nil {
+		return "", err
+	}
+	var res string
+	var resDate time.Time
+	for _, entry := range entries {
+		// Items are trashed with their name, followed by a suffix if it is already used
+		if !strings.HasPrefix(entry.Name(), filepath.Base(originalPath)) ||
+			!strings.HasSuffix(entry.Name(), trashInfoExt) {
+			continue
+		}
+		info, err := readTrashInfo(filepath.Join(variable.LinuxTrashDirectoryInfo, entry.Name()))
+		if err != nil || info.originalPath != originalPath {
+			continue
+		}
+		if res == "" || !info.deletionDate.Before(resDate) {
+			res = filepath.Join(variable.LinuxTrashDirectoryFiles, strings.TrimSuffix(entry.Name(), trashInfoExt))
+			resDate = info.deletionDate
+		}
+	}
+	if res == "" {
+		return "", fmt.Errorf("%s not found in trash", originalPath)
+	}
+	return res, nil
+}
+
+// restoreFromTrash moves an item from its location in the trash back to originalPath,
+// which must not exist
+func restoreFromTrash(trashedPath string, originalPath string) error {
+	if _, err := os.Lstat(originalPath); err == nil {
+		return fmt.Errorf("%s already exists", originalPath)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(originalPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create parent directory: %w", err)
+	}
+	if err := moveElement(context.Background(), trashedPath, originalPath, nil, nil); err != nil {
+		return err
+	}
+	if err := removeTrashInfo(trashedPath); err != nil {
+		return fmt.Errorf("item restored, but failed to remove its trash info: %w", err)
+	}
+	return nil
+}
+
+// isTrashDirectory reports whether path is the directory of the trashed items, that
+// file panels show as the trash view
+func isTrashDirectory(path string) bool {
+	return runtime.GOOS == utils.OsLinux && path == variable.LinuxTrashDirectoryFiles
+}
+
+// trashInfoPath returns the path of the .trashinfo file of an item of a trash. It is in
+// the info directory next to the files directory of the item.
+func trashInfoPath(trashedPath string) string {
+	trashDir := filepath.Dir(filepath.Dir(trashedPath))
+	return filepath.Join(trashDir, "info", filepath.Base(trashedPath)+trashInfoExt)
+}
+
+// isTrashFilesDir reports whether dir holds the items of the home trash, or of the trash
+// of a volume
+func isTrashFilesDir(dir string) bool {
+	return dir == variable.LinuxTrashDirectoryFiles ||
+		(filepath.Base(dir) == "files" && strings.HasPrefix(filepath.Base(filepath.Dir(dir)), volumeTrashPrefix))
+}
+
+// removeTrashInfo removes the .trashinfo file of path, if path is an item of a trash
+func removeTrashInfo(path string) error {
+	if !isTrashFilesDir(filepath.Dir(path)) {
+		return nil
+	}
+	if err := os.Remove(trashInfoPath(path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// deletePermanently removes path, along with its trash info if it is an item of the trash
+func deletePermanently(path string) error {
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+	return removeTrashInfo(path)
+}
+
+// addTrashInfo sets the trash info of the elements of the trash view. Elements are sorted
+// by deletion date instead of modification date, as that is when they were moved there,
+// still with directories first.
+func addTrashInfo(elements []element, sortOptions sortOptionsModelData) []element {
+	for i := range elements {
+		info, err := readTrashInfo(trashInfoPath(elements[i].location))
+		if err != nil {
+			slog.Debug("Cannot read trash info", "item", elements[i].location, "error", err)
+			continue
+		}
+		elements[i].trashInfo = &info
+	}
+	if sortOptions.options[sortOptions.selected] == string(sortingDateModified) {
+		slices.SortStableFunc(elements, func(a, b element) int {
+			// One of them is a directory, and other is not
+			if a.directory != b.directory {
+				if a.directory {
+					return -1
+				}
+				return 1
+			}
+			var dateA, dateB time.Time
+			if a.trashInfo != nil {
+				dateA = a.trashInfo.deletionDate
+			}
+			if b.trashInfo != nil {
+				dateB = b.trashInfo.deletionDate
+			}
+			// Most recently deleted first, like for modification dates
+			if sortOptions.reversed {
+				return dateA.Compare(dateB)
+			}
+			return dateB.Compare(dateA)
+		})
+	}
+	return elements
+}
+
+// canTrash reports whether the items of dir can be moved to the trash. On Linux, items of
+// other devices than the home trash are moved to the trash of their volume. The trash of
+// macOS is in the home directory, items of other devices would have to be copied to it,
+// so they are deleted permanently instead.
+func canTrash(dir string) bool {
+	if runtime.GOOS != utils.OsDarwin {
+		return true
+	}
+	sameDev, err := isSamePartition(dir, variable.DarwinTrashDirectory)
+	if err != nil {
+		// The items are still moved to the trash, moving them reports the error if it fails
+		slog.Error("Cannot check whether items can be moved to the trash", "dir", dir, "error", err)
+		return true
+	}
+	return sameDev
+}
+
+// moveToLinuxTrash moves src to the trash of the home directory, or to the trash of its
+// volume if it is on another device, as per the freedesktop.org trash specification. It
+// returns the location of src in the trash, or an empty string if it is unknown.
+func moveToLinuxTrash(src string) (string, error) {
+	sameDev, err := isSamePartition(src, variable.LinuxTrashDirectoryFiles)
+	if err != nil {
+		// The home trash is tried anyway, moving src to it reports the error if it fails
+		slog.Error("Cannot compare the devices of an item and of the trash", "item", src, "error", err)
+	}
+	if err == nil && !sameDev {
+		absSrc, absErr := filepath.Abs(src)
+		if absErr != nil {
+			return "", absErr
+		}
+		topDir, mountErr := mountPoint(absSrc)
+		if mountErr != nil {
+			return "", fmt.Errorf("cannot find the volume of %s: %w", src, mountErr)
+		}
+		return moveToVolumeTrash(absSrc, topDir)
+	}
+
+	// TODO: We should consider moving away from this package. Its not well written.
+	// It uses package globals, It doesn't initializes trash directory, and we have to do it
+	// separately outside of the this package. There is not documentation about this
+	// It also uses deprecated libraries, and isn't well maintained.
+	if err = trash.Trash(src); err != nil {
+		return "", err
+	}
+	trashedPath, err := findInLinuxTrash(src)
+	if err != nil {
+		slog.Error("Cannot find trashed item in trash", "item", src, "error", err)
+	}
+	return trashedPath, nil
+}
+
+// moveToVolumeTrash moves src to the $topDir/.Trash-$uid trash of its volume, whose top
+// directory is topDir. The $topDir/.Trash directory shared by the users isn't used.
+func moveToVolumeTrash(src string, topDir string) (string, error) {
+	trashDir := filepath.Join(topDir, volumeTrashPrefix+strconv.Itoa(os.Getuid()))
+	filesDir := filepath.Join(trashDir, "files")
+	for _, dir := range []string{trashDir, filesDir, filepath.Join(trashDir, "info")} {
+		// Only the user may see their trashed items
+		if err := os.Mkdir(dir, 0o700); err != nil && !errors.Is(err, os.ErrExist) {
+			return "", fmt.Errorf("cannot create the trash of the volume: %w", err)
+		}
+	}
+	relPath, err := filepath.Rel(topDir, src)
+	if err != nil {
+		return "", err
+	}
+	trashedPath, err := renameIfDuplicate(filepath.Join(filesDir, filepath.Base(src)))
+	if err != nil {
+		return "", err
+	}
+
+	// The trash info is created first, so that the trashed item can always be restored.
+	// Paths in the trash of a volume are relative to its top directory.
+	infoPath := trashInfoPath(trashedPath)
+	info := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n", (&url.URL{Path: relPath}).EscapedPath(),
+		time.Now().Format(trashInfoDateFormat))
+	f, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+	_, err = f.WriteString(info)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(src, trashedPath)
+	}
+	if err != nil {
+		if removeErr := os.Remove(infoPath); removeErr != nil {
+			slog.Error("Cannot remove the trash info of an item that wasn't trashed", "path", infoPath,
+				"error", removeErr)
+		}
+		return "", err
+	}
+	return trashedPath, nil
+}
+
+// mountPoint returns the top directory of the volume of path
+func mountPoint(path string) (string, error) {
+	for {
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path, nil
+		}
+		sameDev, err := sameDevice(path, parent)
+		if err != nil {
+			return "", err
+		}
+		if !sameDev {
+			return path, nil
+		}
+		path = parent
+	}
+}
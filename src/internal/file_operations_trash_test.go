@@ -0,0 +1,192 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	variable "github.com/yorukot/superfile/src/config"
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+	"github.com/yorukot/superfile/src/internal/utils"
+)
+
+// setupTestTrash points the trash directories to an empty trash in a temporary directory
+func setupTestTrash(t *testing.T) {
+	t.Helper()
+	oldFiles, oldInfo := variable.LinuxTrashDirectoryFiles, variable.LinuxTrashDirectoryInfo
+	trashDir := t.TempDir()
+	variable.LinuxTrashDirectoryFiles = filepath.Join(trashDir, "files")
+	variable.LinuxTrashDirectoryInfo = filepath.Join(trashDir, "info")
+	utils.SetupDirectories(t, variable.LinuxTrashDirectoryFiles, variable.LinuxTrashDirectoryInfo)
+	t.Cleanup(func() {
+		variable.LinuxTrashDirectoryFiles, variable.LinuxTrashDirectoryInfo = oldFiles, oldInfo
+	})
+}
+
+// addToTestTrash creates a trashed file named name, deleted from originalPath at deletionDate
+func addToTestTrash(t *testing.T, name string, originalPath string, deletionDate string) string {
+	t.Helper()
+	trashedPath := filepath.Join(variable.LinuxTrashDirectoryFiles, name)
+	utils.SetupFilesWithData(t, []byte(name), trashedPath)
+	utils.SetupFilesWithData(t, fmt.Appendf(nil, "[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		originalPath, deletionDate), trashInfoPath(trashedPath))
+	return trashedPath
+}
+
+func TestReadTrashInfo(t *testing.T) {
+	curTestDir := t.TempDir()
+	testdata := []struct {
+		name         string
+		content      string
+		expectedPath string
+		expectedDate time.Time
+		expectError  bool
+	}{
+		{
+			name:         "Escaped path",
+			content:      "[Trash Info]\nPath=/home/user/my%20file.txt\nDeletionDate=2024-05-01T10:20:30\n",
+			expectedPath: "/home/user/my file.txt",
+			expectedDate: time.Date(2024, 5, 1, 10, 20, 30, 0, time.Local),
+		},
+		{
+			name:         "Unescaped path",
+			content:      "[Trash Info]\nPath=/home/user/100%.txt\nDeletionDate=2024-05-01T10:20:30\n",
+			expectedPath: "/home/user/100%.txt",
+			expectedDate: time.Date(2024, 5, 1, 10, 20, 30, 0, time.Local),
+		},
+		{
+			name:         "Invalid date",
+			content:      "[Trash Info]\nPath=/tmp/a\nDeletionDate=yesterday\n",
+			expectedPath: "/tmp/a",
+		},
+		{
+			name:        "No path",
+			content:     "[Trash Info]\nDeletionDate=2024-05-01T10:20:30\n",
+			expectError: true,
+		},
+	}
+
+	for i, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			infoFile := filepath.Join(curTestDir, fmt.Sprintf("%d%s", i, trashInfoExt))
+			utils.SetupFilesWithData(t, []byte(tt.content), infoFile)
+			info, err := readTrashInfo(infoFile)
+			if tt.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedPath, info.originalPath)
+			assert.True(t, tt.expectedDate.Equal(info.deletionDate), "got date %v", info.deletionDate)
+		})
+	}
+}
+
+func TestTrashView(t *testing.T) {
+	if runtime.GOOS != utils.OsLinux {
+		t.Skip("The trash view is only supported on Linux")
+	}
+	setupTestTrash(t)
+	curTestDir := t.TempDir()
+	older := addToTestTrash(t, "older.txt", filepath.Join(curTestDir, "older.txt"), "2024-01-01T00:00:00")
+	newer := addToTestTrash(t, "newer.txt", filepath.Join(curTestDir, "sub", "newer.txt"), "2024-06-01T00:00:00")
+	require.True(t, isTrashDirectory(variable.LinuxTrashDirectoryFiles))
+
+	t.Run("Sorted by deletion date", func(t *testing.T) {
+		// Directories stay first, even the oldest one
+		dir := filepath.Join(variable.LinuxTrashDirectoryFiles, "dir")
+		utils.SetupDirectories(t, dir)
+		utils.SetupFilesWithData(t, fmt.Appendf(nil, "[Trash Info]\nPath=%s\nDeletionDate=2023-01-01T00:00:00\n",
+			filepath.Join(curTestDir, "dir")), trashInfoPath(dir))
+		t.Cleanup(func() {
+			require.NoError(t, os.Remove(dir))
+			require.NoError(t, os.Remove(trashInfoPath(dir)))
+		})
+
+		sortOptions := defaultFilePanel(variable.LinuxTrashDirectoryFiles, true).sortOptions.data
+		sortOptions.selected = slices.Index(sortOptions.options, string(sortingDateModified))
+		elements := addTrashInfo(returnDirElement(variable.LinuxTrashDirectoryFiles, true, sortOptions), sortOptions)
+		require.Len(t, elements, 3)
+		assert.Equal(t, dir, elements[0].location)
+		assert.Equal(t, newer, elements[1].location)
+		assert.Equal(t, older, elements[2].location)
+		require.NotNil(t, elements[1].trashInfo)
+		assert.Equal(t, filepath.Join(curTestDir, "sub", "newer.txt"), elements[1].trashInfo.originalPath)
+
+		sortOptions.reversed = true
+		elements = addTrashInfo(elements, sortOptions)
+		assert.Equal(t, dir, elements[0].location)
+		assert.Equal(t, older, elements[1].location)
+	})
+
+	t.Run("Restore with conflict", func(t *testing.T) {
+		// An item now exists where older.txt was deleted from
+		utils.SetupFilesWithData(t, []byte("new content"), filepath.Join(curTestDir, "older.txt"))
+		processBarModel := processbar.New()
+		resolver := newPasteConflictResolver(common.PasteConflictRename, nil)
+		state := restoreOperation(&processBarModel, []string{older, newer}, resolver)
+		assert.Equal(t, processbar.Successful, state)
+
+		assert.FileExists(t, filepath.Join(curTestDir, "sub", "newer.txt"))
+		data, err := os.ReadFile(filepath.Join(curTestDir, "older(1).txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "older.txt", string(data))
+		data, err = os.ReadFile(filepath.Join(curTestDir, "older.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "new content", string(data))
+
+		assert.NoFileExists(t, older)
+		assert.NoFileExists(t, trashInfoPath(older))
+		assert.NoFileExists(t, trashInfoPath(newer))
+	})
+
+	t.Run("Permanent delete removes trash info", func(t *testing.T) {
+		trashed := addToTestTrash(t, "deleted.txt", filepath.Join(curTestDir, "deleted.txt"), "2024-01-01T00:00:00")
+		require.NoError(t, deletePermanently(trashed))
+		assert.NoFileExists(t, trashed)
+		assert.NoFileExists(t, trashInfoPath(trashed))
+	})
+}
+
+func TestMoveToVolumeTrash(t *testing.T) {
+	if runtime.GOOS != utils.OsLinux {
+		t.Skip("The trash of volumes is only supported on Linux")
+	}
+	topDir := t.TempDir()
+	src := filepath.Join(topDir, "dir", "my file.txt")
+	utils.SetupDirectories(t, filepath.Dir(src))
+	utils.SetupFilesWithData(t, []byte("first"), src)
+	trashDir := filepath.Join(topDir, fmt.Sprintf(".Trash-%d", os.Getuid()))
+
+	trashedPath, err := moveToVolumeTrash(src, topDir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(trashDir, "files", "my file.txt"), trashedPath)
+	assert.NoFileExists(t, src)
+	info, err := os.Stat(trashDir)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o700), info.Mode().Perm())
+	trashInfo, err := readTrashInfo(trashInfoPath(trashedPath))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("dir", "my file.txt"), trashInfo.originalPath,
+		"paths in the trash of a volume should be relative to its top directory")
+
+	utils.SetupFilesWithData(t, []byte("second"), src)
+	secondPath, err := moveToVolumeTrash(src, topDir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(trashDir, "files", "my file(1).txt"), secondPath)
+
+	require.NoError(t, restoreFromTrash(trashedPath, src))
+	data, err := os.ReadFile(src)
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(data))
+	assert.NoFileExists(t, trashInfoPath(trashedPath))
+	assert.FileExists(t, trashInfoPath(secondPath))
+}
@@ -58,9 +58,11 @@ func (panel *filePanel) updateCurrentFilePanelDir(path string) error {
 		directoryRender: panel.render,
 	}
 
-	if info, err := os.Stat(path); err != nil {
-		return fmt.Errorf("%s : no such file or directory, stats err : %w", path, err)
-	} else if !info.IsDir() {
+	// Archives, and the directories inside them, are browsed like directories
+	if info, err := os.Stat(path); (err != nil || !info.IsDir()) && !isArchiveDir(path) {
+		if err != nil {
+			return fmt.Errorf("%s : no such file or directory, stats err : %w", path, err)
+		}
 		return fmt.Errorf("%s is not a directory", path)
 	}
 
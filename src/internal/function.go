@@ -1,13 +1,13 @@
 package internal
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
-	"runtime"
 	"slices"
 	"sort"
 	"strconv"
@@ -30,33 +30,6 @@ const (
 
 var suffixRegexp = regexp.MustCompile(`^(.*)\((\d+)\)$`)
 
-// Check if the directory is external disk path
-// TODO : This function should be give two directories, and it should return
-// if the two share a different disk partition.
-// Ideally we shouldn't even try to figure that out in our file operations, and let OS handles it.
-// But at least right now its not okay. This returns if `path` is an External disk
-// from perspective of `/`, but it should tell from perspective of currently open directory
-// The usage of this function in cut/paste is not as expected.
-func isExternalDiskPath(path string) bool {
-	// This is very vague. You cannot tell if a path is belonging to an external partition
-	// if you dont define the source path to compare with
-	// But making this true will cause slow file operations based on current implementation
-	if runtime.GOOS == utils.OsWindows {
-		return false
-	}
-
-	// exclude timemachine on MacOS
-	if strings.HasPrefix(path, "/Volumes/.timemachine") {
-		return false
-	}
-
-	// to filter out mounted partitions like /, /boot etc
-	return strings.HasPrefix(path, "/mnt") ||
-		strings.HasPrefix(path, "/media") ||
-		strings.HasPrefix(path, "/run/media") ||
-		strings.HasPrefix(path, "/Volumes")
-}
-
 func returnFocusType(focusPanel focusPanelType) bool {
 	return focusPanel == nonePanelFocus
 }
@@ -65,7 +38,7 @@ func returnFocusType(focusPanel focusPanelType) bool {
 // and also consider testing this caseSensitive with both true and false in
 // our unit_test TestReturnDirElement
 func returnDirElement(location string, displayDotFile bool, sortOptions sortOptionsModelData) []element {
-	dirEntries, err := os.ReadDir(location)
+	dirEntries, err := readDir(location)
 	if err != nil {
 		slog.Error("Error while returning folder elements", "error", err)
 		return nil
@@ -87,7 +60,7 @@ func returnDirElement(location string, displayDotFile bool, sortOptions sortOpti
 func returnDirElementBySearchString(location string, displayDotFile bool, searchString string,
 	sortOptions sortOptionsModelData,
 ) []element {
-	items, err := os.ReadDir(location)
+	items, err := readDir(location)
 	if err != nil {
 		slog.Error("Error while return folder element function", "error", err)
 		return nil
@@ -184,13 +157,13 @@ func getSizeOrderingFunc(dirEntries []os.DirEntry, reversed bool, location strin
 		// This needs to be improved, and we should sort by actual size only
 		// Repeated recursive read would be slow, so we could cache
 		if dirEntries[i].IsDir() && dirEntries[j].IsDir() {
-			filesI, err := os.ReadDir(filepath.Join(location, dirEntries[i].Name()))
+			filesI, err := readDir(filepath.Join(location, dirEntries[i].Name()))
 			// No need of early return, we only call len() on filesI, so nil would
 			// just result in 0
 			if err != nil {
 				slog.Error("Error when reading directory during sort", "error", err)
 			}
-			filesJ, err := os.ReadDir(filepath.Join(location, dirEntries[j].Name()))
+			filesJ, err := readDir(filepath.Join(location, dirEntries[j].Name()))
 			if err != nil {
 				slog.Error("Error when reading directory during sort", "error", err)
 			}
@@ -309,19 +282,28 @@ func (m *model) getFocusedFilePanel() *filePanel {
 
 // Count how many file in the directory
 func countFiles(dirPath string) (int, error) {
+	count, _, err := countFilesAndSize(context.Background(), dirPath, common.Config.SymlinkPolicy)
+	return count, err
+}
+
+// countFilesAndSize returns the count of files in dirPath, and their total size.
+// Symlinks are counted as per symlinkPolicy.
+func countFilesAndSize(ctx context.Context, dirPath string, symlinkPolicy string) (int, int64, error) {
 	count := 0
+	var size int64
 
-	err := filepath.Walk(dirPath, func(_ string, info os.FileInfo, err error) error {
-		if err != nil {
+	err := walkWithSymlinkPolicy(dirPath, symlinkPolicy, func(_ string, info os.FileInfo) error {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
 		if !info.IsDir() {
 			count++
+			size += info.Size()
 		}
 		return nil
 	})
 
-	return count, err
+	return count, size, err
 }
 
 func processCmdToTeaCmd(cmd processbar.Cmd) tea.Cmd {
@@ -0,0 +1,251 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/notify"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+)
+
+// Files inside archives that are larger than this are not extracted to be previewed
+const maxArchivePreviewSize = 32 * 1024 * 1024
+
+// isArchiveReadOnlyKey reports whether msg is a hotkey that would change the items of
+// the focused file panel, while it shows the content of an archive
+func (m *model) isArchiveReadOnlyKey(msg string) bool {
+	hotkeys := [][]string{
+		common.Hotkeys.PasteItems,
+		common.Hotkeys.PasteAsSymlink,
+		common.Hotkeys.PasteAsRelativeSymlink,
+		common.Hotkeys.PasteAsHardlink,
+		common.Hotkeys.FilePanelItemCreate,
+		common.Hotkeys.ExtractFile,
+		common.Hotkeys.ExtractFileHere,
+		common.Hotkeys.ExtractFileToOtherPanel,
+		common.Hotkeys.CompressFile,
+		common.Hotkeys.CompressFileWithFormat,
+		common.Hotkeys.BatchRename,
+		common.Hotkeys.ChangePermissions,
+		common.Hotkeys.OpenFileWithEditor,
+		common.Hotkeys.OpenCurrentDirectoryWithEditor,
+	}
+	// These hotkeys act on the sidebar or the process bar when they are focused
+	if m.getFocusedFilePanel().isFocused {
+		hotkeys = append(hotkeys, common.Hotkeys.DeleteItems, common.Hotkeys.PermanentlyDeleteItems,
+			common.Hotkeys.CutItems, common.Hotkeys.FilePanelItemRename)
+	}
+	return slices.ContainsFunc(hotkeys, func(keys []string) bool {
+		return slices.Contains(keys, msg)
+	}) && isInArchive(m.getFocusedFilePanel().location)
+}
+
+func (m *model) openArchiveReadOnlyModal() {
+	m.notifyModel = notify.New(true, common.ArchiveReadOnlyTitle, common.ArchiveReadOnlyContent, notify.NoAction)
+}
+
+// archiveLoad is the reading of the listing of an archive, see getArchiveLoadCmd
+type archiveLoad struct {
+	reqID  int
+	cancel context.CancelFunc
+}
+
+// getArchiveLoadCmd starts reading the listings of the archives browsed by the file
+// panels that are not loaded yet. The reading of an archive that no file panel browses
+// anymore, as the user left it, is cancelled.
+func (m *model) getArchiveLoadCmd() tea.Cmd {
+	browsed := make(map[string]bool)
+	var cmds []tea.Cmd
+	for _, panel := range m.fileModel.filePanels {
+		if !isArchiveLoading(panel.location) {
+			continue
+		}
+		archivePath, _, _ := splitArchivePath(panel.location)
+		browsed[archivePath] = true
+		if _, ok := m.archiveLoads[archivePath]; !ok {
+			cmds = append(cmds, m.getLoadArchiveCmd(archivePath))
+		}
+	}
+	for archivePath, load := range m.archiveLoads {
+		if !browsed[archivePath] {
+			load.cancel()
+			delete(m.archiveLoads, archivePath)
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m *model) getLoadArchiveCmd(archivePath string) tea.Cmd {
+	reqID := m.ioReqCnt
+	m.ioReqCnt++
+	ctx, cancel := context.WithCancel(context.Background())
+	m.archiveLoads[archivePath] = archiveLoad{reqID: reqID, cancel: cancel}
+	slog.Debug("Submitting archive load request", "id", reqID, "archive", archivePath)
+	return func() tea.Msg {
+		err := archiveListings.load(ctx, archivePath)
+		return NewArchiveLoadedMsg(archivePath, err, reqID)
+	}
+}
+
+// leaveArchive moves the file panels browsing the archive at archivePath to the
+// directory containing it
+func (m *model) leaveArchive(archivePath string) {
+	for i := range m.fileModel.filePanels {
+		panel := &m.fileModel.filePanels[i]
+		if panelArchive, _, ok := splitArchivePath(panel.location); !ok || panelArchive != archivePath {
+			continue
+		}
+		if err := panel.updateCurrentFilePanelDir(filepath.Dir(archivePath)); err != nil {
+			slog.Error("Error while leaving archive", "archive", archivePath, "error", err)
+		}
+	}
+}
+
+// Apply the Action of password modal. The password is kept for the session, and the
+// extraction that needed it continues.
+func (m *model) applyPasswordModalAction(action common.ModelAction) tea.Cmd {
+	req := m.pendingExtract
+	// The extraction is dropped once the modal is closed, with or without a password
+	if !m.passwordModal.IsOpen() {
+		m.pendingExtract = nil
+	}
+	extractAction, ok := action.(common.ExtractArchiveAction)
+	if !ok {
+		_, _ = m.logAndExecuteAction(action)
+		return nil
+	}
+	slog.Debug("Applying model action", "action", extractAction)
+	m.archivePasswords[archiveSetName(extractAction.Archive)] = extractAction.Password
+	if req == nil || req.archives[0] != extractAction.Archive {
+		slog.Error("No extraction waiting for the password", "archive", extractAction.Archive)
+		return nil
+	}
+	return m.getExtractArchivesCmd(*req)
+}
+
+// getClipboardArchive returns the archive containing the items, or "" if they are not
+// inside one. Items inside an archive are extracted instead of copied, so they can't be
+// pasted along with other items.
+func getClipboardArchive(items []string) (string, error) {
+	archivePath, inner, inArchive := splitArchivePath(items[0])
+	inArchive = inArchive && inner != ""
+	for _, item := range items[1:] {
+		itemArchive, itemInner, ok := splitArchivePath(item)
+		itemInArchive := ok && itemInner != ""
+		if itemInArchive != inArchive || inArchive && itemArchive != archivePath {
+			return "", errors.New(common.PasteMixedArchiveContent)
+		}
+	}
+	if !inArchive {
+		return "", nil
+	}
+	return archivePath, nil
+}
+
+// getPasteFromArchiveCmd copies the clipboard items, which are inside an archive, to
+// the focused file panel. Only these items are extracted from the archive.
+func (m *model) getPasteFromArchiveCmd(archivePath string) tea.Cmd {
+	copyItems := m.copyItems.items
+	reqID := m.ioReqCnt
+	m.ioReqCnt++
+	panelLocation := m.getFocusedFilePanel().location
+	password := m.archivePasswords[archiveSetName(archivePath)]
+
+	slog.Debug("Submitting paste from archive request", "id", reqID, "archive", archivePath,
+		"items cnt", len(copyItems), "dest", panelLocation)
+	return func() tea.Msg {
+		resolver := newPasteConflictResolver(common.Config.PasteConflictPolicy, m.askPasteConflict)
+		state := pasteFromArchive(&m.processBarModel, archivePath, password, copyItems, panelLocation, resolver)
+		return NewPasteOperationMsg(state, nil, reqID)
+	}
+}
+
+// pasteFromArchive copies items, which are inside the archive archivePath, into dest
+// by extracting only them and their content. Encrypted items are decrypted with password.
+// They are extracted in a directory of their own first, and then placed in dest, so that
+// items of dest are only replaced as resolver decides.
+func pasteFromArchive(processBar *processbar.Model, archivePath string, password string, items []string,
+	dest string, resolver *pasteConflictResolver) processbar.ProcessState {
+	inner := make([]string, 0, len(items))
+	for _, item := range items {
+		rel, err := filepath.Rel(archivePath, item)
+		if err != nil {
+			slog.Error("Error while getting path of item in archive", "item", item, "error", err)
+			return processbar.Failed
+		}
+		inner = append(inner, filepath.ToSlash(rel))
+	}
+	format := archiveFormatOf(archivePath)
+	selection := selectArchiveItems(inner)
+	state, _ := runExtractProcess(processBar, "Extracting "+filepath.Base(items[0]), []string{archivePath}, dest,
+		func(ctx context.Context) <-chan pasteTotals {
+			return getArchiveTotalsInBackground(ctx, archivePath, format, selection)
+		},
+		func(ctx context.Context, progress *pasteProgress) error {
+			tmpDir, err := os.MkdirTemp(dest, extractTempDirPrefix)
+			if err != nil {
+				return err
+			}
+			defer removeExtractOutput(tmpDir)
+			err = extractArchive(ctx, archivePath, format, password, tmpDir, selection, resolver, progress)
+			if err != nil {
+				return err
+			}
+			return placeExtractedHere(ctx, tmpDir, dest, resolver)
+		})
+	return state
+}
+
+// renderArchiveItemPreview renders the preview of an item inside an archive. Files are
+// extracted to a temporary directory to be previewed like any other file, and decrypted
+// with password if they are encrypted.
+func (m *model) renderArchiveItemPreview(item element, password string, fullModalWidth int) string {
+	if item.directory {
+		files, err := readDir(item.location)
+		if err != nil {
+			slog.Error("Error render directory preview", "error", err)
+			return m.fileModel.filePreview.RenderText(common.FilePreviewDirectoryUnreadableText)
+		}
+		return m.fileModel.filePreview.RenderWithDirEntries(files)
+	}
+	if !item.archiveInfo.Mode().IsRegular() {
+		return m.fileModel.filePreview.RenderText(common.FilePreviewUnsupportedFileMode)
+	}
+	if item.archiveInfo.Size() > maxArchivePreviewSize {
+		return m.fileModel.filePreview.RenderText(common.FilePreviewTooLargeText)
+	}
+	archivePath, inner, _ := splitArchivePath(item.location)
+
+	tmpDir, err := os.MkdirTemp("", "superfile-preview-")
+	if err != nil {
+		slog.Error("Error while creating directory for archive preview", "error", err)
+		return m.fileModel.filePreview.RenderText(common.FilePreviewError)
+	}
+	defer os.RemoveAll(tmpDir)
+	// The name is kept, as the preview depends on the extension
+	tmpPath := filepath.Join(tmpDir, item.name)
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		slog.Error("Error while creating file for archive preview", "error", err)
+		return m.fileModel.filePreview.RenderText(common.FilePreviewError)
+	}
+	err = readArchiveEntry(context.Background(), archivePath, inner, password, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if isArchivePasswordError(err) {
+		return m.fileModel.filePreview.RenderText(common.FilePreviewPasswordNeededText)
+	}
+	if err != nil {
+		slog.Error("Error while reading file of archive for preview", "path", item.location, "error", err)
+		return m.fileModel.filePreview.RenderText(common.FilePreviewError)
+	}
+	return m.fileModel.filePreview.RenderWithPath(tmpPath, fullModalWidth)
+}
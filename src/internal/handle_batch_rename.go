@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/yorukot/superfile/src/config/icon"
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/journal"
+	"github.com/yorukot/superfile/src/internal/ui/notify"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+)
+
+// Open the batch rename modal for the selected items, or the item under the cursor
+func (m *model) openBatchRenameModal() tea.Cmd {
+	panel := m.getFocusedFilePanel()
+	if len(panel.element) == 0 {
+		return nil
+	}
+	items := panel.selected
+	if len(items) == 0 {
+		items = []string{panel.getSelectedItem().location}
+	}
+	if err := m.batchRenameModal.Open(items); err != nil {
+		slog.Error("Error while opening batch rename", "error", err)
+		reqID := m.ioReqCnt
+		m.ioReqCnt++
+		return func() tea.Msg {
+			return NewNotifyModalMsg(notify.New(true, common.BulkRenameFailedTitle, err.Error(), notify.NoAction),
+				reqID)
+		}
+	}
+	return nil
+}
+
+// Apply the Action of batch rename modal. Renames are run as a process
+func (m *model) applyBatchRenameModalAction(action common.ModelAction) tea.Cmd {
+	renameAction, ok := action.(common.BatchRenameAction)
+	if !ok {
+		_, _ = m.logAndExecuteAction(action)
+		return nil
+	}
+	slog.Debug("Applying model action", "action", renameAction)
+	renames := make([]renameItem, len(renameAction.Sources))
+	for i := range renameAction.Sources {
+		renames[i] = renameItem{src: renameAction.Sources[i], dst: renameAction.Destinations[i]}
+	}
+	// The selected paths won't exist anymore
+	m.getFocusedFilePanel().resetSelected()
+
+	reqID := m.ioReqCnt
+	m.ioReqCnt++
+	slog.Debug("Submitting batch rename request", "id", reqID, "renames cnt", len(renames))
+	return func() tea.Msg {
+		state, err := batchRenameOperation(&m.processBarModel, renames, m.journal)
+		if err != nil {
+			return NewNotifyModalMsg(notify.New(true, common.BulkRenameFailedTitle, err.Error(), notify.NoAction),
+				reqID)
+		}
+		return NewBatchRenameOperationMsg(state, reqID)
+	}
+}
+
+// batchRenameOperation applies renames with renameAll, as one process. Either all the
+// items are renamed, or the ones already renamed are reverted.
+func batchRenameOperation(processBarModel *processbar.Model, renames []renameItem,
+	opJournal *journal.Journal) (processbar.ProcessState, error) {
+	p, err := processBarModel.SendAddProcessMsg(
+		fmt.Sprintf("%s%sRenaming %d items", icon.Rename, icon.Space, len(renames)), len(renames), true)
+	if err != nil {
+		slog.Error("Cannot spawn a new process", "error", err)
+		return processbar.Failed, err
+	}
+
+	renameErr := renameAll(renames)
+	if renameErr != nil {
+		slog.Error("Error while applying batch rename", "error", renameErr)
+		p.State = processbar.Failed
+		p.ErrorMsg = renameErr.Error()
+	} else {
+		p.State = processbar.Successful
+		p.Done = len(renames)
+		entry := journal.Entry{Type: journal.Rename}
+		for _, r := range renames {
+			entry.Items = append(entry.Items, journal.Item{Src: r.src, Dst: r.dst})
+		}
+		opJournal.Record(entry)
+	}
+	p.DoneTime = time.Now()
+	if err = processBarModel.SendUpdateProcessMsg(p, true); err != nil {
+		slog.Error("Could not send final update for process Bar", "error", err)
+	}
+	return p.State, renameErr
+}
@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/journal"
+	"github.com/yorukot/superfile/src/internal/ui/notify"
+)
+
+// bulkRenameState is a rename of the items selected in dir, all at once
+type bulkRenameState struct {
+	dir   string
+	items []string
+	// Temporary file with the names of the items, one per line, edited by the user
+	file string
+	// Renames to apply, once confirmed
+	renames []renameItem
+}
+
+// Open the names of the selected items with the editor. Once the editor exits, the
+// changed names are shown for confirmation, and then renamed.
+func (m *model) bulkRenameSelectedItems() tea.Cmd {
+	panel := m.getFocusedFilePanel()
+	if len(panel.selected) == 0 {
+		return nil
+	}
+	reqID := m.ioReqCnt
+	m.ioReqCnt++
+
+	file, err := writeBulkRenameFile(panel.location, panel.selected)
+	if err != nil {
+		slog.Error("Error while preparing bulk rename", "error", err)
+		return func() tea.Msg {
+			return NewNotifyModalMsg(notify.New(true, common.BulkRenameFailedTitle, err.Error(), notify.NoAction),
+				reqID)
+		}
+	}
+	m.bulkRename = &bulkRenameState{
+		dir:   panel.location,
+		items: slices.Clone(panel.selected),
+		file:  file,
+	}
+	slog.Debug("Opening bulk rename file", "id", reqID, "file", file, "items cnt", len(panel.selected))
+	return tea.ExecProcess(getEditorCmd(file), func(err error) tea.Msg {
+		return NewBulkRenameEditedMsg(err, reqID)
+	})
+}
+
+// writeBulkRenameFile writes the paths of items relative to dir to a new temporary
+// file, one per line, and returns its path
+func writeBulkRenameFile(dir string, items []string) (string, error) {
+	var content strings.Builder
+	for _, item := range items {
+		name, err := filepath.Rel(dir, item)
+		if err != nil {
+			return "", fmt.Errorf("cannot get name of %s: %w", item, err)
+		}
+		if strings.ContainsAny(name, "\r\n") {
+			return "", fmt.Errorf("%q contains a line break, and can't be renamed in the editor", name)
+		}
+		content.WriteString(name + "\n")
+	}
+
+	f, err := os.CreateTemp("", "spf-bulk-rename-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("cannot create temporary file: %w", err)
+	}
+	_, err = f.WriteString(content.String())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("cannot write temporary file: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// Read back the names edited in the editor, and ask to confirm the renames
+func (m *model) handleBulkRenameEdited(editorErr error) {
+	state := m.bulkRename
+	if state == nil {
+		slog.Error("Bulk rename edited without a pending bulk rename")
+		return
+	}
+	defer os.Remove(state.file)
+
+	renames, err := readBulkRenameFile(state, editorErr)
+	if err != nil {
+		slog.Error("Error while reading bulk rename", "error", err)
+		m.bulkRename = nil
+		m.notifyModel = notify.New(true, common.BulkRenameFailedTitle, err.Error(), notify.NoAction)
+		return
+	}
+	if len(renames) == 0 {
+		slog.Debug("No item renamed in bulk rename")
+		m.bulkRename = nil
+		return
+	}
+
+	state.renames = renames
+	lines := make([]string, 0, len(renames))
+	for _, r := range renames {
+		lines = append(lines, relativeName(state.dir, r.src)+" -> "+relativeName(state.dir, r.dst))
+	}
+	m.notifyModel = notify.NewList(common.BulkRenameTitle,
+		fmt.Sprintf("%d items will be renamed", len(renames)), notify.BulkRenameAction, lines)
+}
+
+func readBulkRenameFile(state *bulkRenameState, editorErr error) ([]renameItem, error) {
+	if editorErr != nil {
+		return nil, fmt.Errorf("editor failed: %w", editorErr)
+	}
+	content, err := os.ReadFile(state.file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read edited names: %w", err)
+	}
+	return parseBulkRename(state.dir, state.items, string(content))
+}
+
+func (m *model) cancelBulkRename() {
+	m.bulkRename = nil
+}
+
+// Apply the confirmed bulk rename
+func (m *model) getBulkRenameCmd() tea.Cmd {
+	state := m.bulkRename
+	m.bulkRename = nil
+	if state == nil {
+		slog.Error("Bulk rename confirmed without a pending bulk rename")
+		return nil
+	}
+	// The selected paths won't exist anymore
+	m.getFocusedFilePanel().resetSelected()
+
+	reqID := m.ioReqCnt
+	m.ioReqCnt++
+	slog.Debug("Submitting bulk rename request", "id", reqID, "renames cnt", len(state.renames))
+	return func() tea.Msg {
+		if err := renameAll(state.renames); err != nil {
+			slog.Error("Error while applying bulk rename", "error", err)
+			return NewNotifyModalMsg(notify.New(true, common.BulkRenameFailedTitle, err.Error(), notify.NoAction),
+				reqID)
+		}
+		entry := journal.Entry{Type: journal.Rename}
+		for _, r := range state.renames {
+			entry.Items = append(entry.Items, journal.Item{Src: r.src, Dst: r.dst})
+		}
+		m.journal.Record(entry)
+		return nil
+	}
+}
+
+func relativeName(dir string, path string) string {
+	if rel, err := filepath.Rel(dir, path); err == nil {
+		return rel
+	}
+	return path
+}
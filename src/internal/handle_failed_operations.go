@@ -0,0 +1,157 @@
+package internal
+
+import (
+	"log/slog"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+)
+
+// failedOperation is what is needed to run the items of a failed paste or delete
+// again. The items themselves are kept in the process.
+type failedOperation struct {
+	processID string
+	paste     bool
+	// Only for pastes
+	dest string
+	cut  bool
+	// Only for deletes
+	useTrash bool
+}
+
+// newFailedPaste returns the failedOperation of the paste process p, or nil if it has
+// no items to paste again
+func newFailedPaste(p processbar.Process, dest string, cut bool) *failedOperation {
+	if !hasItemsToRetry(p) {
+		return nil
+	}
+	return &failedOperation{processID: p.ID, paste: true, dest: dest, cut: cut}
+}
+
+// newFailedDelete returns the failedOperation of the delete process p, or nil if it has
+// no items to delete again
+func newFailedDelete(p processbar.Process, useTrash bool) *failedOperation {
+	if !hasItemsToRetry(p) {
+		return nil
+	}
+	return &failedOperation{processID: p.ID, useTrash: useTrash}
+}
+
+func hasItemsToRetry(p processbar.Process) bool {
+	return p.State == processbar.Failed && (len(p.Failures) > 0 || len(p.Remaining) > 0)
+}
+
+// recordItemFailure adds item to the failed items of p. The first failure is also
+// the error message of the process.
+func recordItemFailure(p *processbar.Process, item string, err error) {
+	p.Failures = append(p.Failures, processbar.ItemFailure{Item: item, Error: err.Error()})
+	if p.ErrorMsg == "" {
+		p.ErrorMsg = item + ": " + err.Error()
+	}
+}
+
+// recordPasteFailure is like recordItemFailure, for an item that failed while being pasted
+// to dst. dst is empty if nothing was pasted.
+func recordPasteFailure(p *processbar.Process, item string, dst string, err error) {
+	recordItemFailure(p, item, err)
+	p.Failures[len(p.Failures)-1].Dst = dst
+}
+
+// finishItemsProcess sets the final state of p, once its items were handled
+func finishItemsProcess(p *processbar.Process) {
+	if p.State != processbar.InOperation {
+		return
+	}
+	p.State = processbar.Successful
+	if len(p.Failures) > 0 {
+		p.State = processbar.Failed
+	}
+}
+
+// addFailedOperation keeps failed, if not nil, till its process is removed from the
+// process bar
+func (m *model) addFailedOperation(failed *failedOperation) {
+	for id := range m.failedOps {
+		if _, ok := m.processBarModel.GetByID(id); !ok {
+			delete(m.failedOps, id)
+		}
+	}
+	if failed != nil {
+		m.failedOps[failed.processID] = *failed
+	}
+}
+
+// openFailuresModal shows the failed items of the selected process
+func (m *model) openFailuresModal() {
+	p, err := m.processBarModel.GetSelectedProcess()
+	if err != nil || p.State != processbar.Failed || len(p.Failures) == 0 {
+		return
+	}
+	_, retryable := m.failedOps[p.ID]
+	m.failuresModal.Open(p, retryable)
+}
+
+func (m *model) applyFailuresModalAction(action common.ModelAction) tea.Cmd {
+	retryAction, ok := action.(common.RetryOperationAction)
+	if !ok {
+		_, _ = m.logAndExecuteAction(action)
+		return nil
+	}
+	slog.Debug("Applying model action", "action", retryAction)
+	return m.getRetryCmd(retryAction.ProcessID, retryAction.Mode)
+}
+
+// getRetryCmd runs the items of the failed process processID again, as picked by mode
+func (m *model) getRetryCmd(processID string, mode common.RetryMode) tea.Cmd {
+	failed, ok := m.failedOps[processID]
+	p, found := m.processBarModel.GetByID(processID)
+	if !ok || !found {
+		slog.Error("No failed operation to retry", "id", processID)
+		return nil
+	}
+	items, skipErrors := getRetryItems(p, mode)
+	if len(items) == 0 {
+		return nil
+	}
+	// Its items now belong to the new process
+	delete(m.failedOps, processID)
+
+	failures := p.Failures
+
+	reqID := m.ioReqCnt
+	m.ioReqCnt++
+	slog.Debug("Submitting retry request", "id", reqID, "process", processID, "mode", mode,
+		"items cnt", len(items))
+	return func() tea.Msg {
+		if !failed.paste {
+			p := deleteOperation(&m.processBarModel, items, failed.useTrash, skipErrors, m.journal)
+			return NewRetryOperationMsg(p.State, newFailedDelete(p, failed.useTrash), reqID)
+		}
+		resolver := newPasteConflictResolver(common.Config.PasteConflictPolicy, m.askPasteConflict)
+		// Failed items are pasted again where they were being pasted, and not next to it
+		resolver.resumeFailed(failures)
+		p := executePasteOperation(&m.processBarModel, failed.dest, items, failed.cut, nil, skipErrors, resolver,
+			m.journal)
+		return NewRetryOperationMsg(p.State, newFailedPaste(p, failed.dest, failed.cut), reqID)
+	}
+}
+
+// getRetryItems returns the items of the failed process p to run again with mode, and
+// whether the new run should go on after a failure
+func getRetryItems(p processbar.Process, mode common.RetryMode) ([]string, bool) {
+	failedItems := make([]string, 0, len(p.Failures))
+	for _, failure := range p.Failures {
+		failedItems = append(failedItems, failure.Item)
+	}
+	switch mode {
+	case common.RetryFailed:
+		return failedItems, false
+	case common.RetryRemaining:
+		return append(failedItems, p.Remaining...), false
+	case common.RetrySkipErrors:
+		return p.Remaining, true
+	}
+	return nil, false
+}
@@ -1,14 +1,18 @@
 package internal
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
 	"github.com/yorukot/superfile/src/internal/utils"
 )
 
@@ -57,14 +61,15 @@ func TestCompressSelectedFiles(t *testing.T) {
 			expectedFilesAfterExtract: []string{"file1.txt"},
 		},
 		{
-			name:                      "Single Directory Compress",
-			startDir:                  curTestDir,
-			cursor:                    0,
-			selectMode:                false,
-			selectedElem:              nil,
-			expectedZipName:           "dir1.zip",
+			name:            "Single Directory Compress",
+			startDir:        curTestDir,
+			cursor:          0,
+			selectMode:      false,
+			selectedElem:    nil,
+			expectedZipName: "dir1.zip",
+			// The only directory of the archive is not wrapped
 			extractedDirName:          "dir1(1)",
-			expectedFilesAfterExtract: []string{filepath.Join("dir1", "file2.txt")},
+			expectedFilesAfterExtract: []string{"file2.txt"},
 		},
 		{
 			name:                      "Single File Compress with select mode without selection",
@@ -180,6 +185,28 @@ func TestCompressSelectedFiles(t *testing.T) {
 	})
 }
 
+func TestCreateArchiveWithModal(t *testing.T) {
+	curTestDir := t.TempDir()
+	srcDir := filepath.Join(curTestDir, "src")
+	utils.SetupDirectories(t, filepath.Join(srcDir, ".git"))
+	utils.SetupFiles(t, filepath.Join(srcDir, "file.txt"), filepath.Join(srcDir, ".git", "HEAD"))
+
+	m := defaultTestModel(curTestDir)
+	p := NewTestTeaProgWithEventLoop(t, m)
+	p.SendKey(common.Hotkeys.CompressFileWithFormat[0])
+	assert.Eventually(t, m.compressModal.IsOpen, DefaultTestTimeout, DefaultTestTick)
+
+	// Exclude patterns are the last field
+	p.Send(tea.KeyMsg{Type: tea.KeyShiftTab})
+	p.SendKey(".git")
+	p.Send(tea.KeyMsg{Type: tea.KeyEnter})
+	target := filepath.Join(curTestDir, "src."+common.Config.DefaultCompressFormat)
+	assert.Eventually(t, func() bool {
+		entries, err := listArchive(context.Background(), target, common.Config.DefaultCompressFormat)
+		return err == nil && len(entries) == 2
+	}, DefaultTestTimeout, DefaultTestTick, "Archive should be created without the excluded items")
+}
+
 func TestPasteItem(t *testing.T) {
 	curTestDir := t.TempDir()
 	sourceDir := filepath.Join(curTestDir, "source")
@@ -268,6 +295,11 @@ func TestPasteItem(t *testing.T) {
 				verifyPreventedPasteResults(t, m, originalPath)
 			} else {
 				verifySuccessfulPasteResults(t, tt.targetDir, tt.expectedDestFiles, originalPath, tt.shouldOriginalExist)
+				assert.Eventually(t, func() bool {
+					entries := p.getModel().history.Entries()
+					return len(entries) == 1 && entries[0].Destination == tt.targetDir &&
+						entries[0].State == processbar.Successful.String()
+				}, DefaultTestTimeout, DefaultTestTick, "Paste should be recorded in the operation history")
 			}
 			// Checking separately, as this is something independent of tt.shouldPreventPaste
 			if tt.shouldClipboardClear {
@@ -385,3 +417,149 @@ func setupModelAndPerformOperation(t *testing.T, startDir string, useSelectMode
 
 	return m
 }
+
+func TestPasteItemFailures(t *testing.T) {
+	processBar := processbar.New()
+	processBar.ListenForChannelUpdates()
+	t.Cleanup(processBar.SendStopListeningMsgBlocking)
+	sourceDir := t.TempDir()
+	missing := filepath.Join(sourceDir, "missing.txt")
+	file1 := filepath.Join(sourceDir, "file1.txt")
+	file2 := filepath.Join(sourceDir, "file2.txt")
+	utils.SetupFiles(t, file1, file2)
+
+	testdata := []struct {
+		name              string
+		skipErrors        bool
+		expectedRemaining []string
+		expectedDestFiles []string
+	}{
+		{
+			name:              "Stops on the first failure",
+			skipErrors:        false,
+			expectedRemaining: []string{file1, file2},
+			expectedDestFiles: []string{},
+		},
+		{
+			name:              "Skips errors and continues",
+			skipErrors:        true,
+			expectedRemaining: nil,
+			expectedDestFiles: []string{"file1.txt", "file2.txt"},
+		},
+	}
+
+	for _, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			destDir := t.TempDir()
+			p := executePasteOperation(&processBar, destDir, []string{missing, file1, file2}, false, nil, tt.skipErrors,
+				newPasteConflictResolver(common.PasteConflictAsk, nil), nil)
+
+			assert.Equal(t, processbar.Failed, p.State)
+			require.Len(t, p.Failures, 1)
+			assert.Equal(t, missing, p.Failures[0].Item)
+			assert.Equal(t, filepath.Join(destDir, "missing.txt"), p.Failures[0].Dst)
+			assert.Equal(t, tt.expectedRemaining, p.Remaining)
+			verifyDestinationFiles(t, destDir, tt.expectedDestFiles)
+			entries, err := os.ReadDir(destDir)
+			require.NoError(t, err)
+			assert.Len(t, entries, len(tt.expectedDestFiles))
+
+			failed := newFailedPaste(p, destDir, false)
+			require.NotNil(t, failed)
+			assert.Equal(t, failedOperation{processID: p.ID, paste: true, dest: destDir}, *failed)
+		})
+	}
+}
+
+func TestRetryFailedPaste(t *testing.T) {
+	processBar := processbar.New()
+	processBar.ListenForChannelUpdates()
+	t.Cleanup(processBar.SendStopListeningMsgBlocking)
+	curTestDir := t.TempDir()
+	srcDir := filepath.Join(curTestDir, "source", "dir")
+	destDir := filepath.Join(curTestDir, "dest")
+	// The failed run pasted the directory as dir(1), as dir already existed. It failed
+	// while copying b.txt.
+	pastedDir := filepath.Join(destDir, "dir(1)")
+	utils.SetupDirectories(t, srcDir, filepath.Join(destDir, "dir"), pastedDir)
+	utils.SetupFilesWithData(t, []byte("aaa"), filepath.Join(srcDir, "a.txt"), filepath.Join(pastedDir, "a.txt"))
+	utils.SetupFilesWithData(t, []byte("bbb"), filepath.Join(srcDir, "b.txt"))
+	utils.SetupFilesWithData(t, []byte("b"), filepath.Join(pastedDir, "b.txt"))
+	utils.SetupFilesWithData(t, []byte("ccc"), filepath.Join(srcDir, "c.txt"))
+	failures := []processbar.ItemFailure{{Item: srcDir, Error: "failed to copy file contents", Dst: pastedDir}}
+
+	resolver := newPasteConflictResolver(common.PasteConflictRename, nil)
+	resolver.resumeFailed(failures)
+	p := executePasteOperation(&processBar, destDir, []string{srcDir}, false, nil, false, resolver, nil)
+
+	assert.Equal(t, processbar.Successful, p.State)
+	verifyDestinationFiles(t, destDir, []string{"dir", "dir(1)"})
+	assert.NoDirExists(t, filepath.Join(destDir, "dir(2)"), "Failed item should not be pasted next to its copy")
+	for name, expected := range map[string]string{"a.txt": "aaa", "b.txt": "bbb", "c.txt": "ccc"} {
+		data, err := os.ReadFile(filepath.Join(pastedDir, name))
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(data), name)
+	}
+	assert.NoFileExists(t, filepath.Join(pastedDir, "a(1).txt"))
+}
+
+func TestRenameOnlyOperationsAreNotQueued(t *testing.T) {
+	limit := common.Config.OperationsPerDevice
+	common.Config.OperationsPerDevice = 1
+	t.Cleanup(func() { common.Config.OperationsPerDevice = limit })
+	processBar := processbar.New()
+	processBar.ListenForChannelUpdates()
+	t.Cleanup(processBar.SendStopListeningMsgBlocking)
+	curTestDir := t.TempDir()
+	sourceDir := filepath.Join(curTestDir, "source")
+	destDir := filepath.Join(curTestDir, "dest")
+	utils.SetupDirectories(t, sourceDir, destDir)
+	file1 := filepath.Join(sourceDir, "file1.txt")
+	utils.SetupFiles(t, file1)
+	require.True(t, isRenameOnly([]string{file1}, destDir))
+
+	// Another operation uses the device
+	busy, _, err := processBar.SendAddQueuedProcessMsg("busy", 1, deviceKey(destDir))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		busy.State = processbar.Successful
+		_ = processBar.SendUpdateProcessMsg(busy, true)
+	})
+
+	done := make(chan processbar.Process, 1)
+	go func() {
+		done <- executePasteOperation(&processBar, destDir, []string{file1}, true, nil, false,
+			newPasteConflictResolver(common.PasteConflictAsk, nil), nil)
+	}()
+	select {
+	case p := <-done:
+		assert.Equal(t, processbar.Successful, p.State)
+		verifyDestinationFiles(t, destDir, []string{"file1.txt"})
+	case <-time.After(DefaultTestTimeout):
+		t.Fatal("The move waited behind the other operation")
+	}
+}
+
+func TestGetRetryItems(t *testing.T) {
+	p := processbar.Process{
+		State:     processbar.Failed,
+		Failures:  []processbar.ItemFailure{{Item: "/a", Error: "permission denied"}},
+		Remaining: []string{"/b", "/c"},
+	}
+	testdata := []struct {
+		mode               common.RetryMode
+		expectedItems      []string
+		expectedSkipErrors bool
+	}{
+		{common.RetryFailed, []string{"/a"}, false},
+		{common.RetryRemaining, []string{"/a", "/b", "/c"}, false},
+		{common.RetrySkipErrors, []string{"/b", "/c"}, true},
+	}
+	for _, tt := range testdata {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			items, skipErrors := getRetryItems(p, tt.mode)
+			assert.Equal(t, tt.expectedItems, items)
+			assert.Equal(t, tt.expectedSkipErrors, skipErrors)
+		})
+	}
+}
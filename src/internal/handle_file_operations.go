@@ -1,17 +1,22 @@
 package internal
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 
 	variable "github.com/yorukot/superfile/src/config"
+	"github.com/yorukot/superfile/src/internal/history"
+	"github.com/yorukot/superfile/src/internal/journal"
 	"github.com/yorukot/superfile/src/internal/ui/notify"
 	"github.com/yorukot/superfile/src/internal/ui/processbar"
 	"github.com/yorukot/superfile/src/internal/utils"
@@ -113,52 +118,145 @@ func (m *model) getDeleteCmd(permDelete bool) tea.Cmd {
 		items = []string{panel.getSelectedItem().location}
 	}
 
-	useTrash := m.hasTrash && !isExternalDiskPath(panel.location) && !permDelete
+	// Items of the trash view can only be deleted permanently
+	useTrash := m.hasTrash && canTrash(panel.location) && !isTrashDirectory(panel.location) && !permDelete
 
 	reqID := m.ioReqCnt
 	m.ioReqCnt++
 	slog.Debug("Submitting delete request", "id", reqID, "items cnt", len(items))
 	return func() tea.Msg {
-		state := deleteOperation(&m.processBarModel, items, useTrash)
-		return NewDeleteOperationMsg(state, reqID)
+		p := deleteOperation(&m.processBarModel, items, useTrash, false, m.journal)
+		return NewDeleteOperationMsg(p.State, newFailedDelete(p, useTrash), reqID)
 	}
 }
 
-func deleteOperation(processBarModel *processbar.Model, items []string, useTrash bool) processbar.ProcessState {
+// deleteOperation deletes items, or moves them to the trash if useTrash is set. The deleted
+// items are recorded in opJournal, if not nil. It stops on the first item that can't be
+// deleted, unless skipErrors is set. The finished process is returned.
+func deleteOperation(processBarModel *processbar.Model, items []string, useTrash bool, skipErrors bool,
+	opJournal *journal.Journal) processbar.Process {
 	if len(items) == 0 {
-		return processbar.Cancelled
+		return processbar.Process{State: processbar.Cancelled}
+	}
+	// Items are moved to the trash of their device, so that it is only a rename
+	p, ctx, err := addOperationProcess(processBarModel, icon.Delete+icon.Space+filepath.Base(items[0]),
+		len(items), filepath.Dir(items[0]), useTrash)
+	if errors.Is(err, context.Canceled) {
+		return processbar.Process{State: processbar.Cancelled}
 	}
-	p, err := processBarModel.SendAddProcessMsg(icon.Delete+icon.Space+filepath.Base(items[0]), len(items), true)
 	if err != nil {
 		slog.Error("Cannot spawn a new process", "error", err)
-		return processbar.Failed
+		return processbar.Process{State: processbar.Failed}
 	}
+	p.Sources = items
 
-	deleteFunc := os.RemoveAll
+	entry := journal.Entry{Type: journal.Delete, Irreversible: "permanently deleted items can't be restored"}
 	if useTrash {
-		deleteFunc = moveToTrash
+		entry = journal.Entry{Type: journal.Trash}
 	}
-	for _, item := range items {
-		err = deleteFunc(item)
+	for i, item := range items {
+		// A single item can't be partially deleted, so we only stop between items
+		if ctx.Err() != nil {
+			p.State = processbar.Cancelled
+			break
+		}
+		var trashedPath string
+		if useTrash {
+			trashedPath, err = moveToTrash(item)
+		} else {
+			err = deletePermanently(item)
+		}
 		if err != nil {
-			p.State = processbar.Failed
 			slog.Error("Error in delete operation", "item", item, "useTrash", useTrash, "error", err)
+			recordItemFailure(&p, item, err)
+			if skipErrors {
+				continue
+			}
+			p.Remaining = items[i+1:]
 			break
 		}
+		if useTrash && trashedPath == "" {
+			entry.Irreversible = "the location of the items in the trash is unknown"
+		}
+		entry.Items = append(entry.Items, journal.Item{Src: item, Dst: trashedPath})
 		p.Name = icon.Delete + icon.Space + filepath.Base(item)
 		p.Done++
 		processBarModel.TrySendingUpdateProcessMsg(p)
 	}
-
-	if p.State != processbar.Failed {
-		p.State = processbar.Successful
+	if len(entry.Items) > 0 {
+		opJournal.Record(entry)
 	}
+
+	finishItemsProcess(&p)
 	p.DoneTime = time.Now()
 	err = processBarModel.SendUpdateProcessMsg(p, true)
 	if err != nil {
 		slog.Error("Failed to send final delete operation update", "error", err)
 	}
-	return p.State
+	return p
+}
+
+// addOperationProcess adds the process of an operation writing to dest, queued with the
+// other operations on its device. Operations that only rename items are quick, and hardly
+// use the device, so they start right away.
+func addOperationProcess(processBarModel *processbar.Model, name string, total int, dest string,
+	renameOnly bool) (processbar.Process, context.Context, error) {
+	if renameOnly {
+		return processBarModel.SendAddCancellableProcessMsg(name, total, true)
+	}
+	return processBarModel.SendAddQueuedProcessMsg(name, total, deviceKey(dest))
+}
+
+// isRenameOnly reports whether moving items to dest only renames them, as they are all
+// on the device of dest
+func isRenameOnly(items []string, dest string) bool {
+	for _, item := range items {
+		if sameDev, err := isSamePartition(item, dest); err != nil || !sameDev {
+			return false
+		}
+	}
+	return true
+}
+
+// newHistoryEntry returns the entry of the operation history for the finished process p
+func newHistoryEntry(p processbar.Process) history.Entry {
+	errorMsg := p.ErrorMsg
+	if len(p.Failures) > 1 {
+		errorMsg += fmt.Sprintf(" (and %d more)", len(p.Failures)-1)
+	}
+	return history.Entry{
+		Name:        p.Name,
+		Sources:     p.Sources,
+		Destination: p.Destination,
+		State:       p.State.String(),
+		Time:        p.DoneTime,
+		Duration:    p.DoneTime.Sub(p.StartTime),
+		Error:       errorMsg,
+	}
+}
+
+// Handle the hotkeys acting on the file operation under the process bar cursor
+func (m *model) processBarKey(msg string) {
+	var err error
+	switch {
+	case slices.Contains(common.Hotkeys.CancelProcess, msg):
+		err = m.processBarModel.CancelSelectedProcess()
+	case slices.Contains(common.Hotkeys.StartProcess, msg):
+		err = m.processBarModel.StartSelectedProcess()
+	case slices.Contains(common.Hotkeys.MoveProcessUp, msg):
+		err = m.processBarModel.MoveSelectedProcess(-1)
+	case slices.Contains(common.Hotkeys.MoveProcessDown, msg):
+		err = m.processBarModel.MoveSelectedProcess(1)
+	case slices.Contains(common.Hotkeys.ClearFinishedProcesses, msg):
+		m.processBarModel.ClearFinishedProcesses()
+	case slices.Contains(common.Hotkeys.Confirm, msg):
+		m.openFailuresModal()
+	default:
+		return
+	}
+	if err != nil {
+		slog.Debug("Could not apply hotkey to the selected process", "key", msg, "error", err)
+	}
 }
 
 func (m *model) getDeleteTriggerCmd(deletePermanent bool) tea.Cmd {
@@ -176,7 +274,7 @@ func (m *model) getDeleteTriggerCmd(deletePermanent bool) tea.Cmd {
 		content := common.TrashWarnContent
 		action := notify.DeleteAction
 
-		if !m.hasTrash || isExternalDiskPath(panel.location) || deletePermanent {
+		if !m.hasTrash || !canTrash(panel.location) || isTrashDirectory(panel.location) || deletePermanent {
 			title = common.PermanentDeleteWarnTitle
 			content = common.PermanentDeleteWarnContent
 			action = notify.PermanentDeleteAction
@@ -216,23 +314,176 @@ func (m *model) getPasteItemCmd() tea.Cmd {
 	if len(copyItems) == 0 {
 		return nil
 	}
+	archivePath, err := getClipboardArchive(copyItems)
+	if err != nil {
+		m.notifyModel = notify.New(true, common.PasteFailedTitle, err.Error(), notify.NoAction)
+		return nil
+	}
+	// Items can't be cut out of archives, as they are read-only
+	if archivePath != "" && !cut {
+		return m.getPasteFromArchiveCmd(archivePath)
+	}
+
+	// Only one paste is checked at a time, so that its confirmation can't be replaced
+	if m.pendingPaste != nil {
+		m.notifyModel = notify.New(true, common.PasteFailedTitle, common.PastePendingContent, notify.NoAction)
+		return nil
+	}
+	panelLocation := m.getFocusedFilePanel().location
+	if err = validatePasteOperation(panelLocation, copyItems, cut); err != nil {
+		m.notifyModel = notify.New(true, "Invalid paste location", err.Error(), notify.NoAction)
+		return nil
+	}
 
 	// TODO: Do it via m.getNewReqID()
 	// TODO: Have an IO Req Management, collecting info about pending IO Req too
 	reqID := m.ioReqCnt
 	m.ioReqCnt++
-	panelLocation := m.getFocusedFilePanel().location
+	req := pasteRequest{dest: panelLocation, items: copyItems, cut: cut}
+	m.pendingPaste = &req
 
-	slog.Debug("Submitting pasteItems request", "id", reqID, "items cnt", len(copyItems), "dest", panelLocation)
+	slog.Debug("Submitting paste preflight request", "id", reqID, "items cnt", len(copyItems), "dest", panelLocation)
 	return func() tea.Msg {
-		err := validatePasteOperation(panelLocation, copyItems, cut)
-		if err != nil {
-			return NewNotifyModalMsg(notify.New(true, "Invalid paste location", err.Error(), notify.NoAction),
-				reqID)
-		}
-		state := executePasteOperation(&m.processBarModel, panelLocation, copyItems, cut)
-		return NewPasteOperationMsg(state, reqID)
+		preflight, err := runPastePreflight(&m.processBarModel, panelLocation, copyItems, cut,
+			int64(common.Config.PasteConfirmSizeMB)*1024*1024)
+		return NewPastePreflightMsg(preflight, err, reqID)
+	}
+}
+
+// pasteRequest is a paste of items into dest, that passed validatePasteOperation
+type pasteRequest struct {
+	dest  string
+	items []string
+	cut   bool
+	// Counted by the preflight, nil to count them while pasting
+	totals *pasteTotals
+}
+
+// runPastePreflight runs the preflight of the paste of items into dest. The items are
+// only walked if the size of the paste matters, with confirmSize or the free space. It
+// is done in a process, for the user to see it and be able to cancel it. The process is
+// removed once the preflight is done, as the paste has its own.
+func runPastePreflight(processBarModel *processbar.Model, dest string, items []string,
+	cut bool, confirmSize int64) (pastePreflight, error) {
+	res := getPastePreflight(dest, items, cut)
+	if !res.needsWalk(confirmSize) {
+		return res, nil
+	}
+	p, ctx, err := processBarModel.SendAddCancellableProcessMsg(
+		icon.Search+icon.Space+"Checking "+filepath.Base(items[0]), 0, true)
+	if err != nil {
+		return pastePreflight{}, err
+	}
+	defer processBarModel.SendRemoveProcessMsg(p.ID)
+	p.TotalPending = true
+	processBarModel.TrySendingUpdateProcessMsg(p)
+
+	res.walkItems(ctx, cut, confirmSize)
+	if err = ctx.Err(); err != nil {
+		return pastePreflight{}, err
+	}
+	return res, nil
+}
+
+// getPendingPasteCmd runs the paste that was confirmed after its preflight
+func (m *model) getPendingPasteCmd() tea.Cmd {
+	req := m.pendingPaste
+	m.pendingPaste = nil
+	if req == nil {
+		slog.Error("Paste confirmed without a pending paste")
+		return nil
+	}
+	reqID := m.ioReqCnt
+	m.ioReqCnt++
+	slog.Debug("Submitting confirmed pasteItems request", "id", reqID, "items cnt", len(req.items))
+	return func() tea.Msg {
+		return m.executePasteRequest(*req, reqID)
+	}
+}
+
+func (m *model) executePasteRequest(req pasteRequest, reqID int) tea.Msg {
+	resolver := newPasteConflictResolver(common.Config.PasteConflictPolicy, m.askPasteConflict)
+	p := executePasteOperation(&m.processBarModel, req.dest, req.items, req.cut, req.totals, false, resolver,
+		m.journal)
+	return NewPasteOperationMsg(p.State, newFailedPaste(p, req.dest, req.cut), reqID)
+}
+
+// askPasteConflict is called from a running paste operation. It blocks till the user
+// answers the conflict dialog, or the operation is cancelled.
+func (m *model) askPasteConflict(ctx context.Context, src, dst string) (pasteConflictResp, error) {
+	req := pasteConflictReq{
+		src:  src,
+		dst:  dst,
+		resp: make(chan pasteConflictResp, 1),
+		done: ctx.Done(),
+	}
+	select {
+	case m.pasteConflictChan <- req:
+	case <-ctx.Done():
+		return pasteConflictResp{}, ctx.Err()
+	}
+	select {
+	case resp := <-req.resp:
+		return resp, nil
+	case <-ctx.Done():
+		return pasteConflictResp{}, ctx.Err()
+	}
+}
+
+// Waits for the next conflict that a paste operation needs the user to resolve.
+// It is re-issued only once the current conflict is answered, so that only one
+// conflict dialog is open at a time.
+func (m *model) getPasteConflictListenCmd() tea.Cmd {
+	reqID := m.ioReqCnt
+	m.ioReqCnt++
+	return func() tea.Msg {
+		return NewPasteConflictMsg(<-m.pasteConflictChan, reqID)
+	}
+}
+
+// openPasteConflictModal shows the conflict dialog for req. If another dialog is open,
+// req waits till it is closed, see showPendingPasteConflict().
+func (m *model) openPasteConflictModal(req pasteConflictReq) tea.Cmd {
+	m.pendingPasteConflict = &req
+	return m.showPendingPasteConflict()
+}
+
+// showPendingPasteConflict opens the dialog of the pending conflict, unless a dialog
+// is already open. A conflict of an operation cancelled in the meantime is dropped.
+func (m *model) showPendingPasteConflict() tea.Cmd {
+	if m.pendingPasteConflict == nil || m.notifyModel.IsOpen() {
+		return nil
+	}
+	select {
+	case <-m.pendingPasteConflict.done:
+		slog.Debug("Dropping paste conflict of a cancelled operation", "dst", m.pendingPasteConflict.dst)
+		m.pendingPasteConflict = nil
+		return m.getPasteConflictListenCmd()
+	default:
+	}
+	content := common.TruncateTextBeginning(m.pendingPasteConflict.dst, common.ModalWidth-4, "...")
+	m.notifyModel = notify.NewMultiChoice(common.PasteConflictWarnTitle, content,
+		notify.PasteConflictAction, pasteConflictChoiceLabels(), true)
+	return nil
+}
+
+// Send the user's answer to the paste operation waiting on it. If the dialog was
+// cancelled, the item is skipped.
+func (m *model) answerPasteConflict(cancelled bool) tea.Cmd {
+	if m.pendingPasteConflict == nil {
+		slog.Error("Paste conflict answered without a pending conflict")
+		return nil
+	}
+	resp := pasteConflictResp{
+		action:     conflictSkip,
+		applyToAll: m.notifyModel.IsApplyToAll(),
+	}
+	if choice := m.notifyModel.GetSelectedChoice(); !cancelled && choice != -1 {
+		resp.action = pasteConflictChoices()[choice].action
 	}
+	m.pendingPasteConflict.resp <- resp
+	m.pendingPasteConflict = nil
+	return m.getPasteConflictListenCmd()
 }
 
 func validatePasteOperation(panelLocation string, copyItems []string, cut bool) error {
@@ -260,128 +511,261 @@ func validatePasteOperation(panelLocation string, copyItems []string, cut bool)
 // new func to check and return an error that will go in m.content
 // create a new error type
 
-// Paste all clipboard items
+// Paste all clipboard items. The pasted items are recorded in opJournal, if not nil. It stops
+// on the first item that can't be pasted, unless skipErrors is set. The finished process is returned.
 func executePasteOperation(processBarModel *processbar.Model,
-	panelLocation string, copyItems []string, cut bool,
-) processbar.ProcessState {
+	panelLocation string, copyItems []string, cut bool, totals *pasteTotals, skipErrors bool,
+	resolver *pasteConflictResolver, opJournal *journal.Journal,
+) processbar.Process {
 	slog.Debug("executePasteOperation", "items", copyItems, "cut", cut, "panel location", panelLocation)
 
-	p, err := processBarModel.SendAddProcessMsg(
-		icon.GetCopyOrCutIcon(cut)+icon.Space+filepath.Base(copyItems[0]),
-		getTotalFilesCnt(copyItems), true)
+	// Total is filled in by the background count
+	p, ctx, err := addOperationProcess(processBarModel, icon.GetCopyOrCutIcon(cut)+icon.Space+
+		filepath.Base(copyItems[0]), 0, panelLocation, cut && isRenameOnly(copyItems, panelLocation))
+	if errors.Is(err, context.Canceled) {
+		return processbar.Process{State: processbar.Cancelled}
+	}
 	if err != nil {
 		slog.Error("Cannot spawn a new process", "error", err)
-		return processbar.Failed
+		return processbar.Process{State: processbar.Failed}
+	}
+	p.Sources = copyItems
+	p.Destination = panelLocation
+	var totalsChan <-chan pasteTotals
+	if totals != nil {
+		totalsChan = getKnownTotals(*totals)
+	} else {
+		totalsChan = getPasteTotalsInBackground(ctx, copyItems, getSymlinkPolicy(cut))
+	}
+	progress := newPasteProgress(&p, processBarModel, totalsChan)
+	entry := journal.Entry{Type: journal.Copy}
+	if cut {
+		entry.Type = journal.Move
 	}
 
-	for _, filePath := range copyItems {
-		errMessage := "cut item error"
-		if cut && !isExternalDiskPath(filePath) {
-			err = moveElement(filePath, filepath.Join(panelLocation, filepath.Base(filePath)))
-		} else {
-			// TODO : These error cases are hard to test. We have to somehow make the paste operations fail,
-			// which is time consuming and manual. We should test these with automated testcases
-			err = pasteDir(filePath, filepath.Join(panelLocation, filepath.Base(filePath)), &p, cut, processBarModel)
-			if err != nil {
-				errMessage = "paste item error"
-			} else if cut {
-				// TODO: Fix unhandled error
-				os.RemoveAll(filePath)
-			}
+	for i, filePath := range copyItems {
+		if ctx.Err() != nil {
+			p.State = processbar.Cancelled
+			break
 		}
+		dst, skip, errMessage, err := pasteItem(ctx, filePath, filepath.Join(panelLocation, filepath.Base(filePath)),
+			cut, progress, resolver)
 
 		p.Name = icon.GetCopyOrCutIcon(cut) + icon.Space + filepath.Base(filePath)
+		if errors.Is(err, context.Canceled) {
+			slog.Debug("Paste operation cancelled", "current item", filePath, "done", p.Done)
+			p.State = processbar.Cancelled
+			break
+		}
 		if err != nil {
 			slog.Debug("model.pasteItem - paste failure", "error", err,
 				"current item", filePath, "errMessage", errMessage)
-			p.State = processbar.Failed
 			slog.Error(errMessage, "error", err)
+			recordPasteFailure(&p, filePath, dst, fmt.Errorf("%s: %w", errMessage, err))
+			if skipErrors {
+				continue
+			}
+			p.Remaining = copyItems[i+1:]
 			break
 		}
-		processBarModel.TrySendingUpdateProcessMsg(p)
+		if !skip {
+			entry.Items = append(entry.Items, stampCopiedItem(journal.Item{Src: filePath, Dst: dst}))
+		}
+		progress.update()
 	}
-
-	if p.State != processbar.Failed {
-		p.State = processbar.Successful
-		p.Done = p.Total
+	if len(entry.Items) > 0 {
+		if resolver.overwrote {
+			entry.Irreversible = "the paste overwrote or merged into existing items"
+		}
+		opJournal.Record(entry)
 	}
+
+	finishItemsProcess(&p)
+	progress.finish()
 	p.DoneTime = time.Now()
 	err = processBarModel.SendUpdateProcessMsg(p, true)
 	if err != nil {
 		slog.Error("Could not send final update for process Bar", "error", err)
 	}
 
-	return p.State
+	return p
+}
+
+// pasteItem pastes filePath to dst, after resolving a conflict with an existing item.
+// It returns where the item was pasted, and whether it was skipped. If it fails,
+// errMessage describes the step that failed.
+func pasteItem(ctx context.Context, filePath, dst string, cut bool, progress *pasteProgress,
+	resolver *pasteConflictResolver) (string, bool, string, error) {
+	errMessage := "paste item error"
+	dst, skip, err := resolver.resolve(ctx, filePath, dst)
+	switch {
+	case err != nil:
+		errMessage = "paste conflict error"
+	case skip:
+		slog.Debug("Skipping paste of conflicting item", "item", filePath)
+	default:
+		// Moves are renamed when src and dst are on the same device, and copied with
+		// progress otherwise.
+		// TODO : These error cases are hard to test. We have to somehow make the paste operations fail,
+		// which is time consuming and manual. We should test these with automated testcases
+		err = pasteDir(ctx, filePath, dst, cut, progress, resolver)
+	}
+	return dst, skip, errMessage, err
 }
 
-func getTotalFilesCnt(copyItems []string) int {
-	totalFiles := 0
+// getTotalFilesCntAndSize counts the files in copyItems, and their total size in bytes
+func getTotalFilesCntAndSize(ctx context.Context, copyItems []string, symlinkPolicy string) pasteTotals {
+	var res pasteTotals
 	for _, folderPath := range copyItems {
-		// TODO : Fix this. This is inefficient
-		// In case of a cut operations for a directory with a lot of files
-		// we are unnecessarily walking the whole directory recursively
-		// while os will just perform a rename
-		// So instead of few operations this will cause the cut paste
-		// to read the whole directory recursively
-		// we should avoid doing this.
-		// Although this allows us a more detailed progress tracking
-		// this make the copy/cut more inefficient
-		// instead, we could just track progress based on total items in
-		// copyItems
-		// efficiency should be prioritized over more detailed feedback.
-		count, err := countFiles(folderPath)
+		// TODO : This is inefficient in case of a cut operation for a directory with
+		// a lot of files. We walk the whole directory recursively, while the os will
+		// just perform a rename. It runs in the background, so at least it doesn't
+		// delay the operation itself.
+		count, size, err := countFilesAndSize(ctx, folderPath, symlinkPolicy)
+		// The operation has finished or was cancelled, the count isn't needed anymore
+		if errors.Is(err, context.Canceled) {
+			return res
+		}
 		if err != nil {
-			slog.Error("Error in countFiles", "error", err)
+			slog.Error("Error in countFilesAndSize", "error", err)
 			continue
 		}
-		totalFiles += count
+		res.files += count
+		res.bytes += size
 	}
-	return totalFiles
+	return res
 }
 
-// Extract compressed file
-// TODO : err should be returned and properly handled by the caller
+// Extract the selected archives, or the one under the cursor, next to them
 func (m *model) getExtractFileCmd() tea.Cmd {
+	return m.getExtractSelectedCmd(m.getFocusedFilePanel().location, extractSmart)
+}
+
+// Extract the selected archives, or the one under the cursor, directly into the
+// current directory
+func (m *model) getExtractFileHereCmd() tea.Cmd {
+	return m.getExtractSelectedCmd(m.getFocusedFilePanel().location, extractHere)
+}
+
+// Extract the selected archives, or the one under the cursor, into the directory of
+// the next file panel
+func (m *model) getExtractFileToOtherPanelCmd() tea.Cmd {
+	if len(m.fileModel.filePanels) < 2 {
+		m.notifyModel = notify.New(true, common.ExtractFailedTitle, common.ExtractNoOtherPanelContent,
+			notify.NoAction)
+		return nil
+	}
+	otherPanel := m.fileModel.filePanels[(m.filePanelFocusIndex+1)%len(m.fileModel.filePanels)]
+	if isInArchive(otherPanel.location) {
+		m.openArchiveReadOnlyModal()
+		return nil
+	}
+	return m.getExtractSelectedCmd(otherPanel.location, extractSmart)
+}
+
+// getExtractSelectedCmd extracts the selected archives into destDir. When no archive is
+// selected, the one under the cursor is extracted.
+func (m *model) getExtractSelectedCmd(destDir string, mode extractMode) tea.Cmd {
 	panel := m.getFocusedFilePanel()
 	if len(panel.element) == 0 {
 		return nil
 	}
 
-	item := panel.getSelectedItem().location
-
-	ext := strings.ToLower(filepath.Ext(item))
-	if !common.IsExtensionExtractable(ext) {
-		slog.Error("Error unexpected file", "extension type", ext, "item", item, "error", errors.ErrUnsupported)
-		return nil
+	archives := slices.DeleteFunc(slices.Clone(panel.selected), func(item string) bool {
+		return !isExtractable(item)
+	})
+	if len(archives) == 0 {
+		item := panel.getSelectedItem().location
+		if !isExtractable(item) {
+			slog.Error("Error unexpected file", "extension type", filepath.Ext(item), "item", item,
+				"error", errors.ErrUnsupported)
+			return nil
+		}
+		archives = []string{item}
 	}
+	return m.getExtractArchivesCmd(extractRequest{archives: archives, destDir: destDir, mode: mode})
+}
+
+func isExtractable(item string) bool {
+	return archiveFormatOf(item) != "" || common.IsExtensionExtractable(strings.ToLower(filepath.Ext(item)))
+}
+
+// getExtractArchivesCmd runs the extraction req, with the passwords of the session for
+// encrypted archives. The password is asked if it is not known yet, or wrong.
+func (m *model) getExtractArchivesCmd(req extractRequest) tea.Cmd {
 	reqID := m.ioReqCnt
 	m.ioReqCnt++
+	// The command runs in another goroutine, while the passwords can be changed
+	passwords := maps.Clone(m.archivePasswords)
 
-	slog.Debug("Submitting Extract file request", "reqID", reqID, "item", item)
+	slog.Debug("Submitting Extract file request", "reqID", reqID, "archives cnt", len(req.archives),
+		"dest", req.destDir, "mode", req.mode)
 
 	return func() tea.Msg {
-		outputDir := common.FileNameWithoutExtension(item)
-		outputDir, err := renameIfDuplicate(outputDir)
-		if err != nil {
-			slog.Error("Error while renaming for duplicates", "error", err)
-			return NewCompressOperationMsg(processbar.Failed, reqID)
+		resolver := newPasteConflictResolver(common.Config.PasteConflictPolicy, m.askPasteConflict)
+		remaining, err := extractArchives(&m.processBarModel, req, passwords, resolver)
+		if errors.Is(err, context.Canceled) {
+			return NewExtractOperationMsg(processbar.Cancelled, reqID)
 		}
-
-		err = os.MkdirAll(outputDir, 0o755)
-		if err != nil {
-			slog.Error("Error while making directory for extracting files", "error", err)
-			return NewCompressOperationMsg(processbar.Failed, reqID)
+		if isArchivePasswordError(err) {
+			req.archives = remaining
+			return NewArchivePasswordMsg(req, errors.Is(err, errArchiveWrongPassword), reqID)
 		}
-		err = extractCompressFile(item, outputDir, &m.processBarModel)
 		if err != nil {
 			slog.Error("Error extract file", "error", err)
-			return NewCompressOperationMsg(processbar.Failed, reqID)
+			return NewNotifyModalMsg(notify.New(true, common.ExtractFailedTitle, err.Error(), notify.NoAction),
+				reqID)
+		}
+		return NewExtractOperationMsg(processbar.Successful, reqID)
+	}
+}
+
+// Open the archive creation modal for the selected items, or the item under the cursor
+func (m *model) openCompressModal() tea.Cmd {
+	panel := m.getFocusedFilePanel()
+	if len(panel.element) == 0 {
+		return nil
+	}
+	items := panel.selected
+	if len(items) == 0 {
+		items = []string{panel.getSelectedItem().location}
+	}
+	cmd, err := m.compressModal.Open(items, panel.location, common.Config.DefaultCompressFormat)
+	if err != nil {
+		slog.Error("Error while opening compress modal", "error", err)
+	}
+	return cmd
+}
+
+// Apply the Action of compress modal. The archive is created as a process
+func (m *model) applyCompressModalAction(action common.ModelAction) tea.Cmd {
+	createAction, ok := action.(common.CreateArchiveAction)
+	if !ok {
+		_, _ = m.logAndExecuteAction(action)
+		return nil
+	}
+	slog.Debug("Applying model action", "action", createAction)
+
+	reqID := m.ioReqCnt
+	m.ioReqCnt++
+	opts := compressOptions{
+		format:    createAction.Format,
+		level:     createAction.Level,
+		storeOnly: createAction.StoreOnly,
+		excludes:  createAction.Excludes,
+	}
+	return func() tea.Msg {
+		err := compressSourcesWithOptions(createAction.Items, createAction.Target, opts, &m.processBarModel)
+		if err != nil {
+			slog.Error("Error in compressing files", "format", opts.format, "error", err)
+			return NewNotifyModalMsg(notify.New(true, common.CompressFailedTitle, err.Error(), notify.NoAction),
+				reqID)
 		}
 		return NewCompressOperationMsg(processbar.Successful, reqID)
 	}
 }
 
-func (m *model) getCompressSelectedFilesCmd() tea.Cmd {
+func (m *model) getCompressSelectedFilesCmd(format string) tea.Cmd {
 	panel := m.getFocusedFilePanel()
 
 	if len(panel.element) == 0 {
@@ -402,15 +786,15 @@ func (m *model) getCompressSelectedFilesCmd() tea.Cmd {
 	m.ioReqCnt++
 
 	return func() tea.Msg {
-		zipName, err := getZipArchiveName(filepath.Base(firstFile))
+		archivePath, err := getArchivePath(panel.location, filepath.Base(firstFile), format)
 		if err != nil {
-			slog.Error("Error in getZipArchiveName", "error", err)
+			slog.Error("Error in getArchivePath", "error", err)
 			return NewCompressOperationMsg(processbar.Failed, reqID)
 		}
-		zipPath := filepath.Join(panel.location, zipName)
-		if err := zipSources(filesToCompress, zipPath, &m.processBarModel); err != nil {
-			slog.Error("Error in zipping files", "error", err)
-			return NewCompressOperationMsg(processbar.Failed, reqID)
+		if err := compressSources(filesToCompress, archivePath, format, &m.processBarModel); err != nil {
+			slog.Error("Error in compressing files", "format", format, "error", err)
+			return NewNotifyModalMsg(notify.New(true, common.CompressFailedTitle, err.Error(), notify.NoAction),
+				reqID)
 		}
 		return NewCompressOperationMsg(processbar.Successful, reqID)
 	}
@@ -444,6 +828,15 @@ func (m *model) openFileWithEditor() tea.Cmd {
 		slog.Error("Error while writing to chooser file, continuing with open via file editor", "error", err)
 	}
 
+	c := getEditorCmd(panel.element[panel.cursor].location)
+
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		return editorFinishedMsg{err}
+	})
+}
+
+// getEditorCmd returns the command that opens path with the editor for files
+func getEditorCmd(path string) *exec.Cmd {
 	editor := common.Config.Editor
 	if editor == "" {
 		editor = os.Getenv("EDITOR")
@@ -463,13 +856,9 @@ func (m *model) openFileWithEditor() tea.Cmd {
 	cmd := parts[0]
 
 	//nolint:gocritic // appendAssign: intentionally creating a new slice
-	args := append(parts[1:], panel.element[panel.cursor].location)
+	args := append(parts[1:], path)
 
-	c := exec.Command(cmd, args...)
-
-	return tea.ExecProcess(c, func(err error) tea.Msg {
-		return editorFinishedMsg{err}
-	})
+	return exec.Command(cmd, args...)
 }
 
 // Open directory with default editor
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/yorukot/superfile/src/config/icon"
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/journal"
+	"github.com/yorukot/superfile/src/internal/ui/notify"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+)
+
+// Paste the clipboard items as links of type l. The clipboard is kept, even for a cut.
+func (m *model) getPasteAsLinkCmd(l linkType) tea.Cmd {
+	copyItems := m.copyItems.items
+	if len(copyItems) == 0 {
+		return nil
+	}
+
+	reqID := m.ioReqCnt
+	m.ioReqCnt++
+	panelLocation := m.getFocusedFilePanel().location
+
+	slog.Debug("Submitting paste as link request", "id", reqID, "items cnt", len(copyItems),
+		"dest", panelLocation, "link type", l)
+	return func() tea.Msg {
+		err := validatePasteOperation(panelLocation, copyItems, false)
+		if err != nil {
+			return NewNotifyModalMsg(notify.New(true, "Invalid paste location", err.Error(), notify.NoAction),
+				reqID)
+		}
+		resolver := newPasteConflictResolver(common.Config.PasteConflictPolicy, m.askPasteConflict)
+		state, err := linkOperation(&m.processBarModel, panelLocation, copyItems, l, resolver, m.journal)
+		if err != nil {
+			return NewNotifyModalMsg(notify.New(true, common.PasteAsLinkFailedTitle, err.Error(), notify.NoAction),
+				reqID)
+		}
+		return NewLinkOperationMsg(state, reqID)
+	}
+}
+
+// linkOperation creates links of type l in panelLocation to items. Conflicts are resolved
+// like for a paste. It returns the error that stopped the operation, if any.
+func linkOperation(processBarModel *processbar.Model, panelLocation string, items []string, l linkType,
+	resolver *pasteConflictResolver, opJournal *journal.Journal) (processbar.ProcessState, error) {
+	p, ctx, err := processBarModel.SendAddCancellableProcessMsg(
+		icon.Link+icon.Space+filepath.Base(items[0]), len(items), true)
+	if err != nil {
+		slog.Error("Cannot spawn a new process", "error", err)
+		return processbar.Failed, err
+	}
+	p.Sources = items
+	p.Destination = panelLocation
+
+	entry := journal.Entry{Type: l.journalOpType()}
+	var opErr error
+	for _, item := range items {
+		if ctx.Err() != nil {
+			p.State = processbar.Cancelled
+			break
+		}
+		p.Name = icon.Link + icon.Space + filepath.Base(item)
+		target, dst, skip, err := pasteLink(ctx, item, filepath.Join(panelLocation, filepath.Base(item)), l, resolver)
+		if errors.Is(err, context.Canceled) {
+			p.State = processbar.Cancelled
+			break
+		}
+		if err != nil {
+			slog.Error("Error in paste as link operation", "item", item, "link type", l, "error", err)
+			p.State = processbar.Failed
+			p.ErrorMsg = fmt.Sprintf("%s: %v", item, err)
+			opErr = err
+			break
+		}
+		if !skip {
+			entry.Items = append(entry.Items, journal.Item{Src: target, Dst: dst})
+		}
+		p.Done++
+		processBarModel.TrySendingUpdateProcessMsg(p)
+	}
+	if len(entry.Items) > 0 {
+		if resolver.overwrote {
+			entry.Irreversible = "the links replaced existing items"
+		}
+		opJournal.Record(entry)
+	}
+
+	if p.State == processbar.InOperation {
+		p.State = processbar.Successful
+	}
+	p.DoneTime = time.Now()
+	if err = processBarModel.SendUpdateProcessMsg(p, true); err != nil {
+		slog.Error("Could not send final update for process Bar", "error", err)
+	}
+	return p.State, opErr
+}
+
+// pasteLink creates a link at dst to src, after resolving a conflict with an existing
+// item. It returns the target written in the link, where it was created, and whether
+// it was skipped.
+func pasteLink(ctx context.Context, src string, dst string, l linkType,
+	resolver *pasteConflictResolver) (string, string, bool, error) {
+	dst, skip, err := resolver.resolve(ctx, src, dst)
+	if err != nil || skip {
+		return "", "", skip, err
+	}
+	if err = removeLinkConflict(dst); err != nil {
+		return "", "", false, err
+	}
+	target, err := createLink(src, dst, l)
+	return target, dst, false, err
+}
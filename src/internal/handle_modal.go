@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/yorukot/superfile/src/internal/journal"
 	"github.com/yorukot/superfile/src/internal/utils"
 )
 
@@ -33,6 +34,7 @@ func (m *model) createItem() {
 	path := filepath.Join(m.typingModal.location, m.typingModal.textInput.Value())
 	if !strings.HasSuffix(m.typingModal.textInput.Value(), string(filepath.Separator)) {
 		path, _ = renameIfDuplicate(path)
+		createdDirs := missingDirs(filepath.Dir(path))
 		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
 			slog.Error("Error while createItem during directory creation", "error", err)
 			return
@@ -43,13 +45,33 @@ func (m *model) createItem() {
 			return
 		}
 		defer f.Close()
+		m.journal.Record(journal.Entry{
+			Type:  journal.Create,
+			Items: append(createdDirs, journal.Item{Dst: path}),
+		})
 	} else {
+		createdDirs := missingDirs(path)
 		err := os.MkdirAll(path, 0755)
 		if err != nil {
 			slog.Error("Error while createItem during directory creation", "error", err)
 			return
 		}
+		if len(createdDirs) > 0 {
+			m.journal.Record(journal.Entry{Type: journal.Create, Items: createdDirs})
+		}
+	}
+}
+
+// missingDirs returns the directories that os.MkdirAll(path) would create, parents first
+func missingDirs(path string) []journal.Item {
+	var res []journal.Item
+	for ; ; path = filepath.Dir(path) {
+		if _, err := os.Lstat(path); err == nil || filepath.Dir(path) == path {
+			break
+		}
+		res = append([]journal.Item{{Dst: path, IsDir: true}}, res...)
 	}
+	return res
 }
 
 // Cancel rename file or directory
@@ -74,11 +96,24 @@ func (m *model) confirmRename() {
 	oldPath := panel.element[panel.cursor].location
 	newPath := filepath.Join(panel.location, panel.rename.Value())
 
+	entry := journal.Entry{
+		Type:  journal.Rename,
+		Items: []journal.Item{{Src: oldPath, Dst: newPath}},
+	}
+	if newInfo, err := os.Lstat(newPath); err == nil {
+		// Renaming to a different case on a case insensitive filesystem gives the same file
+		if oldInfo, err := os.Lstat(oldPath); err == nil && !os.SameFile(oldInfo, newInfo) {
+			entry.Irreversible = "the rename overwrote an existing item"
+		}
+	}
+
 	// Rename the file
 	err := os.Rename(oldPath, newPath)
 	if err != nil {
 		slog.Error("Error while confirmRename during rename", "error", err)
 		// Dont return. We have to also reset the panel and model information
+	} else if oldPath != newPath {
+		m.journal.Record(entry)
 	}
 	m.fileModel.renaming = false
 	panel.rename.Blur()
@@ -39,6 +39,13 @@ func (m *model) enterPanel() {
 		}
 		return
 	}
+	if variable.ChooserFile == "" && archiveFormatOf(selectedItem.location) != "" && isArchiveDir(selectedItem.location) {
+		err := m.updateCurrentFilePanelDir(selectedItem.location)
+		if err != nil {
+			slog.Error("Error while entering archive", "error", err, "target", selectedItem.location)
+		}
+		return
+	}
 	fileInfo, err := os.Lstat(selectedItem.location)
 	if err != nil {
 		slog.Error("Error while getting file info", "error", err)
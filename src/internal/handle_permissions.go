@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/yorukot/superfile/src/config/icon"
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/notify"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+)
+
+// Open the permissions modal for the selected items, or the item under the cursor
+func (m *model) openPermissionsModal() tea.Cmd {
+	panel := m.getFocusedFilePanel()
+	if len(panel.element) == 0 {
+		return nil
+	}
+	items := panel.selected
+	if len(items) == 0 {
+		items = []string{panel.getSelectedItem().location}
+	}
+	if err := m.permissionsModal.Open(items); err != nil {
+		slog.Error("Error while opening permissions modal", "error", err)
+		reqID := m.ioReqCnt
+		m.ioReqCnt++
+		return func() tea.Msg {
+			return NewNotifyModalMsg(notify.New(true, common.PermissionsFailedTitle, err.Error(), notify.NoAction),
+				reqID)
+		}
+	}
+	return nil
+}
+
+// Apply the Action of permissions modal. Changes are run as a process
+func (m *model) applyPermissionsModalAction(action common.ModelAction) tea.Cmd {
+	permAction, ok := action.(common.ChangePermissionsAction)
+	if !ok {
+		_, _ = m.logAndExecuteAction(action)
+		return nil
+	}
+	slog.Debug("Applying model action", "action", permAction)
+
+	reqID := m.ioReqCnt
+	m.ioReqCnt++
+	return func() tea.Msg {
+		p := changePermissionsOperation(&m.processBarModel, permAction)
+		return NewPermissionsOperationMsg(p.State, reqID)
+	}
+}
+
+// changePermissionsOperation applies action to its items as one process. Items that fail
+// don't stop the others, and are kept in the failures of the process, that is returned.
+func changePermissionsOperation(processBarModel *processbar.Model,
+	action common.ChangePermissionsAction) processbar.Process {
+	p, ctx, err := processBarModel.SendAddCancellableProcessMsg(
+		icon.Lock+icon.Space+filepath.Base(action.Items[0]), 0, true)
+	if err != nil {
+		slog.Error("Cannot spawn a new process", "error", err)
+		return processbar.Process{State: processbar.Failed}
+	}
+	p.Sources = action.Items
+	paths := getPermissionsTargets(action, &p)
+	p.Total = len(paths)
+
+	// Contents first, so that removing the access to a directory does not prevent
+	// changing its contents
+	for _, path := range slices.Backward(paths) {
+		if ctx.Err() != nil {
+			p.State = processbar.Cancelled
+			break
+		}
+		p.Name = icon.Lock + icon.Space + filepath.Base(path)
+		if err := applyPermissions(path, action); err != nil {
+			slog.Error("Error while changing permissions", "path", path, "error", err)
+			recordItemFailure(&p, path, err)
+		}
+		p.Done++
+		processBarModel.TrySendingUpdateProcessMsg(p)
+	}
+
+	finishItemsProcess(&p)
+	p.DoneTime = time.Now()
+	if err = processBarModel.SendUpdateProcessMsg(p, true); err != nil {
+		slog.Error("Could not send final update for process Bar", "error", err)
+	}
+	return p
+}
+
+// getPermissionsTargets returns the paths that action changes, parents first. Directories
+// are walked if the action is recursive, without following symlinks. Items that can't be
+// read are recorded as failures of p.
+func getPermissionsTargets(action common.ChangePermissionsAction, p *processbar.Process) []string {
+	var paths []string
+	add := func(path string, isDir bool) {
+		if (action.DirsOnly && !isDir) || (action.FilesOnly && isDir) {
+			return
+		}
+		paths = append(paths, path)
+	}
+	for _, item := range action.Items {
+		info, err := os.Lstat(item)
+		if err != nil {
+			recordItemFailure(p, item, err)
+			continue
+		}
+		if !action.Recursive || !info.IsDir() {
+			add(item, info.IsDir())
+			continue
+		}
+		err = filepath.WalkDir(item, func(path string, d fs.DirEntry, err error) error {
+			// A directory whose contents can't be read was already added, before
+			// reading them
+			if err != nil {
+				recordItemFailure(p, path, err)
+				return nil
+			}
+			add(path, d.IsDir())
+			return nil
+		})
+		if err != nil {
+			recordItemFailure(p, item, err)
+		}
+	}
+	return paths
+}
+
+func applyPermissions(path string, action common.ChangePermissionsAction) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	// The mode of a symlink is the one of its target, which is not changed
+	if action.SetMode && info.Mode()&os.ModeSymlink == 0 {
+		if err = os.Chmod(path, action.Mode); err != nil {
+			return err
+		}
+	}
+	if action.UID != -1 || action.GID != -1 {
+		return os.Lchown(path, action.UID, action.GID)
+	}
+	return nil
+}
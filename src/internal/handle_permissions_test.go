@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+	"github.com/yorukot/superfile/src/internal/utils"
+)
+
+func TestChangePermissionsOperation(t *testing.T) {
+	if runtime.GOOS == utils.OsWindows {
+		t.Skip("Unix permissions are not available on Windows")
+	}
+	curTestDir := t.TempDir()
+	dir1 := filepath.Join(curTestDir, "dir1")
+	subDir := filepath.Join(dir1, "sub")
+	file1 := filepath.Join(dir1, "file1.txt")
+	file2 := filepath.Join(subDir, "file2.txt")
+
+	testdata := []struct {
+		name           string
+		action         common.ChangePermissionsAction
+		expectedModes  map[string]os.FileMode
+		expectedState  processbar.ProcessState
+		expectedFailed []string
+	}{
+		{
+			name:          "Not recursive",
+			action:        common.ChangePermissionsAction{Items: []string{dir1}, SetMode: true, Mode: 0o700},
+			expectedModes: map[string]os.FileMode{dir1: 0o700, subDir: 0o755, file1: 0o644},
+			expectedState: processbar.Successful,
+		},
+		{
+			name: "Recursive files only",
+			action: common.ChangePermissionsAction{Items: []string{dir1}, SetMode: true, Mode: 0o600,
+				Recursive: true, FilesOnly: true},
+			expectedModes: map[string]os.FileMode{dir1: 0o755, subDir: 0o755, file1: 0o600, file2: 0o600},
+			expectedState: processbar.Successful,
+		},
+		{
+			name: "Recursive directories only",
+			action: common.ChangePermissionsAction{Items: []string{dir1}, SetMode: true, Mode: 0o700,
+				Recursive: true, DirsOnly: true},
+			expectedModes: map[string]os.FileMode{dir1: 0o700, subDir: 0o700, file1: 0o644},
+			expectedState: processbar.Successful,
+		},
+		{
+			name: "Missing item does not stop the others",
+			action: common.ChangePermissionsAction{Items: []string{filepath.Join(curTestDir, "missing"), file1},
+				SetMode: true, Mode: 0o640},
+			expectedModes:  map[string]os.FileMode{file1: 0o640},
+			expectedState:  processbar.Failed,
+			expectedFailed: []string{filepath.Join(curTestDir, "missing")},
+		},
+	}
+
+	for _, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			utils.SetupDirectories(t, dir1, subDir)
+			utils.SetupFiles(t, file1, file2)
+			t.Cleanup(func() {
+				require.NoError(t, os.RemoveAll(dir1))
+			})
+			for _, path := range []string{dir1, subDir} {
+				require.NoError(t, os.Chmod(path, 0o755))
+			}
+			for _, path := range []string{file1, file2} {
+				require.NoError(t, os.Chmod(path, 0o644))
+			}
+			tt.action.UID, tt.action.GID = -1, -1
+
+			processBarModel := processbar.New()
+			p := changePermissionsOperation(&processBarModel, tt.action)
+			assert.Equal(t, tt.expectedState, p.State)
+			var failed []string
+			for _, failure := range p.Failures {
+				failed = append(failed, failure.Item)
+			}
+			assert.Equal(t, tt.expectedFailed, failed)
+			for path, mode := range tt.expectedModes {
+				info, err := os.Lstat(path)
+				require.NoError(t, err)
+				assert.Equal(t, mode, info.Mode().Perm(), "mode of %s", path)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	variable "github.com/yorukot/superfile/src/config"
+	"github.com/yorukot/superfile/src/config/icon"
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/notify"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+)
+
+// Restore the selected items of the trash view to where they were deleted from
+func (m *model) getRestoreFromTrashCmd() tea.Cmd {
+	panel := m.getFocusedFilePanel()
+	if !isTrashDirectory(panel.location) || len(panel.element) == 0 {
+		return nil
+	}
+
+	var items []string
+	if panel.panelMode == selectMode {
+		items = panel.selected
+	} else {
+		items = []string{panel.getSelectedItem().location}
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	reqID := m.ioReqCnt
+	m.ioReqCnt++
+	slog.Debug("Submitting restore from trash request", "id", reqID, "items cnt", len(items))
+	return func() tea.Msg {
+		resolver := newPasteConflictResolver(common.Config.PasteConflictPolicy, m.askPasteConflict)
+		state := restoreOperation(&m.processBarModel, items, resolver)
+		return NewRestoreOperationMsg(state, reqID)
+	}
+}
+
+// restoreOperation moves items of the trash back to their original location. Conflicts
+// with items that exist there now are resolved like for a paste.
+func restoreOperation(processBarModel *processbar.Model, items []string,
+	resolver *pasteConflictResolver) processbar.ProcessState {
+	p, ctx, err := processBarModel.SendAddCancellableProcessMsg(
+		icon.GetCopyOrCutIcon(true)+icon.Space+filepath.Base(items[0]), 0, true)
+	if err != nil {
+		slog.Error("Cannot spawn a new process", "error", err)
+		return processbar.Failed
+	}
+	p.Sources = items
+	progress := newPasteProgress(&p, processBarModel,
+		getPasteTotalsInBackground(ctx, items, getSymlinkPolicy(true)))
+
+	for _, item := range items {
+		if ctx.Err() != nil {
+			p.State = processbar.Cancelled
+			break
+		}
+		p.Name = icon.GetCopyOrCutIcon(true) + icon.Space + filepath.Base(item)
+		err = restoreItem(ctx, item, progress, resolver)
+		if errors.Is(err, context.Canceled) {
+			slog.Debug("Restore operation cancelled", "current item", item, "done", p.Done)
+			p.State = processbar.Cancelled
+			break
+		}
+		if err != nil {
+			p.State = processbar.Failed
+			p.ErrorMsg = fmt.Sprintf("%s: %v", item, err)
+			slog.Error("Error in restore operation", "item", item, "error", err)
+			break
+		}
+		progress.update()
+	}
+
+	if p.State == processbar.InOperation {
+		p.State = processbar.Successful
+	}
+	progress.finish()
+	p.DoneTime = time.Now()
+	err = processBarModel.SendUpdateProcessMsg(p, true)
+	if err != nil {
+		slog.Error("Could not send final update for process Bar", "error", err)
+	}
+	return p.State
+}
+
+func restoreItem(ctx context.Context, item string, progress *pasteProgress,
+	resolver *pasteConflictResolver) error {
+	info, err := readTrashInfo(trashInfoPath(item))
+	if err != nil {
+		return fmt.Errorf("cannot read trash info: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(info.originalPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create parent directory: %w", err)
+	}
+	_, skip, errMessage, err := pasteItem(ctx, item, info.originalPath, true, progress, resolver)
+	if err != nil {
+		return fmt.Errorf("%s: %w", errMessage, err)
+	}
+	if skip {
+		return nil
+	}
+	if err = removeTrashInfo(item); err != nil {
+		return fmt.Errorf("item restored, but failed to remove its trash info: %w", err)
+	}
+	return nil
+}
+
+func (m *model) getEmptyTrashTriggerCmd() tea.Cmd {
+	if !m.hasTrash || !isTrashDirectory(m.getFocusedFilePanel().location) {
+		return nil
+	}
+	reqID := m.ioReqCnt
+	m.ioReqCnt++
+	return func() tea.Msg {
+		return NewNotifyModalMsg(notify.New(true, common.EmptyTrashWarnTitle,
+			common.PermanentDeleteWarnContent, notify.EmptyTrashAction), reqID)
+	}
+}
+
+// Permanently delete all the items of the trash
+func (m *model) getEmptyTrashCmd() tea.Cmd {
+	reqID := m.ioReqCnt
+	m.ioReqCnt++
+	slog.Debug("Submitting empty trash request", "id", reqID)
+	return func() tea.Msg {
+		entries, err := os.ReadDir(variable.LinuxTrashDirectoryFiles)
+		if err != nil {
+			slog.Error("Error while reading trash directory", "error", err)
+			return NewNotifyModalMsg(notify.New(true, "Cannot empty trash", err.Error(), notify.NoAction), reqID)
+		}
+		items := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			items = append(items, filepath.Join(variable.LinuxTrashDirectoryFiles, entry.Name()))
+		}
+		p := deleteOperation(&m.processBarModel, items, false, false, m.journal)
+		return NewDeleteOperationMsg(p.State, newFailedDelete(p, false), reqID)
+	}
+}
@@ -0,0 +1,341 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/journal"
+	"github.com/yorukot/superfile/src/internal/ui/notify"
+)
+
+func (m *model) getUndoCmd() tea.Cmd {
+	reqID := m.ioReqCnt
+	m.ioReqCnt++
+	slog.Debug("Submitting undo request", "id", reqID)
+	return func() tea.Msg {
+		e, ok := m.journal.PopUndo()
+		if !ok {
+			slog.Debug("Nothing to undo")
+			return nil
+		}
+		done, remaining, err := undoEntry(e)
+		if len(done.Items) > 0 {
+			m.journal.PushRedo(done)
+		}
+		if err != nil {
+			if len(remaining.Items) > 0 {
+				m.journal.PushUndo(remaining)
+			}
+			slog.Error("Error while undoing operation", "type", e.Type, "error", err)
+			return NewNotifyModalMsg(notify.New(true, common.UndoFailedTitle, err.Error(), notify.NoAction), reqID)
+		}
+		return nil
+	}
+}
+
+func (m *model) getRedoCmd() tea.Cmd {
+	reqID := m.ioReqCnt
+	m.ioReqCnt++
+	slog.Debug("Submitting redo request", "id", reqID)
+	return func() tea.Msg {
+		e, ok := m.journal.PopRedo()
+		if !ok {
+			slog.Debug("Nothing to redo")
+			return nil
+		}
+		done, remaining, err := redoEntry(e)
+		if len(done.Items) > 0 {
+			m.journal.PushUndo(done)
+		}
+		if err != nil {
+			if len(remaining.Items) > 0 {
+				m.journal.PushRedo(remaining)
+			}
+			slog.Error("Error while redoing operation", "type", e.Type, "error", err)
+			return NewNotifyModalMsg(notify.New(true, common.RedoFailedTitle, err.Error(), notify.NoAction), reqID)
+		}
+		return nil
+	}
+}
+
+// undoEntry reverts the items of e, last item first. done holds the reverted items,
+// which can be redone, and remaining the items left as they were, if it fails. Nothing
+// is changed if an item is not in the state the operation left it in.
+func undoEntry(e journal.Entry) (journal.Entry, journal.Entry, error) {
+	done := e
+	done.Items = nil
+	if e.Irreversible != "" {
+		return done, e, fmt.Errorf("the last operation can't be undone: %s", e.Irreversible)
+	}
+	if e.Type == journal.Rename {
+		return renameEntry(e, true)
+	}
+	for _, item := range e.Items {
+		if err := checkUndoItem(e.Type, item); err != nil {
+			return done, e, err
+		}
+	}
+
+	for i := len(e.Items) - 1; i >= 0; i-- {
+		if err := undoItem(e.Type, e.Items[i]); err != nil {
+			remaining := e
+			remaining.Items = e.Items[:i+1]
+			return done, remaining, err
+		}
+		// Kept in the original order, so that redoing them runs them in the same order
+		done.Items = slices.Insert(done.Items, 0, e.Items[i])
+	}
+	return done, journal.Entry{}, nil
+}
+
+// redoEntry performs the items of e again, first item first. See undoEntry.
+func redoEntry(e journal.Entry) (journal.Entry, journal.Entry, error) {
+	if e.Type == journal.Rename {
+		return renameEntry(e, false)
+	}
+	done := e
+	done.Items = nil
+	for _, item := range e.Items {
+		if err := checkRedoItem(e.Type, item); err != nil {
+			return done, e, err
+		}
+	}
+
+	for i, item := range e.Items {
+		item, err := redoItem(e.Type, item)
+		if err != nil {
+			remaining := e
+			remaining.Items = e.Items[i:]
+			return done, remaining, err
+		}
+		if e.Type == journal.Trash && item.Dst == "" {
+			done.Irreversible = "the location of the items in the trash is unknown"
+		}
+		done.Items = append(done.Items, item)
+	}
+	return done, journal.Entry{}, nil
+}
+
+// renameEntry reverts, or redoes, all the renames of e at once, as they can swap names
+func renameEntry(e journal.Entry, undo bool) (journal.Entry, journal.Entry, error) {
+	renames := make([]renameItem, 0, len(e.Items))
+	for _, item := range e.Items {
+		if undo {
+			renames = append(renames, renameItem{src: item.Dst, dst: item.Src})
+		} else {
+			renames = append(renames, renameItem{src: item.Src, dst: item.Dst})
+		}
+	}
+	for _, r := range renames {
+		if err := checkPaths([]string{r.src}, nil); err != nil {
+			return journal.Entry{Type: e.Type}, e, err
+		}
+	}
+	if err := renameAll(renames); err != nil {
+		return journal.Entry{Type: e.Type}, e, err
+	}
+	return e, journal.Entry{}, nil
+}
+
+func checkUndoItem(t journal.OpType, item journal.Item) error {
+	switch t {
+	case journal.Rename, journal.Move, journal.Trash:
+		return checkPaths([]string{item.Dst}, []string{item.Src})
+	case journal.Create:
+		if err := checkPaths([]string{item.Dst}, nil); err != nil {
+			return err
+		}
+		// The created file could have been written to since, and removing it would lose data
+		if info, err := os.Lstat(item.Dst); err == nil && !item.IsDir && info.Size() > 0 {
+			return errModifiedSince(item.Dst, "created")
+		}
+		return nil
+	case journal.Copy:
+		if err := checkPaths([]string{item.Dst}, nil); err != nil {
+			return err
+		}
+		// Same as for created items, files could have been edited or added in the copy
+		return checkCopyUnmodified(item)
+	case journal.Symlink, journal.Hardlink:
+		return checkPaths([]string{item.Dst}, nil)
+	case journal.Delete:
+		return errors.New("permanently deleted items can't be restored")
+	default:
+		return fmt.Errorf("unknown operation type %q", t)
+	}
+}
+
+func checkRedoItem(t journal.OpType, item journal.Item) error {
+	switch t {
+	case journal.Rename, journal.Move, journal.Copy, journal.Hardlink:
+		return checkPaths([]string{item.Src}, []string{item.Dst})
+	case journal.Symlink:
+		// The target of a symlink does not have to exist
+		return checkPaths(nil, []string{item.Dst})
+	case journal.Create:
+		return checkPaths(nil, []string{item.Dst})
+	case journal.Trash:
+		return checkPaths([]string{item.Src}, nil)
+	case journal.Delete:
+		return errors.New("permanently deleted items can't be deleted again")
+	default:
+		return fmt.Errorf("unknown operation type %q", t)
+	}
+}
+
+func errModifiedSince(path string, operation string) error {
+	return fmt.Errorf("%s has been modified since it was %s", path, operation)
+}
+
+// checkCopyUnmodified verifies that the copy item.Dst is as stampCopiedItem recorded it
+func checkCopyUnmodified(item journal.Item) error {
+	// Copies recorded before stamps were added can't be checked
+	if item.ModTime.IsZero() {
+		return nil
+	}
+	size, modTime, err := getTreeStamp(item.Dst)
+	if err != nil {
+		return fmt.Errorf("cannot check %s: %w", item.Dst, err)
+	}
+	if size != item.Size || !modTime.Equal(item.ModTime) {
+		return errModifiedSince(item.Dst, "pasted")
+	}
+	return nil
+}
+
+// stampCopiedItem records the size and modification time of the copy item.Dst in item,
+// for checkCopyUnmodified
+func stampCopiedItem(item journal.Item) journal.Item {
+	size, modTime, err := getTreeStamp(item.Dst)
+	if err != nil {
+		slog.Error("Cannot get the modification time of a copy", "path", item.Dst, "error", err)
+		return item
+	}
+	item.Size = size
+	item.ModTime = modTime
+	return item
+}
+
+// getTreeStamp returns the total size of the files of path, and the latest modification
+// time of path and everything in it. Editing, adding or removing anything in path
+// changes one of them.
+func getTreeStamp(path string) (int64, time.Time, error) {
+	var size int64
+	var modTime time.Time
+	err := filepath.WalkDir(path, func(_ string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			size += info.Size()
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+		return nil
+	})
+	return size, modTime, err
+}
+
+// checkPaths verifies that all the paths of existing exist, and that none of the paths of
+// missing do, so that an operation can be reverted without overwriting anything.
+func checkPaths(existing []string, missing []string) error {
+	for _, path := range existing {
+		if _, err := os.Lstat(path); err != nil {
+			return fmt.Errorf("%s is no longer there: %w", path, err)
+		}
+	}
+	for _, path := range missing {
+		if _, err := os.Lstat(path); err == nil {
+			return fmt.Errorf("%s already exists", path)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("cannot check %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func undoItem(t journal.OpType, item journal.Item) error {
+	switch t {
+	case journal.Rename:
+		return os.Rename(item.Dst, item.Src)
+	case journal.Move:
+		if err := os.MkdirAll(filepath.Dir(item.Src), 0o755); err != nil {
+			return fmt.Errorf("failed to create parent directory: %w", err)
+		}
+		return ignoreAttrPreserveError(moveElement(context.Background(), item.Dst, item.Src, nil, nil))
+	case journal.Create:
+		// Only removes empty directories, in case items were added to them since
+		return os.Remove(item.Dst)
+	case journal.Copy:
+		return os.RemoveAll(item.Dst)
+	case journal.Symlink, journal.Hardlink:
+		return os.Remove(item.Dst)
+	case journal.Trash:
+		return restoreFromTrash(item.Dst, item.Src)
+	case journal.Delete:
+		return errors.New("permanently deleted items can't be restored")
+	default:
+		return fmt.Errorf("unknown operation type %q", t)
+	}
+}
+
+// redoItem performs the operation on item again. It returns the item with its updated
+// destination, which changes when it is moved to the trash again.
+func redoItem(t journal.OpType, item journal.Item) (journal.Item, error) {
+	var err error
+	switch t {
+	case journal.Rename:
+		err = os.Rename(item.Src, item.Dst)
+	case journal.Move:
+		err = ignoreAttrPreserveError(moveElement(context.Background(), item.Src, item.Dst, nil, nil))
+	case journal.Create:
+		if item.IsDir {
+			err = os.Mkdir(item.Dst, 0o755)
+		} else {
+			var f *os.File
+			if f, err = os.OpenFile(item.Dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644); err == nil {
+				err = f.Close()
+			}
+		}
+	case journal.Copy:
+		err = ignoreAttrPreserveError(copyElement(context.Background(), item.Src, item.Dst, nil, nil))
+		if err == nil {
+			item = stampCopiedItem(item)
+		}
+	case journal.Symlink:
+		err = os.Symlink(item.Src, item.Dst)
+	case journal.Hardlink:
+		err = createHardlink(item.Src, item.Dst)
+	case journal.Trash:
+		item.Dst, err = moveToTrash(item.Src)
+	case journal.Delete:
+		err = errors.New("permanently deleted items can't be deleted again")
+	default:
+		err = fmt.Errorf("unknown operation type %q", t)
+	}
+	return item, err
+}
+
+// ignoreAttrPreserveError drops errors about attributes that could not be preserved,
+// as the items themselves were copied
+func ignoreAttrPreserveError(err error) error {
+	if isAttrPreserveError(err) {
+		slog.Warn("Could not preserve attributes", "error", err)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,172 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Maximum count of operations kept. The file is rewritten with the last MaxEntries
+// ones once it has twice as many.
+const MaxEntries = 1000
+
+// Entry is a finished operation of the process bar
+type Entry struct {
+	Name        string   `json:"name"`
+	Sources     []string `json:"sources,omitempty"`
+	Destination string   `json:"destination,omitempty"`
+	// Final state of the process, like "successful" or "failed"
+	State string `json:"state"`
+	// When the operation finished
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// History keeps the finished operations, oldest first. Each one is appended to a file as
+// a line of JSON, so that they can still be browsed after a restart.
+// Its methods are safe for concurrent use.
+type History struct {
+	mu       sync.Mutex
+	filePath string
+	entries  []Entry
+	// Count of lines in the file, including the ones that are not in entries anymore
+	cntLines int
+}
+
+// New loads the history saved at filePath. Invalid lines are skipped, and a missing file
+// gives an empty history. If filePath is empty, the history is only kept in memory.
+func New(filePath string) *History {
+	h := &History{filePath: filePath}
+	if filePath == "" {
+		return h
+	}
+	f, err := os.Open(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return h
+	}
+	if err != nil {
+		slog.Error("Error reading operation history", "error", err)
+		return h
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		h.cntLines++
+		var e Entry
+		if err = json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			slog.Debug("Skipping invalid line of operation history", "line", h.cntLines, "error", err)
+			continue
+		}
+		h.entries = pushEntry(h.entries, e)
+	}
+	if err = scanner.Err(); err != nil {
+		slog.Error("Error reading operation history", "error", err)
+	}
+	return h
+}
+
+// Record adds a finished operation
+func (h *History) Record(e Entry) {
+	if h == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = pushEntry(h.entries, e)
+	h.save(e)
+}
+
+// Entries returns the recorded operations, oldest first
+func (h *History) Entries() []Entry {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]Entry, len(h.entries))
+	copy(out, h.entries)
+	return out
+}
+
+func pushEntry(entries []Entry, e Entry) []Entry {
+	entries = append(entries, e)
+	if len(entries) > MaxEntries {
+		entries = entries[len(entries)-MaxEntries:]
+	}
+	return entries
+}
+
+// save appends e to the file, or rewrites it if it has grown too much. h.mu must be held.
+// Errors are only logged, as failing to save must not fail the operation itself.
+func (h *History) save(e Entry) {
+	if h.filePath == "" {
+		return
+	}
+	var err error
+	if h.cntLines >= 2*MaxEntries {
+		err = h.writeFile()
+	} else {
+		err = h.appendToFile(e)
+	}
+	if err != nil {
+		slog.Error("Error saving operation history", "error", err)
+	}
+}
+
+func (h *History) appendToFile(e Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("error marshaling history entry: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(h.filePath), 0o755); err != nil {
+		return fmt.Errorf("error creating history directory: %w", err)
+	}
+	f, err := os.OpenFile(h.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening history file: %w", err)
+	}
+	_, err = f.Write(append(line, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing history file: %w", err)
+	}
+	h.cntLines++
+	return nil
+}
+
+// writeFile replaces the file with the entries kept in memory
+func (h *History) writeFile() error {
+	var data []byte
+	for _, e := range h.entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("error marshaling history entry: %w", err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+	if err := os.MkdirAll(filepath.Dir(h.filePath), 0o755); err != nil {
+		return fmt.Errorf("error creating history directory: %w", err)
+	}
+	// Written to a temporary file first, so that a crash doesn't leave a truncated history
+	tmpFile := h.filePath + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0o600); err != nil {
+		return fmt.Errorf("error writing history file: %w", err)
+	}
+	if err := os.Rename(tmpFile, h.filePath); err != nil {
+		return fmt.Errorf("error replacing history file: %w", err)
+	}
+	h.cntLines = len(h.entries)
+	return nil
+}
@@ -0,0 +1,60 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "state", "history.jsonl")
+	h := New(filePath)
+	assert.Empty(t, h.Entries(), "New history should be empty")
+
+	paste := Entry{Name: "file.txt", Sources: []string{"/a/file.txt"}, Destination: "/b",
+		State: "successful", Duration: 2 * time.Second}
+	failed := Entry{Name: "dir", Sources: []string{"/a/dir"}, State: "failed", Error: "permission denied"}
+	h.Record(paste)
+	h.Record(failed)
+
+	entries := h.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "file.txt", entries[0].Name)
+	assert.False(t, entries[0].Time.IsZero(), "Time should be set when recording")
+
+	t.Run("Saved to file", func(t *testing.T) {
+		loaded := New(filePath).Entries()
+		require.Len(t, loaded, 2)
+		assert.Equal(t, paste.Sources, loaded[0].Sources)
+		assert.Equal(t, paste.Duration, loaded[0].Duration)
+		assert.Equal(t, failed.Error, loaded[1].Error)
+	})
+
+	t.Run("Invalid lines are skipped", func(t *testing.T) {
+		invalidFile := filepath.Join(t.TempDir(), "history.jsonl")
+		require.NoError(t, os.WriteFile(invalidFile,
+			[]byte("{invalid\n{\"name\":\"valid\",\"state\":\"failed\"}\n"), 0o600))
+		loaded := New(invalidFile).Entries()
+		require.Len(t, loaded, 1)
+		assert.Equal(t, "valid", loaded[0].Name)
+	})
+
+	t.Run("Entries are capped", func(t *testing.T) {
+		cappedFile := filepath.Join(t.TempDir(), "history.jsonl")
+		capped := New(cappedFile)
+		for range 2*MaxEntries + 5 {
+			capped.Record(paste)
+		}
+		assert.Len(t, capped.Entries(), MaxEntries)
+
+		data, err := os.ReadFile(cappedFile)
+		require.NoError(t, err)
+		assert.Less(t, strings.Count(string(data), "\n"), 2*MaxEntries, "File should be rewritten once too long")
+		assert.Len(t, New(cappedFile).Entries(), MaxEntries)
+	})
+}
@@ -0,0 +1,192 @@
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Maximum count of operations kept in each of the undo and redo lists
+const MaxEntries = 100
+
+// OpType is the kind of a recorded operation
+type OpType string
+
+const (
+	Rename OpType = "rename"
+	Create OpType = "create"
+	Move   OpType = "move"
+	Copy   OpType = "copy"
+	Trash  OpType = "trash"
+	Delete OpType = "delete"
+	// Src of the items of a link is the target of the link, as written in it
+	Symlink  OpType = "symlink"
+	Hardlink OpType = "hardlink"
+)
+
+// Item is a single path changed by an operation
+type Item struct {
+	// Where the item was before the operation. Empty for created items.
+	Src string `json:"src,omitempty"`
+	// Where the item is after the operation. For items moved to the trash, its
+	// location in the trash. Empty for deleted items.
+	Dst   string `json:"dst,omitempty"`
+	IsDir bool   `json:"is_dir,omitempty"`
+	// Total size of the files of a copied item, and the latest modification time of
+	// anything in it, so that undoing the copy doesn't remove changes made to it since
+	Size    int64     `json:"size,omitempty"`
+	ModTime time.Time `json:"mod_time,omitzero"`
+}
+
+// Entry is a completed operation
+type Entry struct {
+	Type  OpType    `json:"type"`
+	Items []Item    `json:"items"`
+	Time  time.Time `json:"time"`
+	// Why the operation can't be undone. Empty if it can be.
+	Irreversible string `json:"irreversible,omitempty"`
+}
+
+type journalData struct {
+	Undo []Entry `json:"undo"`
+	Redo []Entry `json:"redo"`
+}
+
+// Journal keeps the lists of operations that can be undone and redone. They are saved
+// to a file after each change, so that operations can still be undone after a restart.
+// Its methods are safe for concurrent use.
+type Journal struct {
+	mu       sync.Mutex
+	filePath string
+	data     journalData
+}
+
+// New loads the journal saved at filePath. A missing or invalid file gives an empty journal.
+// If filePath is empty, the journal is only kept in memory.
+func New(filePath string) *Journal {
+	j := &Journal{filePath: filePath}
+	if filePath == "" {
+		return j
+	}
+	jsonData, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return j
+	}
+	if err != nil {
+		slog.Error("Error reading operation journal", "error", err)
+		return j
+	}
+	if err = json.Unmarshal(jsonData, &j.data); err != nil {
+		slog.Error("Error parsing operation journal", "error", err)
+		j.data = journalData{}
+	}
+	return j
+}
+
+// Record adds a completed operation. It clears the operations that could be redone.
+func (j *Journal) Record(e Entry) {
+	if j == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.data.Undo = pushEntry(j.data.Undo, e)
+	j.data.Redo = nil
+	j.save()
+}
+
+// PopUndo removes and returns the last operation that can be undone. ok is false if there is none.
+func (j *Journal) PopUndo() (Entry, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var e Entry
+	var ok bool
+	j.data.Undo, e, ok = popEntry(j.data.Undo)
+	if ok {
+		j.save()
+	}
+	return e, ok
+}
+
+// PopRedo removes and returns the last undone operation. ok is false if there is none.
+func (j *Journal) PopRedo() (Entry, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var e Entry
+	var ok bool
+	j.data.Redo, e, ok = popEntry(j.data.Redo)
+	if ok {
+		j.save()
+	}
+	return e, ok
+}
+
+// PushUndo adds e as the last operation that can be undone, without clearing the redo list.
+// It is used for redone operations, and to put back an operation that could not be undone.
+func (j *Journal) PushUndo(e Entry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.data.Undo = pushEntry(j.data.Undo, e)
+	j.save()
+}
+
+// PushRedo adds e as the last operation that can be redone
+func (j *Journal) PushRedo(e Entry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.data.Redo = pushEntry(j.data.Redo, e)
+	j.save()
+}
+
+func pushEntry(entries []Entry, e Entry) []Entry {
+	entries = append(entries, e)
+	if len(entries) > MaxEntries {
+		entries = entries[len(entries)-MaxEntries:]
+	}
+	return entries
+}
+
+func popEntry(entries []Entry) ([]Entry, Entry, bool) {
+	if len(entries) == 0 {
+		return entries, Entry{}, false
+	}
+	return entries[:len(entries)-1], entries[len(entries)-1], true
+}
+
+// save writes the journal to its file. j.mu must be held. Errors are only logged,
+// as failing to save must not fail the operation itself.
+func (j *Journal) save() {
+	if j.filePath == "" {
+		return
+	}
+	if err := j.writeFile(); err != nil {
+		slog.Error("Error saving operation journal", "error", err)
+	}
+}
+
+func (j *Journal) writeFile() error {
+	data, err := json.Marshal(j.data)
+	if err != nil {
+		return fmt.Errorf("error marshaling journal: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(j.filePath), 0o755); err != nil {
+		return fmt.Errorf("error creating journal directory: %w", err)
+	}
+	// Written to a temporary file first, so that a crash doesn't leave a truncated journal
+	tmpFile := j.filePath + ".tmp"
+	if err = os.WriteFile(tmpFile, data, 0o600); err != nil {
+		return fmt.Errorf("error writing journal file: %w", err)
+	}
+	if err = os.Rename(tmpFile, j.filePath); err != nil {
+		return fmt.Errorf("error replacing journal file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "state", "journal.json")
+	j := New(filePath)
+	_, ok := j.PopUndo()
+	assert.False(t, ok, "New journal should be empty")
+
+	rename := Entry{Type: Rename, Items: []Item{{Src: "/a", Dst: "/b"}}}
+	create := Entry{Type: Create, Items: []Item{{Dst: "/c", IsDir: true}}}
+	j.Record(rename)
+	j.Record(create)
+
+	e, ok := j.PopUndo()
+	require.True(t, ok)
+	assert.Equal(t, Create, e.Type)
+	assert.False(t, e.Time.IsZero(), "Time should be set when recording")
+	j.PushRedo(e)
+
+	t.Run("Saved to file", func(t *testing.T) {
+		loaded := New(filePath)
+		redo, ok := loaded.PopRedo()
+		require.True(t, ok)
+		assert.Equal(t, create.Items, redo.Items)
+		undo, ok := loaded.PopUndo()
+		require.True(t, ok)
+		assert.Equal(t, rename.Items, undo.Items)
+	})
+
+	t.Run("Recording clears redo", func(t *testing.T) {
+		j.Record(Entry{Type: Trash, Items: []Item{{Src: "/d", Dst: "/trash/d"}}})
+		_, ok := j.PopRedo()
+		assert.False(t, ok)
+	})
+
+	t.Run("Entries are capped", func(t *testing.T) {
+		capped := New("")
+		for range MaxEntries + 5 {
+			capped.Record(rename)
+		}
+		cnt := 0
+		for {
+			if _, ok := capped.PopUndo(); !ok {
+				break
+			}
+			cnt++
+		}
+		assert.Equal(t, MaxEntries, cnt)
+	})
+
+	t.Run("Popping an empty journal doesn't save it", func(t *testing.T) {
+		emptyFile := filepath.Join(t.TempDir(), "journal.json")
+		empty := New(emptyFile)
+		_, ok := empty.PopUndo()
+		assert.False(t, ok)
+		_, ok = empty.PopRedo()
+		assert.False(t, ok)
+		assert.NoFileExists(t, emptyFile)
+	})
+
+	t.Run("Invalid file gives an empty journal", func(t *testing.T) {
+		invalidFile := filepath.Join(t.TempDir(), "journal.json")
+		require.NoError(t, os.WriteFile(invalidFile, []byte("{invalid"), 0o600))
+		_, ok := New(invalidFile).PopUndo()
+		assert.False(t, ok)
+	})
+}
@@ -19,6 +19,9 @@ import (
 // updates and fixes in key handling code
 func (m *model) mainKey(msg string) tea.Cmd { //nolint: gocyclo,cyclop,funlen // See above
 	switch {
+	case m.isArchiveReadOnlyKey(msg):
+		m.openArchiveReadOnlyModal()
+
 	// If move up Key is pressed, check the current state and executes
 	case slices.Contains(common.Hotkeys.ListUp, msg):
 		switch m.focusPanel {
@@ -83,6 +86,21 @@ func (m *model) mainKey(msg string) tea.Cmd { //nolint: gocyclo,cyclop,funlen //
 	case slices.Contains(common.Hotkeys.PasteItems, msg):
 		return m.getPasteItemCmd()
 
+	case slices.Contains(common.Hotkeys.PasteAsSymlink, msg):
+		return m.getPasteAsLinkCmd(symlinkAbsolute)
+
+	case slices.Contains(common.Hotkeys.PasteAsRelativeSymlink, msg):
+		return m.getPasteAsLinkCmd(symlinkRelative)
+
+	case slices.Contains(common.Hotkeys.PasteAsHardlink, msg):
+		return m.getPasteAsLinkCmd(hardlink)
+
+	case slices.Contains(common.Hotkeys.Undo, msg):
+		return m.getUndoCmd()
+
+	case slices.Contains(common.Hotkeys.Redo, msg):
+		return m.getRedoCmd()
+
 	case slices.Contains(common.Hotkeys.FilePanelItemCreate, msg):
 		m.panelCreateNewFile()
 	case slices.Contains(common.Hotkeys.PinnedDirectory, msg):
@@ -96,9 +114,16 @@ func (m *model) mainKey(msg string) tea.Cmd { //nolint: gocyclo,cyclop,funlen //
 
 	case slices.Contains(common.Hotkeys.ExtractFile, msg):
 		return m.getExtractFileCmd()
+	case slices.Contains(common.Hotkeys.ExtractFileHere, msg):
+		return m.getExtractFileHereCmd()
+	case slices.Contains(common.Hotkeys.ExtractFileToOtherPanel, msg):
+		return m.getExtractFileToOtherPanelCmd()
 
 	case slices.Contains(common.Hotkeys.CompressFile, msg):
-		return m.getCompressSelectedFilesCmd()
+		return m.getCompressSelectedFilesCmd(common.Config.DefaultCompressFormat)
+
+	case slices.Contains(common.Hotkeys.CompressFileWithFormat, msg):
+		return m.openCompressModal()
 
 	case slices.Contains(common.Hotkeys.OpenCommandLine, msg):
 		m.promptModal.Open(true)
@@ -106,6 +131,12 @@ func (m *model) mainKey(msg string) tea.Cmd { //nolint: gocyclo,cyclop,funlen //
 		m.promptModal.Open(false)
 	case slices.Contains(common.Hotkeys.OpenZoxide, msg):
 		return m.zoxideModal.Open()
+	case slices.Contains(common.Hotkeys.OpenOperationHistory, msg):
+		m.historyModal.Open(m.history.Entries())
+	case slices.Contains(common.Hotkeys.BatchRename, msg):
+		return m.openBatchRenameModal()
+	case slices.Contains(common.Hotkeys.ChangePermissions, msg):
+		return m.openPermissionsModal()
 
 	case slices.Contains(common.Hotkeys.OpenHelpMenu, msg):
 		m.openHelpMenu()
@@ -141,6 +172,9 @@ func (m *model) normalAndBrowserModeKey(msg string) tea.Cmd {
 		if m.focusPanel == sidebarFocus && slices.Contains(common.Hotkeys.SearchBar, msg) {
 			m.sidebarSearchBarFocus()
 		}
+		if m.focusPanel == processBarFocus {
+			m.processBarKey(msg)
+		}
 		return nil
 	}
 	// Check if in the select mode and focusOn filepanel
@@ -162,6 +196,12 @@ func (m *model) normalAndBrowserModeKey(msg string) tea.Cmd {
 			m.copyMultipleItem(true)
 		case slices.Contains(common.Hotkeys.FilePanelSelectAllItem, msg):
 			m.selectAllItem()
+		case slices.Contains(common.Hotkeys.FilePanelItemRename, msg):
+			return m.bulkRenameSelectedItems()
+		case slices.Contains(common.Hotkeys.RestoreFromTrash, msg):
+			return m.getRestoreFromTrashCmd()
+		case slices.Contains(common.Hotkeys.EmptyTrash, msg):
+			return m.getEmptyTrashTriggerCmd()
 		}
 		return nil
 	}
@@ -187,6 +227,10 @@ func (m *model) normalAndBrowserModeKey(msg string) tea.Cmd {
 		m.copyPath()
 	case slices.Contains(common.Hotkeys.CopyPWD, msg):
 		m.copyPWD()
+	case slices.Contains(common.Hotkeys.RestoreFromTrash, msg):
+		return m.getRestoreFromTrashCmd()
+	case slices.Contains(common.Hotkeys.EmptyTrash, msg):
+		return m.getEmptyTrashTriggerCmd()
 	}
 	return nil
 }
@@ -206,16 +250,34 @@ func (m *model) notifyModelOpenKey(msg string) tea.Cmd {
 	isCancel := slices.Contains(common.Hotkeys.CancelTyping, msg) || slices.Contains(common.Hotkeys.Quit, msg)
 	isConfirm := slices.Contains(common.Hotkeys.Confirm, msg)
 
+	if m.notifyModel.IsMultiChoice() || m.notifyModel.IsList() {
+		switch {
+		case slices.Contains(common.Hotkeys.ListUp, msg):
+			m.notifyModel.ListUp()
+			return nil
+		case slices.Contains(common.Hotkeys.ListDown, msg):
+			m.notifyModel.ListDown()
+			return nil
+		case isConfirm && m.notifyModel.IsCursorOnApplyToAll():
+			m.notifyModel.ToggleApplyToAll()
+			return nil
+		}
+	}
+
 	if !isCancel && !isConfirm {
 		slog.Warn("Invalid keypress in notifyModel", "msg", msg)
 		return nil
 	}
 	m.notifyModel.Close()
 	action := m.notifyModel.GetConfirmAction()
+	var cmd tea.Cmd
 	if isCancel {
-		return m.handleNotifyModelCancel(action)
+		cmd = m.handleNotifyModelCancel(action)
+	} else {
+		cmd = m.handleNotifyModelConfirm(action)
 	}
-	return m.handleNotifyModelConfirm(action)
+	// A paste conflict that came while the dialog was open is shown now
+	return tea.Batch(cmd, m.showPendingPasteConflict())
 }
 
 func (m *model) handleNotifyModelCancel(action notify.ConfirmActionType) tea.Cmd {
@@ -224,7 +286,13 @@ func (m *model) handleNotifyModelCancel(action notify.ConfirmActionType) tea.Cmd
 		m.cancelRename()
 	case notify.QuitAction:
 		m.modelQuitState = notQuitting
-	case notify.DeleteAction, notify.NoAction, notify.PermanentDeleteAction:
+	case notify.PasteConflictAction:
+		return m.answerPasteConflict(true)
+	case notify.BulkRenameAction:
+		m.cancelBulkRename()
+	case notify.PastePreflightAction:
+		m.pendingPaste = nil
+	case notify.DeleteAction, notify.NoAction, notify.PermanentDeleteAction, notify.EmptyTrashAction:
 		// Do nothing
 	default:
 		slog.Error("Unknown type of action", "action", action)
@@ -238,10 +306,18 @@ func (m *model) handleNotifyModelConfirm(action notify.ConfirmActionType) tea.Cm
 		return m.getDeleteCmd(false)
 	case notify.PermanentDeleteAction:
 		return m.getDeleteCmd(true)
+	case notify.EmptyTrashAction:
+		return m.getEmptyTrashCmd()
+	case notify.BulkRenameAction:
+		return m.getBulkRenameCmd()
+	case notify.PastePreflightAction:
+		return m.getPendingPasteCmd()
 	case notify.RenameAction:
 		m.confirmRename()
 	case notify.QuitAction:
 		m.modelQuitState = quitConfirmationReceived
+	case notify.PasteConflictAction:
+		return m.answerPasteConflict(false)
 	case notify.NoAction:
 		// Ignore
 	default:
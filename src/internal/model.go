@@ -12,6 +12,7 @@ import (
 
 	"github.com/yorukot/superfile/src/config/icon"
 	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/compress"
 	"github.com/yorukot/superfile/src/internal/ui/metadata"
 	"github.com/yorukot/superfile/src/internal/ui/notify"
 	"github.com/yorukot/superfile/src/internal/utils"
@@ -53,6 +54,7 @@ func (m *model) Init() tea.Cmd {
 		tea.SetWindowTitle("superfile"),
 		textinput.Blink, // Assuming textinput.Blink is a valid command
 		processCmdToTeaCmd(m.processBarModel.GetListenCmd()),
+		m.getPasteConflictListenCmd(),
 	)
 }
 
@@ -63,7 +65,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// If its quitDone. But if we are at this state, its already bad, so we need
 	// to first figure out if its possible in testing, and fix it.
 	slog.Debug("model.Update() called", "msgType", reflect.TypeOf(msg))
-	var sidebarCmd, inputCmd, updateCmd, panelCmd, metadataCmd, filePreviewCmd tea.Cmd
+	var sidebarCmd, inputCmd, updateCmd, panelCmd, metadataCmd, filePreviewCmd, archiveCmd tea.Cmd
 	gotModelUpdateMsg := false
 
 	sidebarCmd = m.sidebarModel.UpdateState(msg)
@@ -94,6 +96,10 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		slog.Debug("Got ModelUpdate message", "id", msg.GetReqID())
 		gotModelUpdateMsg = true
 		updateCmd = msg.Apply(&m.zoxideModal)
+	case compress.EstimateMsg:
+		slog.Debug("Got ModelUpdate message", "id", msg.GetReqID())
+		gotModelUpdateMsg = true
+		updateCmd = msg.Apply(&m.compressModal)
 	case ModelUpdateMessage:
 		// TODO: Some of these updates messages should trigger filePanel state update
 		// For example a success message for delete operation
@@ -112,6 +118,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	panelCmd = m.updateFilePanelsState(msg)
 
 	m.updateModelStateAfterMsg()
+	archiveCmd = m.getArchiveLoadCmd()
 
 	// Temp fix till we add metadata cache, to prevent multiple metadata fetch spawns
 	// Ideally we might want to fetch only if the current file selected in filepanel changes
@@ -120,7 +127,8 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		filePreviewCmd = m.getFilePreviewCmd(forcePreviewRender)
 	}
 
-	return m, tea.Batch(sidebarCmd, helpMenuCmd, inputCmd, updateCmd, panelCmd, metadataCmd, filePreviewCmd)
+	return m, tea.Batch(sidebarCmd, helpMenuCmd, inputCmd, updateCmd, panelCmd, metadataCmd, filePreviewCmd,
+		archiveCmd)
 }
 
 func (m *model) handleMouseMsg(msg tea.MouseMsg) {
@@ -168,8 +176,19 @@ func (m *model) getFilePreviewCmd(forcePreviewRender bool) tea.Cmd {
 
 	// Copy to a local variable to be used in below closure.
 	fullModalWidth := m.fullWidth
+	// Passwords given for extractions are used for previews too
+	var archivePassword string
+	if selectedItem.archiveInfo != nil {
+		if archivePath, _, ok := splitArchivePath(selectedItem.location); ok {
+			archivePassword = m.archivePasswords[archiveSetName(archivePath)]
+		}
+	}
 
 	return func() tea.Msg {
+		if selectedItem.archiveInfo != nil {
+			return NewFilePreviewUpdateMsg(selectedItem.location,
+				m.renderArchiveItemPreview(selectedItem, archivePassword, fullModalWidth), reqCnt)
+		}
 		return NewFilePreviewUpdateMsg(selectedItem.location,
 			m.fileModel.filePreview.RenderWithPath(selectedItem.location, fullModalWidth), reqCnt)
 	}
@@ -233,6 +252,10 @@ func (m *model) handleWindowResize(msg tea.WindowSizeMsg) {
 	m.setProcessBarModelSize()
 	m.setPromptModelSize()
 	m.setZoxideModelSize()
+	m.setHistoryModelSize()
+	m.setFailuresModelSize()
+	m.setBatchRenameModelSize()
+	m.setPermissionsModelSize()
 
 	if m.fileModel.maxFilePanel >= 10 {
 		m.fileModel.maxFilePanel = 10
@@ -323,6 +346,28 @@ func (m *model) setZoxideModelSize() {
 	m.zoxideModal.SetWidth(m.fullWidth / 2)
 }
 
+func (m *model) setHistoryModelSize() {
+	m.historyModal.SetMaxHeight(m.fullHeight / 2)
+	m.historyModal.SetWidth(m.fullWidth / 2)
+}
+
+func (m *model) setFailuresModelSize() {
+	m.failuresModal.SetMaxHeight(m.fullHeight / 2)
+	m.failuresModal.SetWidth(m.fullWidth / 2)
+}
+
+func (m *model) setBatchRenameModelSize() {
+	m.batchRenameModal.SetMaxHeight(m.fullHeight / 2)
+	m.batchRenameModal.SetWidth(m.fullWidth / 2)
+}
+
+func (m *model) setPermissionsModelSize() {
+	m.permissionsModal.SetMaxHeight(m.fullHeight / 2)
+	m.permissionsModal.SetWidth(m.fullWidth / 3)
+	m.passwordModal.SetWidth(m.fullWidth / 3)
+	m.compressModal.SetWidth(m.fullWidth / 3)
+}
+
 func (m *model) setMetadataModelSize() {
 	m.fileMetaData.SetDimensions(utils.FooterWidth(m.fullWidth)+2, m.footerHeight+2)
 }
@@ -367,6 +412,10 @@ func (m *model) handleKeyInput(msg tea.KeyMsg) tea.Cmd {
 	case m.zoxideModal.IsOpen():
 		// Ignore keypress. It will be handled in Update call via
 		// updateFilePanelState
+	case m.batchRenameModal.IsOpen(), m.permissionsModal.IsOpen(), m.passwordModal.IsOpen(),
+		m.compressModal.IsOpen(), m.historyModal.IsOpen(), m.failuresModal.IsOpen():
+		// Ignore keypress. It will be handled in Update call via
+		// updateFilePanelState
 
 	// Handles all warn models except the warn model for confirming to quit
 	case m.notifyModel.IsOpen():
@@ -442,6 +491,24 @@ func (m *model) updateFilePanelsState(msg tea.Msg) tea.Cmd {
 	case m.zoxideModal.IsOpen():
 		action, cmd = m.zoxideModal.HandleUpdate(msg)
 		m.applyZoxideModalAction(action)
+	case m.batchRenameModal.IsOpen():
+		action, cmd = m.batchRenameModal.HandleUpdate(msg)
+		cmd = tea.Batch(cmd, m.applyBatchRenameModalAction(action))
+	case m.permissionsModal.IsOpen():
+		action, cmd = m.permissionsModal.HandleUpdate(msg)
+		cmd = tea.Batch(cmd, m.applyPermissionsModalAction(action))
+	case m.passwordModal.IsOpen():
+		action, cmd = m.passwordModal.HandleUpdate(msg)
+		cmd = tea.Batch(cmd, m.applyPasswordModalAction(action))
+	case m.compressModal.IsOpen():
+		action, cmd = m.compressModal.HandleUpdate(msg)
+		cmd = tea.Batch(cmd, m.applyCompressModalAction(action))
+	case m.historyModal.IsOpen():
+		// The history only shows operations, it has no action to apply
+		_, cmd = m.historyModal.HandleUpdate(msg)
+	case m.failuresModal.IsOpen():
+		action, cmd = m.failuresModal.HandleUpdate(msg)
+		cmd = tea.Batch(cmd, m.applyFailuresModalAction(action))
 	}
 
 	// TODO : This is like duct taping a bigger problem
@@ -521,7 +588,8 @@ func (m *model) createNewFilePanelRelativeToCurrent(path string) error {
 func (m *model) updateCurrentFilePanelDir(path string) error {
 	panel := m.getFocusedFilePanel()
 	err := panel.updateCurrentFilePanelDir(path)
-	if err == nil {
+	// Archives are not directories that zoxide could cd into
+	if err == nil && !isInArchive(panel.location) {
 		// Track the directory change with zoxide
 		m.trackDirectoryWithZoxide(panel.location)
 	}
@@ -633,6 +701,48 @@ func (m *model) updateRenderForOverlay(finalRender string) string {
 		return stringfunction.PlaceOverlay(overlayX, overlayY, zoxideModal, finalRender)
 	}
 
+	if m.batchRenameModal.IsOpen() {
+		batchRenameModal := m.batchRenameModal.Render()
+		overlayX := m.fullWidth/2 - m.batchRenameModal.GetWidth()/2
+		overlayY := m.fullHeight/2 - m.batchRenameModal.GetMaxHeight()/2
+		return stringfunction.PlaceOverlay(overlayX, overlayY, batchRenameModal, finalRender)
+	}
+
+	if m.permissionsModal.IsOpen() {
+		permissionsModal := m.permissionsModal.Render()
+		overlayX := m.fullWidth/2 - m.permissionsModal.GetWidth()/2
+		overlayY := m.fullHeight/2 - m.permissionsModal.GetMaxHeight()/2
+		return stringfunction.PlaceOverlay(overlayX, overlayY, permissionsModal, finalRender)
+	}
+
+	if m.passwordModal.IsOpen() {
+		passwordModal := m.passwordModal.Render()
+		overlayX := m.fullWidth/2 - m.passwordModal.GetWidth()/2
+		overlayY := m.fullHeight/2 - m.passwordModal.GetHeight()/2
+		return stringfunction.PlaceOverlay(overlayX, overlayY, passwordModal, finalRender)
+	}
+
+	if m.compressModal.IsOpen() {
+		compressModal := m.compressModal.Render()
+		overlayX := m.fullWidth/2 - m.compressModal.GetWidth()/2
+		overlayY := m.fullHeight/2 - m.compressModal.GetHeight()/2
+		return stringfunction.PlaceOverlay(overlayX, overlayY, compressModal, finalRender)
+	}
+
+	if m.historyModal.IsOpen() {
+		historyModal := m.historyModal.Render()
+		overlayX := m.fullWidth/2 - m.historyModal.GetWidth()/2
+		overlayY := m.fullHeight/2 - m.historyModal.GetMaxHeight()/2
+		return stringfunction.PlaceOverlay(overlayX, overlayY, historyModal, finalRender)
+	}
+
+	if m.failuresModal.IsOpen() {
+		failuresModal := m.failuresModal.Render()
+		overlayX := m.fullWidth/2 - m.failuresModal.GetWidth()/2
+		overlayY := m.fullHeight/2 - m.failuresModal.GetMaxHeight()/2
+		return stringfunction.PlaceOverlay(overlayX, overlayY, failuresModal, finalRender)
+	}
+
 	panel := m.fileModel.filePanels[m.filePanelFocusIndex]
 
 	if panel.sortOptions.open {
@@ -659,7 +769,7 @@ func (m *model) updateRenderForOverlay(finalRender string) string {
 	if m.notifyModel.IsOpen() {
 		notifyModal := m.notifyModel.Render()
 		overlayX := m.fullWidth/2 - common.ModalWidth/2
-		overlayY := m.fullHeight/2 - common.ModalHeight/2
+		overlayY := m.fullHeight/2 - m.notifyModel.GetHeight()/2
 		return stringfunction.PlaceOverlay(overlayX, overlayY, notifyModal, finalRender)
 	}
 	return finalRender
@@ -733,6 +843,11 @@ func (m *model) getFilePanelItems() {
 		} else {
 			fileElement = returnDirElement(filePanel.location, m.toggleDotFile, filePanel.sortOptions.data)
 		}
+		if isTrashDirectory(filePanel.location) {
+			fileElement = addTrashInfo(fileElement, filePanel.sortOptions.data)
+		} else {
+			fileElement = addArchiveInfo(fileElement, filePanel.location)
+		}
 		// Update file panel list
 		filePanel.element = fileElement
 		m.fileModel.filePanels[i].element = fileElement
@@ -749,8 +864,8 @@ func (m *model) quitSuperfile(cdOnQuit bool) {
 	if common.Config.Metadata && et != nil {
 		et.Close()
 	}
-	// cd on quit
-	currentDir := m.fileModel.filePanels[m.filePanelFocusIndex].location
+	// cd on quit. The shell can't cd into an archive, so the directory containing it is used.
+	currentDir := realDirectory(m.fileModel.filePanels[m.filePanelFocusIndex].location)
 	variable.SetLastDir(currentDir)
 
 	if cdOnQuit {
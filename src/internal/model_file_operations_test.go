@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -15,6 +16,7 @@ import (
 	variable "github.com/yorukot/superfile/src/config"
 	"github.com/yorukot/superfile/src/internal/common"
 	"github.com/yorukot/superfile/src/internal/ui/notify"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
 	"github.com/yorukot/superfile/src/internal/utils"
 )
 
@@ -200,11 +202,58 @@ func isTrashed(fileAbsPath string) bool {
 	}
 }
 
+// trashTestDir returns a temporary directory on the device of the trash, for the deleted
+// items to be moved to it rather than to the trash of the volume of the test directory
+func trashTestDir(t *testing.T) string {
+	t.Helper()
+	parent := filepath.Dir(variable.LinuxTrashDirectory)
+	if runtime.GOOS == utils.OsDarwin {
+		parent = variable.HomeDir
+	}
+	require.NoError(t, os.MkdirAll(parent, 0o755))
+	dir, err := os.MkdirTemp(parent, "superfile-test-")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return dir
+}
+
+func TestBatchRename(t *testing.T) {
+	curTestDir := t.TempDir()
+	file1 := filepath.Join(curTestDir, "file1.txt")
+	file2 := filepath.Join(curTestDir, "file2.txt")
+	utils.SetupFilesWithData(t, []byte("f1"), file1)
+	utils.SetupFilesWithData(t, []byte("f2"), file2)
+
+	m := defaultTestModel(curTestDir)
+	p := NewTestTeaProgWithEventLoop(t, m)
+	panel := m.getFocusedFilePanel()
+	panel.panelMode = selectMode
+	panel.selected = []string{file1, file2}
+
+	p.SendKey(common.Hotkeys.BatchRename[0])
+	assert.Eventually(t, m.batchRenameModal.IsOpen, DefaultTestTimeout, DefaultTestTick)
+	p.SendKey(`(1|2)`)
+	p.Send(tea.KeyMsg{Type: tea.KeyTab})
+	p.SendKey(`{n}$1`)
+	p.Send(tea.KeyMsg{Type: tea.KeyEnter})
+
+	assert.Eventually(t, func() bool {
+		data, err := os.ReadFile(filepath.Join(curTestDir, "file11.txt"))
+		return err == nil && string(data) == "f1"
+	}, DefaultTestTimeout, DefaultTestTick, "Files never got renamed")
+	assert.False(t, m.batchRenameModal.IsOpen())
+	data, err := os.ReadFile(filepath.Join(curTestDir, "file22.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "f2", string(data))
+	assert.NoFileExists(t, file1)
+	assert.Empty(t, panel.selected)
+}
+
 func TestFileDelete(t *testing.T) {
 	if runtime.GOOS == utils.OsWindows {
 		t.Skip("Skipping for windows")
 	}
-	curTestDir := t.TempDir()
+	curTestDir := trashTestDir(t)
 	file1 := filepath.Join(curTestDir, "file1.txt")
 	file2 := filepath.Join(curTestDir, "file2.txt")
 
@@ -266,3 +315,187 @@ func TestFileDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestUndoRedo(t *testing.T) {
+	curTestDir := t.TempDir()
+	dir1 := filepath.Join(curTestDir, "dir1")
+	dir2 := filepath.Join(curTestDir, "dir2")
+	file1 := filepath.Join(dir1, "file1.txt")
+	utils.SetupDirectories(t, dir1, dir2)
+	utils.SetupFilesWithData(t, []byte("f1"), file1)
+
+	pathExists := func(path string) func() bool {
+		return func() bool {
+			_, err := os.Lstat(path)
+			return err == nil
+		}
+	}
+	pathMissing := func(path string) func() bool {
+		return func() bool {
+			_, err := os.Lstat(path)
+			return os.IsNotExist(err)
+		}
+	}
+
+	t.Run("Rename", func(t *testing.T) {
+		m := defaultTestModel(dir1)
+		p := NewTestTeaProgWithEventLoop(t, m)
+		setFilePanelSelectedItemByLocation(t, m.getFocusedFilePanel(), file1)
+		renamed := filepath.Join(dir1, "file1_new.txt")
+
+		p.SendKey(common.Hotkeys.FilePanelItemRename[0])
+		p.SendKey("_new")
+		p.Send(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Eventually(t, pathExists(renamed), DefaultTestTimeout, DefaultTestTick)
+
+		p.SendKey(common.Hotkeys.Undo[0])
+		assert.Eventually(t, pathExists(file1), DefaultTestTimeout, DefaultTestTick)
+		assert.NoFileExists(t, renamed)
+
+		p.SendKey(common.Hotkeys.Redo[0])
+		assert.Eventually(t, pathExists(renamed), DefaultTestTimeout, DefaultTestTick)
+		assert.NoFileExists(t, file1)
+
+		// Undoing must not overwrite an item created since
+		utils.SetupFilesWithData(t, []byte("other"), file1)
+		p.SendKey(common.Hotkeys.Undo[0])
+		assert.Eventually(t, m.notifyModel.IsOpen, DefaultTestTimeout, DefaultTestTick)
+		assert.Equal(t, common.UndoFailedTitle, m.notifyModel.GetTitle())
+		data, err := os.ReadFile(file1)
+		require.NoError(t, err)
+		assert.Equal(t, "other", string(data))
+		assert.FileExists(t, renamed)
+		require.NoError(t, os.Remove(file1))
+		require.NoError(t, os.Rename(renamed, file1))
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		m := defaultTestModel(dir2)
+		p := NewTestTeaProgWithEventLoop(t, m)
+		newDir := filepath.Join(dir2, "newDir")
+		newFile := filepath.Join(newDir, "newFile.txt")
+
+		p.SendKeyDirectly(common.Hotkeys.FilePanelItemCreate[0])
+		m.typingModal.textInput.SetValue(filepath.Join("newDir", "newFile.txt"))
+		p.Send(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Eventually(t, pathExists(newFile), DefaultTestTimeout, DefaultTestTick)
+
+		p.SendKey(common.Hotkeys.Undo[0])
+		assert.Eventually(t, pathMissing(newDir), DefaultTestTimeout, DefaultTestTick)
+
+		p.SendKey(common.Hotkeys.Redo[0])
+		assert.Eventually(t, pathExists(newFile), DefaultTestTimeout, DefaultTestTick)
+		require.NoError(t, os.RemoveAll(newDir))
+	})
+
+	t.Run("Copy", func(t *testing.T) {
+		m := defaultTestModel(dir1)
+		p := NewTestTeaProgWithEventLoop(t, m)
+		setFilePanelSelectedItemByLocation(t, m.getFocusedFilePanel(), file1)
+		copied := filepath.Join(dir2, "file1.txt")
+
+		p.SendKeyDirectly(common.Hotkeys.CopyItems[0])
+		m.updateCurrentFilePanelDir(dir2)
+		p.SendKey(common.Hotkeys.PasteItems[0])
+		assert.Eventually(t, pathExists(copied), DefaultTestTimeout, DefaultTestTick)
+
+		p.SendKey(common.Hotkeys.Undo[0])
+		assert.Eventually(t, pathMissing(copied), DefaultTestTimeout, DefaultTestTick)
+		assert.FileExists(t, file1)
+
+		p.SendKey(common.Hotkeys.Redo[0])
+		assert.Eventually(t, pathExists(copied), DefaultTestTimeout, DefaultTestTick)
+
+		// Undoing must not remove changes made to the copy since
+		require.NoError(t, os.WriteFile(copied, []byte("edited"), 0o644))
+		p.SendKey(common.Hotkeys.Undo[0])
+		assert.Eventually(t, m.notifyModel.IsOpen, DefaultTestTimeout, DefaultTestTick)
+		assert.Equal(t, common.UndoFailedTitle, m.notifyModel.GetTitle())
+		data, err := os.ReadFile(copied)
+		require.NoError(t, err)
+		assert.Equal(t, "edited", string(data))
+	})
+
+	t.Run("Permanent delete is refused", func(t *testing.T) {
+		m := defaultTestModel(dir2)
+		p := NewTestTeaProgWithEventLoop(t, m)
+		copied := filepath.Join(dir2, "file1.txt")
+		setFilePanelSelectedItemByLocation(t, m.getFocusedFilePanel(), copied)
+
+		p.SendKey(common.Hotkeys.PermanentlyDeleteItems[0])
+		assert.Eventually(t, m.notifyModel.IsOpen, DefaultTestTimeout, DefaultTestTick)
+		p.Send(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Eventually(t, pathMissing(copied), DefaultTestTimeout, DefaultTestTick)
+		// The delete is recorded after the file is gone
+		assert.Eventually(t, func() bool {
+			process, err := m.processBarModel.GetSelectedProcess()
+			return err == nil && process.State == processbar.Successful
+		}, DefaultTestTimeout, DefaultTestTick)
+
+		p.SendKey(common.Hotkeys.Undo[0])
+		assert.Eventually(t, func() bool {
+			return m.notifyModel.IsOpen() && m.notifyModel.GetTitle() == common.UndoFailedTitle
+		}, DefaultTestTimeout, DefaultTestTick)
+		assert.NoFileExists(t, copied)
+	})
+}
+
+func TestExtractEncryptedArchive(t *testing.T) {
+	curTestDir := t.TempDir()
+	archive := filepath.Join(curTestDir, "encrypted.zip")
+	data, err := os.ReadFile(filepath.Join("testdata", "encrypted_aes.zip"))
+	require.NoError(t, err)
+	utils.SetupFilesWithData(t, data, archive)
+
+	m := defaultTestModel(curTestDir)
+	p := NewTestTeaProgWithEventLoop(t, m)
+	p.SendKey(common.Hotkeys.ExtractFile[0])
+	assert.Eventually(t, m.passwordModal.IsOpen, DefaultTestTimeout, DefaultTestTick,
+		"Password should be asked for an encrypted archive")
+	assert.NoDirExists(t, filepath.Join(curTestDir, "dir"))
+
+	p.SendKey("wrong")
+	p.Send(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Eventually(t, func() bool {
+		return m.passwordModal.IsOpen() && strings.Contains(m.passwordModal.Render(), common.ArchiveWrongPasswordText)
+	}, DefaultTestTimeout, DefaultTestTick, "Password should be asked again after a wrong one")
+
+	p.SendKey("superfile")
+	p.Send(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(curTestDir, "dir", "file.txt"))
+		return err == nil
+	}, DefaultTestTimeout, DefaultTestTick, "Archive should be extracted with the right password")
+	assert.Equal(t, "superfile", m.archivePasswords[archive], "Password should be kept for the session")
+}
+
+func TestExtractToOtherPanel(t *testing.T) {
+	curTestDir := t.TempDir()
+	dir1 := filepath.Join(curTestDir, "dir1")
+	dir2 := filepath.Join(curTestDir, "dir2")
+	utils.SetupDirectories(t, dir1, dir2)
+	archive1 := filepath.Join(dir1, "first.zip")
+	archive2 := filepath.Join(dir1, "second.zip")
+	setupZipWithoutDirs(t, archive1, map[string]string{"first/file.txt": "first"})
+	setupZipWithoutDirs(t, archive2, map[string]string{"a.txt": "a", "b.txt": "b"})
+
+	t.Run("Without other panel", func(t *testing.T) {
+		m := defaultTestModel(dir1)
+		TeaUpdate(m, utils.TeaRuneKeyMsg(common.Hotkeys.ExtractFileToOtherPanel[0]))
+		assert.True(t, m.notifyModel.IsOpen())
+		assert.Equal(t, common.ExtractFailedTitle, m.notifyModel.GetTitle())
+	})
+
+	t.Run("Selected archives", func(t *testing.T) {
+		m := defaultTestModel(dir1, dir2)
+		p := NewTestTeaProgWithEventLoop(t, m)
+		setupPanelModeAndSelection(t, m, true, "", []string{archive1, archive2})
+		p.SendKey(common.Hotkeys.ExtractFileToOtherPanel[0])
+		assert.Eventually(t, func() bool {
+			_, err1 := os.Stat(filepath.Join(dir2, "first", "file.txt"))
+			_, err2 := os.Stat(filepath.Join(dir2, "second", "b.txt"))
+			return err1 == nil && err2 == nil
+		}, DefaultTestTimeout, DefaultTestTick, "Both archives should be extracted into the other panel")
+		assert.NoDirExists(t, filepath.Join(dir1, "first"))
+	})
+}
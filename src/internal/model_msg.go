@@ -1,10 +1,14 @@
 package internal
 
 import (
+	"context"
+	"errors"
 	"log/slog"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/yorukot/superfile/src/internal/common"
 	"github.com/yorukot/superfile/src/internal/ui/metadata"
 	"github.com/yorukot/superfile/src/internal/ui/notify"
 	"github.com/yorukot/superfile/src/internal/ui/processbar"
@@ -27,11 +31,14 @@ type PasteOperationMsg struct {
 	BaseMessage
 
 	state processbar.ProcessState
+	// Set when some items failed, so that they can be pasted again
+	failed *failedOperation
 }
 
-func NewPasteOperationMsg(state processbar.ProcessState, reqID int) PasteOperationMsg {
+func NewPasteOperationMsg(state processbar.ProcessState, failed *failedOperation, reqID int) PasteOperationMsg {
 	return PasteOperationMsg{
-		state: state,
+		state:  state,
+		failed: failed,
 		BaseMessage: BaseMessage{
 			reqID: reqID,
 		},
@@ -42,6 +49,148 @@ func (msg PasteOperationMsg) ApplyToModel(m *model) tea.Cmd {
 	if (msg.state == processbar.Failed || msg.state == processbar.Successful) && m.copyItems.cut {
 		m.copyItems.reset(false)
 	}
+	m.addFailedOperation(msg.failed)
+	return nil
+}
+
+// PastePreflightMsg is sent once the preflight of the pending paste is done. The paste
+// starts, or it is confirmed first, if its preflight found an issue, it overwrites
+// existing items, or it is large.
+type PastePreflightMsg struct {
+	BaseMessage
+
+	preflight pastePreflight
+	// Set if the preflight was cancelled
+	err error
+}
+
+func NewPastePreflightMsg(preflight pastePreflight, err error, reqID int) PastePreflightMsg {
+	return PastePreflightMsg{
+		preflight: preflight,
+		err:       err,
+		BaseMessage: BaseMessage{
+			reqID: reqID,
+		},
+	}
+}
+
+func (msg PastePreflightMsg) ApplyToModel(m *model) tea.Cmd {
+	if m.pendingPaste == nil {
+		slog.Error("Paste preflight done without a pending paste", "id", msg.reqID)
+		return nil
+	}
+	if msg.err != nil {
+		slog.Debug("Paste preflight stopped", "id", msg.reqID, "error", msg.err)
+		m.pendingPaste = nil
+		return nil
+	}
+	if msg.preflight.hasTotals {
+		m.pendingPaste.totals = &msg.preflight.totals
+	}
+	if !msg.preflight.needsConfirm(int64(common.Config.PasteConfirmSizeMB)*1024*1024,
+		common.Config.PasteConflictPolicy) {
+		return m.getPendingPasteCmd()
+	}
+	slog.Debug("Paste needs to be confirmed", "id", msg.reqID, "copy bytes", msg.preflight.copyBytes,
+		"free bytes", msg.preflight.freeBytes, "unreadable cnt", len(msg.preflight.unreadable),
+		"conflicts cnt", len(msg.preflight.conflicts))
+	content, lines := msg.preflight.summary()
+	m.notifyModel = notify.NewList(common.PastePreflightTitle, content, notify.PastePreflightAction, lines)
+	return nil
+}
+
+// RetryOperationMsg is sent once a paste or delete, run again from failuresModal, is
+// finished. Unlike the first run, it doesn't touch the clipboard or the selection.
+type RetryOperationMsg struct {
+	BaseMessage
+
+	state  processbar.ProcessState
+	failed *failedOperation
+}
+
+func NewRetryOperationMsg(state processbar.ProcessState, failed *failedOperation, reqID int) RetryOperationMsg {
+	return RetryOperationMsg{
+		state:  state,
+		failed: failed,
+		BaseMessage: BaseMessage{
+			reqID: reqID,
+		},
+	}
+}
+
+func (msg RetryOperationMsg) ApplyToModel(m *model) tea.Cmd {
+	m.addFailedOperation(msg.failed)
+	return nil
+}
+
+// ArchivePasswordMsg asks the password of an encrypted archive, when the extraction
+// needs one, or when the one it was given is wrong. The extraction continues from this
+// archive once the password is given.
+type ArchivePasswordMsg struct {
+	BaseMessage
+
+	req           extractRequest
+	wrongPassword bool
+}
+
+func NewArchivePasswordMsg(req extractRequest, wrongPassword bool, reqID int) ArchivePasswordMsg {
+	return ArchivePasswordMsg{
+		req:           req,
+		wrongPassword: wrongPassword,
+		BaseMessage: BaseMessage{
+			reqID: reqID,
+		},
+	}
+}
+
+func (msg ArchivePasswordMsg) ApplyToModel(m *model) tea.Cmd {
+	archive := msg.req.archives[0]
+	errMsg := ""
+	if msg.wrongPassword {
+		// It would be tried again on the next extraction otherwise
+		delete(m.archivePasswords, archiveSetName(archive))
+		errMsg = common.ArchiveWrongPasswordText
+	}
+	m.pendingExtract = &msg.req
+	m.passwordModal.Open(archive, errMsg)
+	return nil
+}
+
+type PermissionsOperationMsg struct {
+	BaseMessage
+
+	state processbar.ProcessState
+}
+
+func NewPermissionsOperationMsg(state processbar.ProcessState, reqID int) PermissionsOperationMsg {
+	return PermissionsOperationMsg{
+		state: state,
+		BaseMessage: BaseMessage{
+			reqID: reqID,
+		},
+	}
+}
+
+func (msg PermissionsOperationMsg) ApplyToModel(_ *model) tea.Cmd {
+	return nil
+}
+
+type LinkOperationMsg struct {
+	BaseMessage
+
+	state processbar.ProcessState
+}
+
+func NewLinkOperationMsg(state processbar.ProcessState, reqID int) LinkOperationMsg {
+	return LinkOperationMsg{
+		state: state,
+		BaseMessage: BaseMessage{
+			reqID: reqID,
+		},
+	}
+}
+
+func (msg LinkOperationMsg) ApplyToModel(_ *model) tea.Cmd {
 	return nil
 }
 
@@ -49,11 +198,14 @@ type DeleteOperationMsg struct {
 	BaseMessage
 
 	state processbar.ProcessState
+	// Set when some items failed, so that they can be deleted again
+	failed *failedOperation
 }
 
-func NewDeleteOperationMsg(state processbar.ProcessState, reqID int) DeleteOperationMsg {
+func NewDeleteOperationMsg(state processbar.ProcessState, failed *failedOperation, reqID int) DeleteOperationMsg {
 	return DeleteOperationMsg{
-		state: state,
+		state:  state,
+		failed: failed,
 		BaseMessage: BaseMessage{
 			reqID: reqID,
 		},
@@ -63,6 +215,67 @@ func NewDeleteOperationMsg(state processbar.ProcessState, reqID int) DeleteOpera
 func (msg DeleteOperationMsg) ApplyToModel(m *model) tea.Cmd {
 	// Remove selection
 	m.getFocusedFilePanel().resetSelected()
+	m.addFailedOperation(msg.failed)
+	return nil
+}
+
+type RestoreOperationMsg struct {
+	BaseMessage
+
+	state processbar.ProcessState
+}
+
+func NewRestoreOperationMsg(state processbar.ProcessState, reqID int) RestoreOperationMsg {
+	return RestoreOperationMsg{
+		state: state,
+		BaseMessage: BaseMessage{
+			reqID: reqID,
+		},
+	}
+}
+
+func (msg RestoreOperationMsg) ApplyToModel(m *model) tea.Cmd {
+	// Remove selection
+	m.getFocusedFilePanel().resetSelected()
+	return nil
+}
+
+type BatchRenameOperationMsg struct {
+	BaseMessage
+
+	state processbar.ProcessState
+}
+
+func NewBatchRenameOperationMsg(state processbar.ProcessState, reqID int) BatchRenameOperationMsg {
+	return BatchRenameOperationMsg{
+		state: state,
+		BaseMessage: BaseMessage{
+			reqID: reqID,
+		},
+	}
+}
+
+func (msg BatchRenameOperationMsg) ApplyToModel(_ *model) tea.Cmd {
+	return nil
+}
+
+type BulkRenameEditedMsg struct {
+	BaseMessage
+
+	err error
+}
+
+func NewBulkRenameEditedMsg(err error, reqID int) BulkRenameEditedMsg {
+	return BulkRenameEditedMsg{
+		err: err,
+		BaseMessage: BaseMessage{
+			reqID: reqID,
+		},
+	}
+}
+
+func (msg BulkRenameEditedMsg) ApplyToModel(m *model) tea.Cmd {
+	m.handleBulkRenameEdited(msg.err)
 	return nil
 }
 
@@ -77,6 +290,9 @@ func (msg ProcessBarUpdateMsg) ApplyToModel(m *model) tea.Cmd {
 	if err != nil {
 		slog.Error("Error applying processbar update", "error", err)
 	}
+	if p, finished := processbar.FinishedProcess(msg.pMsg); finished {
+		return tea.Batch(processCmdToTeaCmd(cmd), getCompletionNotificationCmd(p, m.output))
+	}
 	return processCmdToTeaCmd(cmd)
 }
 
@@ -198,3 +414,67 @@ func (msg FilePreviewUpdateMsg) ApplyToModel(m *model) tea.Cmd {
 	m.fileModel.filePreview.SetContent(msg.content)
 	return nil
 }
+
+type PasteConflictMsg struct {
+	BaseMessage
+
+	req pasteConflictReq
+}
+
+func NewPasteConflictMsg(req pasteConflictReq, reqID int) PasteConflictMsg {
+	return PasteConflictMsg{
+		req: req,
+		BaseMessage: BaseMessage{
+			reqID: reqID,
+		},
+	}
+}
+
+// The listener is re-armed only after the user answers, in answerPasteConflict(), or
+// once the conflict of a cancelled operation is dropped
+func (msg PasteConflictMsg) ApplyToModel(m *model) tea.Cmd {
+	return m.openPasteConflictModal(msg.req)
+}
+
+// ArchiveLoadedMsg is sent once the listing of an archive browsed by a file panel is
+// read, see getArchiveLoadCmd
+type ArchiveLoadedMsg struct {
+	BaseMessage
+
+	archivePath string
+	err         error
+}
+
+func NewArchiveLoadedMsg(archivePath string, err error, reqID int) ArchiveLoadedMsg {
+	return ArchiveLoadedMsg{
+		archivePath: archivePath,
+		err:         err,
+		BaseMessage: BaseMessage{
+			reqID: reqID,
+		},
+	}
+}
+
+func (msg ArchiveLoadedMsg) ApplyToModel(m *model) tea.Cmd {
+	if load, ok := m.archiveLoads[msg.archivePath]; ok && load.reqID == msg.reqID {
+		delete(m.archiveLoads, msg.archivePath)
+	}
+	// Cancelled as the file panels left the archive
+	if errors.Is(msg.err, context.Canceled) {
+		return nil
+	}
+	if msg.err != nil {
+		slog.Error("Error while reading archive", "archive", msg.archivePath, "error", msg.err)
+		m.leaveArchive(msg.archivePath)
+		m.notifyModel = notify.New(true, common.ArchiveOpenFailedTitle, msg.err.Error(), notify.NoAction)
+		return nil
+	}
+	// The file panels in the archive are read again right away
+	for i := range m.fileModel.filePanels {
+		panel := &m.fileModel.filePanels[i]
+		if archivePath, _, ok := splitArchivePath(panel.location); ok && archivePath == msg.archivePath {
+			panel.lastTimeGetElement = time.Time{}
+		}
+	}
+	return nil
+}
@@ -110,6 +110,10 @@ func (panel *filePanel) renderFooter(r *rendering.Renderer) {
 
 func (panel *filePanel) renderFileEntries(r *rendering.Renderer, mainPanelHeight, filePanelWidth int) {
 	if len(panel.element) == 0 {
+		if isArchiveLoading(panel.location) {
+			r.AddLines(common.FilePanelArchiveLoadingText)
+			return
+		}
 		r.AddLines(common.FilePanelNoneText)
 		return
 	}
@@ -141,16 +145,59 @@ func (panel *filePanel) renderFileEntries(r *rendering.Renderer, mainPanelHeight
 		// Calculate the actual prefix width for proper alignment
 		prefixWidth := lipgloss.Width(cursor+" ") + lipgloss.Width(selectBox)
 
+		nameWidth := filePanelWidth - prefixWidth
+		trashInfo := ""
+		if panel.element[i].trashInfo != nil {
+			trashInfo = renderTrashInfo(*panel.element[i].trashInfo, nameWidth/2)
+			nameWidth -= lipgloss.Width(trashInfo)
+		} else if panel.element[i].archiveInfo != nil {
+			trashInfo = renderArchiveInfo(panel.element[i].archiveInfo, nameWidth/2)
+			nameWidth -= lipgloss.Width(trashInfo)
+		}
+
 		renderedName := common.PrettierName(
 			panel.element[i].name,
-			filePanelWidth-prefixWidth,
+			nameWidth,
 			dirExists,
 			isSelected,
 			common.FilePanelBGColor,
 		)
 
-		r.AddLines(common.FilePanelCursorStyle.Render(cursor+" ") + selectBox + renderedName)
+		r.AddLines(common.FilePanelCursorStyle.Render(cursor+" ") + selectBox + renderedName + trashInfo)
+	}
+}
+
+// renderTrashInfo renders the deletion date and the original location of an item of
+// the trash view, in at most width cells. The location is truncated first.
+func renderTrashInfo(info trashInfo, width int) string {
+	date := info.deletionDate.Format(trashDateDisplayFormat)
+	if info.deletionDate.IsZero() {
+		date = "-"
+	}
+	// Separating spaces around the date
+	pathWidth := width - len(date) - 3
+	if pathWidth < minTrashPathWidth {
+		return ""
+	}
+	return common.FilePanelHintStyle.Render(" " + date + " " +
+		common.TruncateTextBeginning(info.originalPath, pathWidth, "...") + " ")
+}
+
+// renderArchiveInfo renders the size and the modification date of an item inside an
+// archive, in at most width cells. Directories only have a date.
+func renderArchiveInfo(info os.FileInfo, width int) string {
+	date := info.ModTime().Format(trashDateDisplayFormat)
+	if info.ModTime().IsZero() {
+		date = "-"
+	}
+	text := " " + date + " "
+	if !info.IsDir() {
+		text = " " + common.FormatFileSize(info.Size()) + text
+	}
+	if lipgloss.Width(text) > width {
+		return ""
 	}
+	return common.FilePanelHintStyle.Render(text)
 }
 
 func (panel *filePanel) getSortInfo() (string, string) {
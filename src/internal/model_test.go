@@ -56,6 +56,9 @@ func TestMain(m *testing.M) {
 		os.Exit(1)
 	}
 	defer cleanupTestDir()
+	// Keep the journal and the operation history of each test model in memory only
+	variable.SetJournalFile("")
+	variable.SetHistoryFile("")
 
 	flag.Parse()
 	if testing.Verbose() {
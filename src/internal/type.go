@@ -1,12 +1,22 @@
 package internal
 
 import (
+	"io"
+	"os"
 	"time"
 
 	zoxidelib "github.com/lazysegtree/go-zoxide"
 
+	"github.com/yorukot/superfile/src/internal/history"
+	"github.com/yorukot/superfile/src/internal/journal"
+	"github.com/yorukot/superfile/src/internal/ui/batchrename"
+	"github.com/yorukot/superfile/src/internal/ui/compress"
+	"github.com/yorukot/superfile/src/internal/ui/failures"
+	historyui "github.com/yorukot/superfile/src/internal/ui/history"
 	"github.com/yorukot/superfile/src/internal/ui/metadata"
 	"github.com/yorukot/superfile/src/internal/ui/notify"
+	"github.com/yorukot/superfile/src/internal/ui/password"
+	"github.com/yorukot/superfile/src/internal/ui/permissions"
 	"github.com/yorukot/superfile/src/internal/ui/processbar"
 	"github.com/yorukot/superfile/src/internal/ui/sidebar"
 
@@ -78,9 +88,42 @@ type model struct {
 	promptModal prompt.Model
 	zoxideModal zoxideui.Model
 
+	batchRenameModal batchrename.Model
+	permissionsModal permissions.Model
+	passwordModal    password.Model
+	compressModal    compress.Model
+	historyModal     historyui.Model
+	failuresModal    failures.Model
+
+	// Passwords of encrypted archives, by archiveSetName, kept in memory for the session
+	archivePasswords map[string]string
+	// Extraction waiting for the password asked in passwordModal
+	pendingExtract *extractRequest
+	// Archives browsed by the file panels whose listing is being read, by their path
+	archiveLoads map[string]archiveLoad
+
+	// Paste operations waiting for the user to resolve a name conflict send
+	// their request here. The request being shown in notifyModel is kept in
+	// pendingPasteConflict until it is answered
+	pasteConflictChan    chan pasteConflictReq
+	pendingPasteConflict *pasteConflictReq
+
+	// Pastes and deletes that failed, by their process ID, so that failuresModal
+	// can run their items again
+	failedOps map[string]failedOperation
+
+	// Paste being checked by its preflight, or waiting to be confirmed after it
+	pendingPaste *pasteRequest
+
+	// Bulk rename being edited in the editor, or waiting to be confirmed
+	bulkRename *bulkRenameState
+
 	// Zoxide client for directory tracking
 	zClient *zoxidelib.Client
 
+	// Output of the program, where completion notifications are written
+	output io.Writer
+
 	fileMetaData         metadata.Model
 	ioReqCnt             int
 	modelQuitState       modelQuitStateType
@@ -107,6 +150,11 @@ type model struct {
 
 	// whether usable trash directory exists or not
 	hasTrash bool
+
+	// Completed file operations, that can be undone and redone
+	journal *journal.Journal
+	// Finished processes of the process bar, shown in historyModal
+	history *history.History
 }
 
 // Modal
@@ -195,6 +243,10 @@ type element struct {
 	location  string
 	directory bool
 	metaData  [][2]string
+	// Only set for the items of the trash view
+	trashInfo *trashInfo
+	// Only set for the items inside an archive
+	archiveInfo os.FileInfo
 }
 
 /* FILE WINDOWS TYPE END*/
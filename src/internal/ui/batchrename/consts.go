@@ -0,0 +1,22 @@
+package batchrename
+
+const (
+	batchRenameHeadlineText = "Batch Rename"
+
+	BatchRenameMinWidth  = 30
+	BatchRenameMinHeight = 12
+
+	// Lines of the modal that are not preview rows : borders(2), fields(4),
+	// section dividers(2), status(1) and hints(1)
+	nonPreviewLines = 10
+
+	// Width of the field labels, like " Replace: "
+	labelWidth = 10
+
+	defaultDateFormat = "2006-01-02"
+
+	nextFieldKey = "tab"
+	prevFieldKey = "shift+tab"
+	prevCaseKey  = "left"
+	nextCaseKey  = "right"
+)
@@ -0,0 +1,210 @@
+package batchrename
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/yorukot/superfile/src/config/icon"
+	"github.com/yorukot/superfile/src/internal/common"
+)
+
+func DefaultModel(maxHeight int, width int) Model {
+	return GenerateModel(maxHeight, width)
+}
+
+func GenerateModel(maxHeight int, width int) Model {
+	m := Model{
+		headline: icon.Rename + icon.Space + batchRenameHeadlineText,
+		open:     false,
+	}
+	for i := range m.inputs {
+		m.inputs[i] = common.GeneratePromptTextInput()
+	}
+	m.inputs[findField].Placeholder = "regular expression, whole name if empty"
+	m.inputs[replaceField].Placeholder = "$1, {n:03}, {name}, {ext}, {date}, {size}"
+	m.inputs[extField].Placeholder = "unchanged"
+	m.SetMaxHeight(maxHeight)
+	m.SetWidth(width)
+	return m
+}
+
+func (m *Model) HandleUpdate(msg tea.Msg) (common.ModelAction, tea.Cmd) {
+	slog.Debug("batchrename.Model HandleUpdate()", "msg", msg,
+		"msgType", reflect.TypeOf(msg), "focus", m.focus)
+	var action common.ModelAction
+	action = common.NoAction{}
+	var cmd tea.Cmd
+	if !m.IsOpen() {
+		slog.Error("HandleUpdate called on closed batch rename")
+		return action, cmd
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case slices.Contains(common.Hotkeys.BatchRename, msg.String()) && m.justOpened:
+			// Ignore the key that just opened this modal to prevent it from appearing in text input
+			m.justOpened = false
+		case slices.Contains(common.Hotkeys.ConfirmTyping, msg.String()):
+			action = m.handleConfirm()
+		case slices.Contains(common.Hotkeys.CancelTyping, msg.String()):
+			m.Close()
+		case msg.String() == nextFieldKey:
+			m.setFocus((m.focus + 1) % fieldCnt)
+		case msg.String() == prevFieldKey:
+			m.setFocus((m.focus + fieldCnt - 1) % fieldCnt)
+		// Letters are typed in the text inputs, and not used to scroll
+		case slices.Contains(common.Hotkeys.ListUp, msg.String()) && !isKeyAlphaNum(msg):
+			m.scrollUp()
+		case slices.Contains(common.Hotkeys.ListDown, msg.String()) && !isKeyAlphaNum(msg):
+			m.scrollDown()
+		case m.focus == caseField:
+			m.handleCaseKey(msg.String())
+		default:
+			m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+			m.refresh()
+		}
+		m.justOpened = false
+	default:
+		// Non keypress updates like Cursor Blink
+		if m.focus != caseField {
+			m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+		}
+	}
+	return action, cmd
+}
+
+func (m *Model) handleCaseKey(key string) {
+	switch key {
+	case nextCaseKey, " ":
+		m.caseMode = (m.caseMode + 1) % caseModeCnt
+	case prevCaseKey:
+		m.caseMode = (m.caseMode + caseModeCnt - 1) % caseModeCnt
+	default:
+		return
+	}
+	m.refresh()
+}
+
+// Renames are only confirmed when all of them can be done. Otherwise, the modal
+// stays open with the reason shown.
+func (m *Model) handleConfirm() common.ModelAction {
+	if m.err != nil || m.conflictCnt() > 0 {
+		return common.NoAction{}
+	}
+	action := common.BatchRenameAction{}
+	for _, row := range m.rows {
+		if row.changed() {
+			action.Sources = append(action.Sources, row.Src)
+			action.Destinations = append(action.Destinations, row.Dst)
+		}
+	}
+	m.Close()
+	if len(action.Sources) == 0 {
+		return common.NoAction{}
+	}
+	return action
+}
+
+// Open the modal to rename paths. Their info is read once here, for the tokens
+func (m *Model) Open(paths []string) error {
+	if len(paths) == 0 {
+		return errors.New("no items to rename")
+	}
+	items := make([]Item, 0, len(paths))
+	for _, path := range paths {
+		info, err := os.Lstat(path)
+		if err != nil {
+			return fmt.Errorf("cannot read %s: %w", path, err)
+		}
+		items = append(items, Item{Path: path, Info: info})
+	}
+	m.items = items
+	m.open = true
+	m.justOpened = true
+	for i := range m.inputs {
+		m.inputs[i].SetValue("")
+	}
+	m.caseMode = CaseKeep
+	m.setFocus(findField)
+	m.refresh()
+	return nil
+}
+
+func (m *Model) Close() {
+	m.open = false
+	m.justOpened = false
+	for i := range m.inputs {
+		m.inputs[i].Blur()
+		m.inputs[i].SetValue("")
+	}
+	m.items = nil
+	m.rows = nil
+	m.err = nil
+	m.renderIndex = 0
+}
+
+func (m *Model) IsOpen() bool {
+	return m.open
+}
+
+func (m *Model) GetOptions() Options {
+	return Options{
+		Find:    m.inputs[findField].Value(),
+		Replace: m.inputs[replaceField].Value(),
+		Case:    m.caseMode,
+		Ext:     m.inputs[extField].Value(),
+	}
+}
+
+func (m *Model) GetRows() []Row {
+	return slices.Clone(m.rows)
+}
+
+func (m *Model) setFocus(f field) {
+	m.focus = f
+	for i := range m.inputs {
+		if field(i) == f {
+			_ = m.inputs[i].Focus()
+		} else {
+			m.inputs[i].Blur()
+		}
+	}
+}
+
+// refresh updates the preview for the current options
+func (m *Model) refresh() {
+	p, err := compilePattern(m.GetOptions())
+	m.err = err
+	if err != nil {
+		return
+	}
+	m.rows = buildPreview(m.items, p)
+	m.updateRenderIndex()
+}
+
+func (m *Model) conflictCnt() int {
+	cnt := 0
+	for _, row := range m.rows {
+		if row.Conflict != "" {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+func (m *Model) changedCnt() int {
+	cnt := 0
+	for _, row := range m.rows {
+		if row.changed() {
+			cnt++
+		}
+	}
+	return cnt
+}
@@ -0,0 +1,141 @@
+package batchrename
+
+import (
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/utils"
+)
+
+func TestMain(m *testing.M) {
+	common.Hotkeys.ConfirmTyping = []string{"enter"}
+	common.Hotkeys.CancelTyping = []string{"esc"}
+	common.Hotkeys.ListUp = []string{"up", "k"}
+	common.Hotkeys.ListDown = []string{"down", "j"}
+	common.Hotkeys.BatchRename = []string{"B"}
+	m.Run()
+}
+
+func setupTestModel(t *testing.T, paths ...string) Model {
+	t.Helper()
+	m := GenerateModel(50, 80)
+	require.NoError(t, m.Open(paths))
+	return m
+}
+
+func typeText(m *Model, text string) {
+	for _, r := range text {
+		m.HandleUpdate(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+func TestPreviewConflicts(t *testing.T) {
+	curTestDir := t.TempDir()
+	fileA := filepath.Join(curTestDir, "a.txt")
+	fileB := filepath.Join(curTestDir, "b.txt")
+	fileC := filepath.Join(curTestDir, "c.txt")
+	other := filepath.Join(curTestDir, "other.txt")
+	utils.SetupFiles(t, fileA, fileB, fileC, other)
+
+	testdata := []struct {
+		name      string
+		opts      Options
+		conflicts []string
+	}{
+		{
+			name:      "Same new name",
+			opts:      Options{Replace: "same"},
+			conflicts: []string{"duplicate name", "duplicate name", "duplicate name"},
+		},
+		{
+			name:      "Existing item that is not renamed",
+			opts:      Options{Find: "^a$", Replace: "other"},
+			conflicts: []string{"already exists", "", ""},
+		},
+		{
+			name:      "Item that is not renamed keeps its name",
+			opts:      Options{Find: "^a$", Replace: "b"},
+			conflicts: []string{"duplicate name", "", ""},
+		},
+		{
+			name:      "Shift names",
+			opts:      Options{Find: "^(a|b)$", Replace: "${1}x"},
+			conflicts: []string{"", "", ""},
+		},
+		{
+			name:      "Invalid name",
+			opts:      Options{Find: "^b$", Replace: "sub/b"},
+			conflicts: []string{"", "invalid name", ""},
+		},
+	}
+
+	for _, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := compilePattern(tt.opts)
+			require.NoError(t, err)
+			m := setupTestModel(t, fileA, fileB, fileC)
+			rows := buildPreview(m.items, p)
+			require.Len(t, rows, len(tt.conflicts))
+			for i, row := range rows {
+				assert.Equal(t, tt.conflicts[i], row.Conflict, "row %d : %v", i, row)
+			}
+		})
+	}
+}
+
+func TestHandleUpdate(t *testing.T) {
+	curTestDir := t.TempDir()
+	fileA := filepath.Join(curTestDir, "a.txt")
+	fileB := filepath.Join(curTestDir, "b.txt")
+	utils.SetupFiles(t, fileA, fileB)
+
+	t.Run("Live preview and confirm", func(t *testing.T) {
+		m := setupTestModel(t, fileA, fileB)
+		// Opening key is ignored
+		typeText(&m, "B")
+		assert.Empty(t, m.GetOptions().Find)
+
+		m.HandleUpdate(tea.KeyMsg{Type: tea.KeyTab})
+		typeText(&m, "file_{n:02}")
+		m.HandleUpdate(tea.KeyMsg{Type: tea.KeyTab})
+		m.HandleUpdate(tea.KeyMsg{Type: tea.KeyTab})
+		m.HandleUpdate(tea.KeyMsg{Type: tea.KeyRight})
+		m.HandleUpdate(tea.KeyMsg{Type: tea.KeyRight})
+		assert.Equal(t, Options{Replace: "file_{n:02}", Case: CaseUpper}, m.GetOptions())
+		assert.Equal(t, []Row{
+			{Src: fileA, Dst: filepath.Join(curTestDir, "FILE_01.txt")},
+			{Src: fileB, Dst: filepath.Join(curTestDir, "FILE_02.txt")},
+		}, m.GetRows())
+
+		action, _ := m.HandleUpdate(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Equal(t, common.BatchRenameAction{
+			Sources:      []string{fileA, fileB},
+			Destinations: []string{filepath.Join(curTestDir, "FILE_01.txt"), filepath.Join(curTestDir, "FILE_02.txt")},
+		}, action)
+		assert.False(t, m.IsOpen())
+	})
+
+	t.Run("Confirm is refused with conflicts", func(t *testing.T) {
+		m := setupTestModel(t, fileA, fileB)
+		m.HandleUpdate(tea.KeyMsg{Type: tea.KeyTab})
+		typeText(&m, "same")
+		action, _ := m.HandleUpdate(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Equal(t, common.NoAction{}, action)
+		assert.True(t, m.IsOpen())
+		assert.Contains(t, m.Render(), "2 conflicts")
+	})
+
+	t.Run("Confirm is refused with invalid regex", func(t *testing.T) {
+		m := setupTestModel(t, fileA, fileB)
+		typeText(&m, "(")
+		require.Error(t, m.err)
+		action, _ := m.HandleUpdate(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Equal(t, common.NoAction{}, action)
+		assert.True(t, m.IsOpen())
+	})
+}
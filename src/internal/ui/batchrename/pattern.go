@@ -0,0 +1,156 @@
+package batchrename
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/yorukot/superfile/src/internal/common"
+)
+
+// Tokens of the replacement, like {n:03}, {date} or {size}
+var tokenRegexp = regexp.MustCompile(`\{(n|name|ext|date|size|bytes)(?::([^}]*))?\}`)
+
+type pattern struct {
+	find    *regexp.Regexp
+	replace string
+	caseMod CaseMode
+	ext     string
+	// Whether the name changes at all, as an empty find and replace keeps it
+	rename bool
+}
+
+func compilePattern(opts Options) (*pattern, error) {
+	p := &pattern{
+		replace: opts.Replace,
+		caseMod: opts.Case,
+		ext:     strings.TrimPrefix(opts.Ext, "."),
+		rename:  opts.Find != "" || opts.Replace != "",
+	}
+	find := opts.Find
+	if find == "" {
+		// The replacement is the whole new name
+		find = "^.*$"
+	}
+	var err error
+	if p.find, err = regexp.Compile(find); err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %w", err)
+	}
+	for _, match := range tokenRegexp.FindAllStringSubmatch(opts.Replace, -1) {
+		if err := checkToken(match[1], match[2]); err != nil {
+			return nil, err
+		}
+	}
+	if strings.ContainsAny(p.ext, `/\`) {
+		return nil, errors.New("invalid extension")
+	}
+	return p, nil
+}
+
+func checkToken(token string, arg string) error {
+	switch token {
+	case "n":
+		if arg == "" {
+			return nil
+		}
+		if width, err := strconv.Atoi(arg); err != nil || width < 0 {
+			return fmt.Errorf("invalid counter format %q, expected a width like {n:03}", arg)
+		}
+	case "name", "ext", "size", "bytes":
+		if arg != "" {
+			return fmt.Errorf("{%s} does not take a format", token)
+		}
+	}
+	return nil
+}
+
+// newName returns the new name of item, which is the index-th item renamed
+func (p *pattern) newName(item Item, index int) string {
+	name := filepath.Base(item.Path)
+	stem, ext := splitName(name, item.Info.IsDir())
+	newStem := stem
+	if p.rename {
+		// '$' of tokens must not be taken as capture groups
+		replace := tokenRegexp.ReplaceAllStringFunc(p.replace, func(token string) string {
+			match := tokenRegexp.FindStringSubmatch(token)
+			return strings.ReplaceAll(expandToken(match[1], match[2], item, index, stem, ext), "$", "$$")
+		})
+		newStem = p.find.ReplaceAllString(stem, replace)
+	}
+	newStem = p.caseMod.apply(newStem)
+	if p.ext != "" && !item.Info.IsDir() {
+		ext = "." + p.ext
+	}
+	return newStem + ext
+}
+
+func expandToken(token string, arg string, item Item, index int, stem string, ext string) string {
+	switch token {
+	case "n":
+		width, _ := strconv.Atoi(arg)
+		return fmt.Sprintf("%0*d", width, index+1)
+	case "name":
+		return stem
+	case "ext":
+		return strings.TrimPrefix(ext, ".")
+	case "date":
+		if arg == "" {
+			arg = defaultDateFormat
+		}
+		return item.Info.ModTime().Format(arg)
+	case "size":
+		return strings.ReplaceAll(common.FormatFileSize(item.Info.Size()), " ", "")
+	case "bytes":
+		return strconv.FormatInt(item.Info.Size(), 10)
+	}
+	return ""
+}
+
+// splitName splits name into its stem and extension. Directories and dotfiles
+// like .bashrc have no extension.
+func splitName(name string, isDir bool) (string, string) {
+	if isDir {
+		return name, ""
+	}
+	ext := filepath.Ext(name)
+	if ext == name {
+		return name, ""
+	}
+	return strings.TrimSuffix(name, ext), ext
+}
+
+func (c CaseMode) apply(s string) string {
+	switch c {
+	case CaseLower:
+		return strings.ToLower(s)
+	case CaseUpper:
+		return strings.ToUpper(s)
+	case CaseTitle:
+		return toTitle(s)
+	case CaseKeep, caseModeCnt:
+	}
+	return s
+}
+
+// toTitle capitalizes the first letter of each word, and lowers the others
+func toTitle(s string) string {
+	runes := []rune(s)
+	wordStart := true
+	for i, r := range runes {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if wordStart {
+				runes[i] = unicode.ToUpper(r)
+			} else {
+				runes[i] = unicode.ToLower(r)
+			}
+			wordStart = false
+		} else {
+			wordStart = true
+		}
+	}
+	return string(runes)
+}
@@ -0,0 +1,127 @@
+package batchrename
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yorukot/superfile/src/internal/utils"
+)
+
+func TestNewName(t *testing.T) {
+	curTestDir := t.TempDir()
+	file := filepath.Join(curTestDir, "IMG_1234.jpeg")
+	dir := filepath.Join(curTestDir, "my.dir")
+	dotFile := filepath.Join(curTestDir, ".bashrc")
+	dollarDir := filepath.Join(curTestDir, "price$1")
+	utils.SetupFilesWithData(t, make([]byte, 2048), file)
+	utils.SetupFiles(t, dotFile)
+	utils.SetupDirectories(t, dir, dollarDir)
+	modTime := time.Date(2024, 5, 1, 10, 20, 30, 0, time.Local)
+	require.NoError(t, os.Chtimes(file, modTime, modTime))
+	itemOf := func(path string) Item {
+		info, err := os.Lstat(path)
+		require.NoError(t, err)
+		return Item{Path: path, Info: info}
+	}
+
+	testdata := []struct {
+		name     string
+		item     Item
+		index    int
+		opts     Options
+		expected string
+	}{
+		{
+			name:     "No options",
+			item:     itemOf(file),
+			opts:     Options{},
+			expected: "IMG_1234.jpeg",
+		},
+		{
+			name:     "Capture groups",
+			item:     itemOf(file),
+			opts:     Options{Find: `^IMG_(\d+)$`, Replace: "photo-$1"},
+			expected: "photo-1234.jpeg",
+		},
+		{
+			name:     "Whole name with padded counter",
+			item:     itemOf(file),
+			index:    6,
+			opts:     Options{Replace: "holiday_{n:03}"},
+			expected: "holiday_007.jpeg",
+		},
+		{
+			name:     "Name, date and size tokens",
+			item:     itemOf(file),
+			opts:     Options{Replace: "{date}_{name}_{bytes}_{size}"},
+			expected: "2024-05-01_IMG_1234_2048_2.00KiB.jpeg",
+		},
+		{
+			name:     "Date with layout",
+			item:     itemOf(file),
+			opts:     Options{Find: "^", Replace: "{date:20060102-1504} "},
+			expected: "20240501-1020 IMG_1234.jpeg",
+		},
+		{
+			name:     "Case and extension",
+			item:     itemOf(file),
+			opts:     Options{Case: CaseLower, Ext: ".jpg"},
+			expected: "img_1234.jpg",
+		},
+		{
+			name:     "Title case",
+			item:     itemOf(file),
+			opts:     Options{Replace: "my SUMMER photos", Case: CaseTitle},
+			expected: "My Summer Photos.jpeg",
+		},
+		{
+			name:     "Directories have no extension",
+			item:     itemOf(dir),
+			opts:     Options{Case: CaseUpper, Ext: "txt"},
+			expected: "MY.DIR",
+		},
+		{
+			name:     "Dotfiles have no extension",
+			item:     itemOf(dotFile),
+			opts:     Options{Replace: "{name}{ext}.bak"},
+			expected: ".bashrc.bak",
+		},
+		{
+			name:     "Dollar of tokens is not a capture group",
+			item:     itemOf(dollarDir),
+			opts:     Options{Find: "^", Replace: "{name}-"},
+			expected: "price$1-price$1",
+		},
+	}
+
+	for _, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := compilePattern(tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, p.newName(tt.item, tt.index))
+		})
+	}
+}
+
+func TestCompilePatternErrors(t *testing.T) {
+	testdata := []struct {
+		name string
+		opts Options
+	}{
+		{name: "Invalid regular expression", opts: Options{Find: "(unclosed"}},
+		{name: "Invalid counter", opts: Options{Replace: "{n:abc}"}},
+		{name: "Format for a token without one", opts: Options{Replace: "{size:x}"}},
+		{name: "Extension with separator", opts: Options{Ext: "a/b"}},
+	}
+	for _, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compilePattern(tt.opts)
+			assert.Error(t, err)
+		})
+	}
+}
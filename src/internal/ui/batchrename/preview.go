@@ -0,0 +1,63 @@
+package batchrename
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// buildPreview returns the renames of items with p. Renames that can't be done,
+// like to a name that another item already has, get a conflict.
+func buildPreview(items []Item, p *pattern) []Row {
+	rows := make([]Row, len(items))
+	// Sources of the items that are renamed, their current names will be free
+	srcs := make(map[string]bool, len(items))
+	for i, item := range items {
+		newName := p.newName(item, i)
+		rows[i] = Row{Src: item.Path, Dst: filepath.Join(filepath.Dir(item.Path), newName)}
+		if newName == "" || newName == "." || newName == ".." || strings.ContainsAny(newName, `/\`) {
+			rows[i].Conflict = "invalid name"
+		}
+		if rows[i].changed() {
+			srcs[item.Path] = true
+		}
+	}
+
+	// Unchanged items keep their names, so they are counted too
+	dstCnt := make(map[string]int, len(rows))
+	for _, row := range rows {
+		dstCnt[row.Dst]++
+	}
+	for i := range rows {
+		row := &rows[i]
+		if !row.changed() || row.Conflict != "" {
+			continue
+		}
+		if dstCnt[row.Dst] > 1 {
+			row.Conflict = "duplicate name"
+			continue
+		}
+		if !srcs[row.Dst] && existsAsOtherFile(row.Src, row.Dst) {
+			row.Conflict = "already exists"
+		}
+	}
+	return rows
+}
+
+// existsAsOtherFile reports whether dst exists, and is not src with a name that
+// differs only in case, on a case insensitive filesystem
+func existsAsOtherFile(src string, dst string) bool {
+	dstInfo, err := os.Lstat(dst)
+	if errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	if srcInfo, srcErr := os.Lstat(src); err == nil && srcErr == nil && os.SameFile(srcInfo, dstInfo) {
+		return false
+	}
+	return true
+}
+
+func (r Row) changed() bool {
+	return r.Src != r.Dst
+}
@@ -0,0 +1,66 @@
+package batchrename
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui"
+	"github.com/yorukot/superfile/src/internal/ui/rendering"
+)
+
+func (m *Model) Render() string {
+	r := ui.BatchRenameRenderer(m.maxHeight, m.width)
+	r.SetBorderTitle(m.headline)
+
+	labels := [inputCnt]string{"Find", "Replace", "Ext"}
+	for i := range m.inputs {
+		r.AddLines(m.renderLabel(field(i), labels[i]) + m.inputs[i].View())
+	}
+	r.AddLines(m.renderLabel(caseField, "Case") + "< " + m.caseMode.String() + " >")
+
+	r.AddSection()
+	m.renderPreview(r)
+
+	r.AddSection()
+	r.AddLines(m.renderStatus())
+	r.AddLines(common.ModalTitleStyle.Render(fmt.Sprintf(" %s/%s: switch field, %s/%s: change case",
+		nextFieldKey, prevFieldKey, prevCaseKey, nextCaseKey)))
+	return r.Render()
+}
+
+func (m *Model) renderLabel(f field, label string) string {
+	label = fmt.Sprintf(" %-*s", labelWidth-1, label+":")
+	if m.focus == f {
+		return common.ModalCursorStyle.Render(label)
+	}
+	return label
+}
+
+func (m *Model) renderPreview(r *rendering.Renderer) {
+	endIndex := min(m.renderIndex+m.visibleRowCnt(), len(m.rows))
+	// Available width: modal width - borders(2) - padding(1)
+	availableWidth := m.width - 3
+	for _, row := range m.rows[m.renderIndex:endIndex] {
+		line := filepath.Base(row.Src)
+		if row.changed() {
+			line += " → " + filepath.Base(row.Dst)
+		}
+		if row.Conflict != "" {
+			line = common.TruncateText(line+" ("+row.Conflict+")", availableWidth, "...")
+			r.AddLines(common.ModalErrorStyle.Render(" " + line))
+			continue
+		}
+		r.AddLines(" " + common.TruncateText(line, availableWidth, "..."))
+	}
+}
+
+func (m *Model) renderStatus() string {
+	if m.err != nil {
+		return common.ModalErrorStyle.Render(" " + m.err.Error())
+	}
+	if cnt := m.conflictCnt(); cnt > 0 {
+		return common.ModalErrorStyle.Render(fmt.Sprintf(" %d conflicts, cannot rename", cnt))
+	}
+	return fmt.Sprintf(" %d of %d items will be renamed", m.changedCnt(), len(m.rows))
+}
@@ -0,0 +1,96 @@
+package batchrename
+
+import (
+	"os"
+
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+// Model is the batch rename modal
+type Model struct {
+	// Configuration
+	headline string
+
+	// State
+	open       bool
+	justOpened bool // Flag to ignore the opening keystroke
+	items      []Item
+	inputs     [inputCnt]textinput.Model
+	caseMode   CaseMode
+	focus      field
+	// Preview of the renames with the current options
+	rows []Row
+	// Error in the current options, like an invalid regular expression
+	err         error
+	renderIndex int // Index of first visible row in the preview
+
+	// Dimensions, set by the main model as the terminal is resized
+	width     int
+	maxHeight int
+}
+
+// Item is an item to rename, with the info used for the date and size tokens
+type Item struct {
+	Path string
+	Info os.FileInfo
+}
+
+// Row is the preview of the rename of one item
+type Row struct {
+	Src string
+	Dst string
+	// Why the item can't be renamed to Dst, if it can't
+	Conflict string
+}
+
+// Options describe how the new names are built
+type Options struct {
+	// Regular expression matched against the name without its extension. If empty,
+	// Replace is the whole new name.
+	Find string
+	// Replacement of the matches, with capture groups like $1 and tokens like {n:03}
+	Replace string
+	Case    CaseMode
+	// New extension of the files, if not empty
+	Ext string
+}
+
+// CaseMode is a change of case applied to the new names
+type CaseMode int
+
+const (
+	CaseKeep CaseMode = iota
+	CaseLower
+	CaseUpper
+	CaseTitle
+	caseModeCnt
+)
+
+func (c CaseMode) String() string {
+	switch c {
+	case CaseKeep:
+		return "Keep"
+	case CaseLower:
+		return "lowercase"
+	case CaseUpper:
+		return "UPPERCASE"
+	case CaseTitle:
+		return "Title Case"
+	case caseModeCnt:
+	}
+	return "Unknown"
+}
+
+type field int
+
+const (
+	findField field = iota
+	replaceField
+	extField
+	// Not a text input, its value is changed with left and right
+	caseField
+	fieldCnt
+)
+
+// Number of fields that are text inputs
+const inputCnt = int(caseField)
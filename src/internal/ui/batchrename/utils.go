@@ -0,0 +1,67 @@
+package batchrename
+
+import (
+	"log/slog"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func (m *Model) GetWidth() int {
+	return m.width
+}
+
+func (m *Model) GetMaxHeight() int {
+	return m.maxHeight
+}
+
+func (m *Model) SetWidth(width int) {
+	if width < BatchRenameMinWidth {
+		slog.Warn("Batch rename initialized with too less width", "width", width)
+		width = BatchRenameMinWidth
+	}
+	m.width = width
+	// Excluding borders(2), label, and one extra character that is appended
+	// by textInput.View()
+	for i := range m.inputs {
+		m.inputs[i].Width = width - 2 - labelWidth - 1
+	}
+}
+
+func (m *Model) SetMaxHeight(maxHeight int) {
+	if maxHeight < BatchRenameMinHeight {
+		slog.Warn("Batch rename initialized with too less maxHeight", "maxHeight", maxHeight)
+		maxHeight = BatchRenameMinHeight
+	}
+	m.maxHeight = maxHeight
+	m.updateRenderIndex()
+}
+
+func (m *Model) visibleRowCnt() int {
+	return m.maxHeight - nonPreviewLines
+}
+
+func (m *Model) scrollUp() {
+	if m.renderIndex > 0 {
+		m.renderIndex--
+	}
+}
+
+func (m *Model) scrollDown() {
+	if m.renderIndex < len(m.rows)-m.visibleRowCnt() {
+		m.renderIndex++
+	}
+}
+
+// Keep the rows visible after the preview or the height changes
+func (m *Model) updateRenderIndex() {
+	m.renderIndex = max(0, min(m.renderIndex, len(m.rows)-m.visibleRowCnt()))
+}
+
+func isKeyAlphaNum(msg tea.KeyMsg) bool {
+	r := []rune(msg.String())
+	if len(r) != 1 {
+		return false
+	}
+	return unicode.IsLetter(r[0]) || unicode.IsNumber(r[0])
+}
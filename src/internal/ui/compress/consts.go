@@ -0,0 +1,28 @@
+package compress
+
+import "time"
+
+const (
+	compressHeadlineText = "Create archive"
+
+	CompressMinWidth = 50
+	// Borders, the fields, the estimate, the status and the hint
+	CompressHeight = 12
+
+	// Width of the field labels, like " Format: "
+	labelWidth = 14
+
+	// Long enough for most paths
+	destCharLimit = 1024
+
+	nextFieldKey = "tab"
+	prevFieldKey = "shift+tab"
+	leftKey      = "left"
+	rightKey     = "right"
+	toggleKey    = " "
+
+	maxLevel = 9
+
+	// The estimate waits for the exclude patterns to stop changing before walking the items
+	estimateDelay = 300 * time.Millisecond
+)
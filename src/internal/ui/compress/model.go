@@ -0,0 +1,296 @@
+package compress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/yorukot/superfile/src/config/icon"
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/utils"
+)
+
+// DefaultModel returns the compress modal, estimating the size of the items with countFn
+func DefaultModel(width int, countFn CountFunc) Model {
+	return GenerateModel(width, countFn)
+}
+
+func GenerateModel(width int, countFn CountFunc) Model {
+	m := Model{
+		headline:     icon.CompressFile + icon.Space + compressHeadlineText,
+		countFn:      countFn,
+		open:         false,
+		nameInput:    common.GeneratePromptTextInput(),
+		destInput:    common.GeneratePromptTextInput(),
+		excludeInput: common.GeneratePromptTextInput(),
+	}
+	m.destInput.CharLimit = destCharLimit
+	m.excludeInput.Placeholder = ".git, node_modules, *.log"
+	m.SetWidth(width)
+	return m
+}
+
+func (m *Model) HandleUpdate(msg tea.Msg) (common.ModelAction, tea.Cmd) {
+	slog.Debug("compress.Model HandleUpdate()", "msg", msg,
+		"msgType", reflect.TypeOf(msg), "focus", m.focus)
+	var action common.ModelAction
+	action = common.NoAction{}
+	var cmd tea.Cmd
+	if !m.IsOpen() {
+		slog.Error("HandleUpdate called on closed compress modal")
+		return action, cmd
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case slices.Contains(common.Hotkeys.CompressFileWithFormat, msg.String()) && m.justOpened:
+			// Ignore the key that just opened this modal to prevent it from appearing in text input
+		case slices.Contains(common.Hotkeys.ConfirmTyping, msg.String()):
+			action = m.handleConfirm()
+		case slices.Contains(common.Hotkeys.CancelTyping, msg.String()):
+			m.Close()
+		case msg.String() == nextFieldKey:
+			m.setFocus((m.focus + 1) % fieldCnt)
+		case msg.String() == prevFieldKey:
+			m.setFocus((m.focus + fieldCnt - 1) % fieldCnt)
+		default:
+			cmd = m.handleFieldKey(msg)
+		}
+		m.justOpened = false
+	default:
+		// Non keypress updates like Cursor Blink
+		if input := m.focusedInput(); input != nil {
+			*input, cmd = input.Update(msg)
+		}
+	}
+	return action, cmd
+}
+
+func (m *Model) handleFieldKey(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	m.err = nil
+	switch m.focus {
+	case nameField:
+		m.nameInput, cmd = m.nameInput.Update(msg)
+	case destField:
+		m.destInput, cmd = m.destInput.Update(msg)
+	case formatField:
+		switch msg.String() {
+		case rightKey, toggleKey:
+			m.format = (m.format + 1) % len(common.CompressFormats)
+		case leftKey:
+			m.format = (m.format + len(common.CompressFormats) - 1) % len(common.CompressFormats)
+		}
+	case levelField:
+		switch msg.String() {
+		case rightKey, toggleKey:
+			m.level = (m.level + 1) % (maxLevel + 1)
+		case leftKey:
+			m.level = (m.level + maxLevel) % (maxLevel + 1)
+		}
+	case storeOnlyField:
+		if msg.String() == toggleKey {
+			m.storeOnly = !m.storeOnly
+		}
+	case excludeField:
+		prevValue := m.excludeInput.Value()
+		m.excludeInput, cmd = m.excludeInput.Update(msg)
+		if m.excludeInput.Value() != prevValue {
+			cmd = tea.Batch(cmd, m.getEstimateCmd(estimateDelay))
+		}
+	case fieldCnt:
+	}
+	return cmd
+}
+
+// An invalid field keeps the modal open, with its error shown in the status line
+func (m *Model) handleConfirm() common.ModelAction {
+	action, err := m.getAction()
+	if err != nil {
+		m.err = err
+		return common.NoAction{}
+	}
+	m.Close()
+	return action
+}
+
+func (m *Model) getAction() (common.CreateArchiveAction, error) {
+	name := strings.TrimSpace(m.nameInput.Value())
+	if name == "" {
+		return common.CreateArchiveAction{}, errors.New("the name can't be empty")
+	}
+	if strings.ContainsAny(name, `/\`) {
+		return common.CreateArchiveAction{}, errors.New("the name can't contain a path separator")
+	}
+	dest := m.getDestination()
+	if info, err := os.Stat(dest); err != nil || !info.IsDir() {
+		return common.CreateArchiveAction{}, fmt.Errorf("%s is not a directory", dest)
+	}
+	excludes, err := utils.ParseExcludePatterns(m.excludeInput.Value())
+	if err != nil {
+		return common.CreateArchiveAction{}, err
+	}
+	target := filepath.Join(dest, name+"."+m.getFormat())
+	if _, err = os.Lstat(target); err == nil {
+		return common.CreateArchiveAction{}, fmt.Errorf("%s already exists", filepath.Base(target))
+	}
+	return common.CreateArchiveAction{
+		Items:     slices.Clone(m.items),
+		Target:    target,
+		Format:    m.getFormat(),
+		Level:     m.level,
+		StoreOnly: m.storeOnly,
+		Excludes:  excludes,
+	}, nil
+}
+
+// getDestination returns the destination directory. Relative paths are relative to the
+// directory of the items.
+func (m *Model) getDestination() string {
+	return utils.ResolveAbsPath(filepath.Dir(m.items[0]), strings.TrimSpace(m.destInput.Value()))
+}
+
+// getFormat returns the format of the archive. Tar archives that only store their
+// items are not compressed at all.
+func (m *Model) getFormat() string {
+	format := common.CompressFormats[m.format]
+	if m.storeOnly && format != common.CompressFormatZip {
+		return common.CompressFormatTar
+	}
+	return format
+}
+
+// Open the modal to archive items into dest, with format picked at first. It returns
+// the command estimating the size of the items.
+func (m *Model) Open(items []string, dest string, format string) (tea.Cmd, error) {
+	if len(items) == 0 {
+		return nil, errors.New("no items to compress")
+	}
+	m.items = slices.Clone(items)
+	m.open = true
+	m.justOpened = true
+	base := filepath.Base(items[0])
+	m.nameInput.SetValue(strings.TrimSuffix(base, filepath.Ext(base)))
+	m.destInput.SetValue(dest)
+	m.excludeInput.SetValue("")
+	m.format = max(slices.Index(common.CompressFormats, format), 0)
+	m.level = 0
+	m.storeOnly = false
+	m.err = nil
+	m.setFocus(nameField)
+	return m.getEstimateCmd(0), nil
+}
+
+func (m *Model) Close() {
+	m.open = false
+	m.justOpened = false
+	m.items = nil
+	m.setFocus(nameField)
+	m.nameInput.SetValue("")
+	m.destInput.SetValue("")
+	m.excludeInput.SetValue("")
+	// Results of estimates still running are ignored
+	m.reqCnt++
+	m.stopEstimate()
+}
+
+func (m *Model) IsOpen() bool {
+	return m.open
+}
+
+// getEstimateCmd returns the command estimating the count and size of the files that
+// are archived, with the current exclude patterns. The estimate running is cancelled,
+// and the new one starts after delay, unless it is cancelled by then.
+func (m *Model) getEstimateCmd(delay time.Duration) tea.Cmd {
+	m.stopEstimate()
+	m.reqCnt++
+	reqID := m.reqCnt
+	m.estimate = estimate{}
+	excludes, err := utils.ParseExcludePatterns(m.excludeInput.Value())
+	if err != nil {
+		m.estimate = estimate{done: true, err: err}
+		return nil
+	}
+	items := m.items
+	countFn := m.countFn
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelEstimate = cancel
+
+	slog.Debug("Submitting compress estimate request", "items cnt", len(items), "id", reqID, "delay", delay)
+	return func() tea.Msg {
+		select {
+		case <-ctx.Done():
+			return NewEstimateMsg(0, 0, ctx.Err(), reqID)
+		case <-time.After(delay):
+		}
+		files, bytes, err := estimateSize(ctx, countFn, items, excludes)
+		return NewEstimateMsg(files, bytes, err, reqID)
+	}
+}
+
+func (m *Model) stopEstimate() {
+	if m.cancelEstimate != nil {
+		m.cancelEstimate()
+		m.cancelEstimate = nil
+	}
+}
+
+// Apply updates the compress modal with the estimate, unless a newer one was requested
+func (msg EstimateMsg) Apply(m *Model) tea.Cmd {
+	if msg.reqID != m.reqCnt {
+		slog.Debug("Ignoring stale compress estimate", "id", msg.reqID, "current id", m.reqCnt)
+		return nil
+	}
+	m.estimate = msg.estimate
+	m.stopEstimate()
+	return nil
+}
+
+// estimateSize returns the count and size of the files in items, as counted by countFn
+func estimateSize(ctx context.Context, countFn CountFunc, items []string, excludes []string) (int, int64, error) {
+	files := 0
+	var size int64
+	for _, item := range items {
+		itemFiles, itemSize, err := countFn(ctx, item, excludes)
+		files += itemFiles
+		size += itemSize
+		if err != nil {
+			return files, size, err
+		}
+	}
+	return files, size, nil
+}
+
+func (m *Model) setFocus(f field) {
+	m.focus = f
+	for _, input := range []*textinput.Model{&m.nameInput, &m.destInput, &m.excludeInput} {
+		input.Blur()
+	}
+	if input := m.focusedInput(); input != nil {
+		_ = input.Focus()
+	}
+}
+
+func (m *Model) focusedInput() *textinput.Model {
+	switch m.focus {
+	case nameField:
+		return &m.nameInput
+	case destField:
+		return &m.destInput
+	case excludeField:
+		return &m.excludeInput
+	case formatField, levelField, storeOnlyField, fieldCnt:
+	}
+	return nil
+}
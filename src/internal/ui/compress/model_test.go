@@ -0,0 +1,196 @@
+package compress
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/utils"
+)
+
+func TestMain(m *testing.M) {
+	common.Hotkeys.ConfirmTyping = []string{"enter"}
+	common.Hotkeys.CancelTyping = []string{"esc"}
+	common.Hotkeys.CompressFileWithFormat = []string{"alt+a"}
+	m.Run()
+}
+
+func typeText(m *Model, text string) {
+	for _, r := range text {
+		m.HandleUpdate(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+func pressKey(m *Model, key tea.KeyType, times int) {
+	for range times {
+		m.HandleUpdate(tea.KeyMsg{Type: key})
+	}
+}
+
+// countItem counts each item as one file of 5 bytes
+func countItem(ctx context.Context, _ string, _ []string) (int, int64, error) {
+	return 1, 5, ctx.Err()
+}
+
+func setupItems(t *testing.T) (string, string) {
+	t.Helper()
+	curTestDir := t.TempDir()
+	srcDir := filepath.Join(curTestDir, "project")
+	utils.SetupDirectories(t, filepath.Join(srcDir, ".git"), filepath.Join(srcDir, "node_modules"))
+	utils.SetupFilesWithData(t, []byte("0123456789"), filepath.Join(srcDir, "main.go"))
+	utils.SetupFilesWithData(t, []byte("01234"), filepath.Join(srcDir, "debug.log"))
+	utils.SetupFilesWithData(t, []byte("0123456789"), filepath.Join(srcDir, ".git", "HEAD"))
+	utils.SetupFilesWithData(t, []byte("0123456789"), filepath.Join(srcDir, "node_modules", "lib.js"))
+	return curTestDir, srcDir
+}
+
+func TestCompressModal(t *testing.T) {
+	curTestDir, srcDir := setupItems(t)
+
+	t.Run("Confirm returns the settings", func(t *testing.T) {
+		m := DefaultModel(CompressMinWidth, countItem)
+		_, err := m.Open([]string{srcDir}, curTestDir, common.CompressFormatZip)
+		require.NoError(t, err)
+		m.HandleUpdate(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("alt+a")})
+		assert.Equal(t, "project", m.nameInput.Value(), "the opening key should be ignored")
+
+		typeText(&m, "-backup")
+		// Format, from zip to tar.gz
+		pressKey(&m, tea.KeyTab, 2)
+		pressKey(&m, tea.KeyRight, 2)
+		// Level
+		pressKey(&m, tea.KeyTab, 1)
+		pressKey(&m, tea.KeyLeft, 1)
+		// Exclude patterns
+		pressKey(&m, tea.KeyTab, 2)
+		typeText(&m, ".git, node_modules")
+
+		action, _ := m.HandleUpdate(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Equal(t, common.CreateArchiveAction{
+			Items:    []string{srcDir},
+			Target:   filepath.Join(curTestDir, "project-backup.tar.gz"),
+			Format:   common.CompressFormatTarGz,
+			Level:    9,
+			Excludes: []string{".git", "node_modules"},
+		}, action)
+		assert.False(t, m.IsOpen())
+	})
+
+	t.Run("Store only tar archives are not compressed", func(t *testing.T) {
+		m := DefaultModel(CompressMinWidth, countItem)
+		_, err := m.Open([]string{srcDir}, curTestDir, common.CompressFormatTarXz)
+		require.NoError(t, err)
+		pressKey(&m, tea.KeyShiftTab, 2)
+		m.HandleUpdate(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+		require.True(t, m.storeOnly)
+
+		action, _ := m.HandleUpdate(tea.KeyMsg{Type: tea.KeyEnter})
+		createAction, ok := action.(common.CreateArchiveAction)
+		require.True(t, ok)
+		assert.Equal(t, common.CompressFormatTar, createAction.Format)
+		assert.Equal(t, filepath.Join(curTestDir, "project.tar"), createAction.Target)
+	})
+
+	testdata := []struct {
+		name string
+		dest string
+		text string
+	}{
+		{name: "Existing archive", dest: curTestDir, text: ""},
+		{name: "Missing destination", dest: filepath.Join(curTestDir, "missing"), text: "-new"},
+		{name: "Path in name", dest: curTestDir, text: "/sub"},
+	}
+	utils.SetupFiles(t, filepath.Join(curTestDir, "project.zip"))
+	for _, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			m := DefaultModel(CompressMinWidth, countItem)
+			_, err := m.Open([]string{srcDir}, tt.dest, common.CompressFormatZip)
+			require.NoError(t, err)
+			typeText(&m, tt.text)
+			action, _ := m.HandleUpdate(tea.KeyMsg{Type: tea.KeyEnter})
+			assert.Equal(t, common.NoAction{}, action)
+			assert.True(t, m.IsOpen())
+			assert.Error(t, m.err)
+		})
+	}
+}
+
+func TestCompressEstimate(t *testing.T) {
+	_, srcDir := setupItems(t)
+
+	t.Run("Items are summed", func(t *testing.T) {
+		files, size, err := estimateSize(context.Background(), countItem, []string{srcDir, srcDir}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 2, files)
+		assert.Equal(t, int64(10), size)
+	})
+
+	t.Run("Exclude patterns are passed", func(t *testing.T) {
+		var excludes []string
+		m := DefaultModel(CompressMinWidth, func(_ context.Context, _ string, e []string) (int, int64, error) {
+			excludes = e
+			return 0, 0, nil
+		})
+		_, err := m.Open([]string{srcDir}, filepath.Dir(srcDir), common.CompressFormatZip)
+		require.NoError(t, err)
+		m.excludeInput.SetValue(".git, *.log")
+		msg := m.getEstimateCmd(0)()
+		assert.Equal(t, []string{".git", "*.log"}, excludes)
+		msg.(EstimateMsg).Apply(&m)
+		assert.True(t, m.estimate.done)
+	})
+
+	t.Run("Estimates are debounced", func(t *testing.T) {
+		var calls atomic.Int32
+		m := DefaultModel(CompressMinWidth, func(ctx context.Context, src string, e []string) (int, int64, error) {
+			calls.Add(1)
+			return countItem(ctx, src, e)
+		})
+		_, err := m.Open([]string{srcDir}, filepath.Dir(srcDir), common.CompressFormatZip)
+		require.NoError(t, err)
+		first := m.getEstimateCmd(estimateDelay)
+		second := m.getEstimateCmd(estimateDelay)
+
+		msg, ok := first().(EstimateMsg)
+		require.True(t, ok)
+		require.ErrorIs(t, msg.estimate.err, context.Canceled)
+		assert.Equal(t, int32(0), calls.Load(), "the replaced estimate shouldn't count the items")
+
+		msg, ok = second().(EstimateMsg)
+		require.True(t, ok)
+		msg.Apply(&m)
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Equal(t, estimate{done: true, files: 1, bytes: 5}, m.estimate)
+	})
+
+	t.Run("Closing cancels the estimate", func(t *testing.T) {
+		m := DefaultModel(CompressMinWidth, countItem)
+		_, err := m.Open([]string{srcDir}, filepath.Dir(srcDir), common.CompressFormatZip)
+		require.NoError(t, err)
+		cmd := m.getEstimateCmd(estimateDelay)
+		m.Close()
+		msg, ok := cmd().(EstimateMsg)
+		require.True(t, ok)
+		require.ErrorIs(t, msg.estimate.err, context.Canceled)
+	})
+
+	t.Run("Stale estimates are ignored", func(t *testing.T) {
+		m := DefaultModel(CompressMinWidth, countItem)
+		_, err := m.Open([]string{srcDir}, filepath.Dir(srcDir), common.CompressFormatZip)
+		require.NoError(t, err)
+		staleID := m.reqCnt
+		m.getEstimateCmd(0)
+		NewEstimateMsg(1, 1, nil, staleID).Apply(&m)
+		assert.False(t, m.estimate.done)
+		NewEstimateMsg(2, 20, errors.New("failed"), m.reqCnt).Apply(&m)
+		assert.True(t, m.estimate.done)
+		assert.Contains(t, m.Render(), "Cannot estimate size")
+	})
+}
@@ -0,0 +1,72 @@
+package compress
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui"
+)
+
+func (m *Model) Render() string {
+	r := ui.CompressRenderer(CompressHeight, m.width)
+	r.SetBorderTitle(m.headline)
+
+	r.AddLines(m.renderLabel(nameField, "Name") + m.nameInput.View())
+	r.AddLines(m.renderLabel(destField, "Destination") + m.destInput.View())
+	r.AddLines(m.renderLabel(formatField, "Format") + "< " + common.CompressFormats[m.format] + " >")
+	r.AddLines(m.renderLabel(levelField, "Level") + m.renderLevel())
+	r.AddLines(m.renderLabel(storeOnlyField, "Store only") + checkbox(m.storeOnly))
+	r.AddLines(m.renderLabel(excludeField, "Exclude") + m.excludeInput.View())
+
+	r.AddSection()
+	r.AddLines(m.renderEstimate())
+	r.AddLines(m.renderStatus())
+	r.AddLines(common.ModalTitleStyle.Render(fmt.Sprintf(" %s/%s: switch field, space: toggle",
+		nextFieldKey, prevFieldKey)))
+	return r.Render()
+}
+
+func (m *Model) renderLabel(f field, label string) string {
+	label = fmt.Sprintf(" %-*s", labelWidth-1, label+":")
+	if m.focus == f {
+		return common.ModalCursorStyle.Render(label)
+	}
+	return label
+}
+
+func (m *Model) renderLevel() string {
+	if m.storeOnly {
+		return "no compression"
+	}
+	level := "default"
+	if m.level != 0 {
+		level = strconv.Itoa(m.level)
+	}
+	return "< " + level + " >"
+}
+
+func (m *Model) renderEstimate() string {
+	switch {
+	case !m.estimate.done:
+		return " Estimating size..."
+	case m.estimate.err != nil:
+		return common.ModalErrorStyle.Render(" Cannot estimate size: " + m.estimate.err.Error())
+	}
+	return fmt.Sprintf(" %d files, %s to archive", m.estimate.files, common.FormatFileSize(m.estimate.bytes))
+}
+
+func (m *Model) renderStatus() string {
+	if m.err != nil {
+		return common.ModalErrorStyle.Render(" " + m.err.Error())
+	}
+	name := m.nameInput.Value() + "." + m.getFormat()
+	return " " + common.TruncateText("Creates "+name, m.width-3, "...")
+}
+
+func checkbox(checked bool) string {
+	if checked {
+		return "[x]"
+	}
+	return "[ ]"
+}
@@ -0,0 +1,82 @@
+package compress
+
+import (
+	"context"
+
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+// CountFunc returns the count and size of the files archived from src, leaving out the
+// ones matched by excludes
+type CountFunc func(ctx context.Context, src string, excludes []string) (int, int64, error)
+
+// Model is the modal creating an archive
+type Model struct {
+	// Configuration
+	headline string
+	countFn  CountFunc
+
+	// State
+	open       bool
+	justOpened bool // Flag to ignore the opening keystroke
+	items      []string
+	focus      field
+
+	nameInput    textinput.Model
+	destInput    textinput.Model
+	excludeInput textinput.Model
+	// Index of the format in common.CompressFormats
+	format int
+	// Compression level from 1 to 9, 0 for the default level of the format
+	level     int
+	storeOnly bool
+
+	// Validation error, shown once the creation is confirmed
+	err error
+
+	// Estimated size of the items that are archived
+	estimate estimate
+	// Request tracking for async estimates
+	reqCnt int
+	// Cancels the estimate that is running, if any
+	cancelEstimate context.CancelFunc
+
+	// Dimensions, set by the main model as the terminal is resized
+	width int
+}
+
+type estimate struct {
+	done  bool
+	files int
+	bytes int64
+	err   error
+}
+
+// EstimateMsg is the result of an async estimate of the size of the archived items
+type EstimateMsg struct {
+	estimate estimate
+	reqID    int
+}
+
+func NewEstimateMsg(files int, bytes int64, err error, reqID int) EstimateMsg {
+	return EstimateMsg{
+		estimate: estimate{done: true, files: files, bytes: bytes, err: err},
+		reqID:    reqID,
+	}
+}
+
+func (msg EstimateMsg) GetReqID() int {
+	return msg.reqID
+}
+
+type field int
+
+const (
+	nameField field = iota
+	destField
+	formatField
+	levelField
+	storeOnlyField
+	excludeField
+	fieldCnt
+)
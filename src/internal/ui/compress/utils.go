@@ -0,0 +1,25 @@
+package compress
+
+import "log/slog"
+
+func (m *Model) GetWidth() int {
+	return m.width
+}
+
+func (m *Model) GetHeight() int {
+	return CompressHeight
+}
+
+func (m *Model) SetWidth(width int) {
+	if width < CompressMinWidth {
+		slog.Warn("Compress modal initialized with too less width", "width", width)
+		width = CompressMinWidth
+	}
+	m.width = width
+	// Excluding borders(2), label, and one extra character that is appended
+	// by textInput.View()
+	inputWidth := width - 2 - labelWidth - 1
+	m.nameInput.Width = inputWidth
+	m.destInput.Width = inputWidth
+	m.excludeInput.Width = inputWidth
+}
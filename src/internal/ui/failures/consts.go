@@ -0,0 +1,15 @@
+package failures
+
+const (
+	FailuresMinWidth = 40
+	// Borders, the summary, one failure, the modes and their separators
+	FailuresMinHeight = 2 + 1 + 1 + 1 + 1 + maxModes
+
+	// Count of ways to run the items again, see common.RetryMode
+	maxModes = 3
+
+	nextModeKey = "tab"
+	prevModeKey = "shift+tab"
+
+	notRetryableText = "This operation can't be retried"
+)
@@ -0,0 +1,68 @@
+package failures
+
+import (
+	"log/slog"
+	"reflect"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/yorukot/superfile/src/internal/common"
+)
+
+func DefaultModel(maxHeight int, width int) Model {
+	m := Model{}
+	m.SetMaxHeight(maxHeight)
+	m.SetWidth(width)
+	return m
+}
+
+// HandleUpdate browses the failed items and picks how to run them again. A
+// common.RetryOperationAction is returned once it is confirmed.
+func (m *Model) HandleUpdate(msg tea.Msg) (common.ModelAction, tea.Cmd) {
+	slog.Debug("failures.Model HandleUpdate()", "msg", msg, "msgType", reflect.TypeOf(msg))
+	var action common.ModelAction = common.NoAction{}
+	if !m.IsOpen() {
+		slog.Error("HandleUpdate called on closed failures modal")
+		return action, nil
+	}
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return action, nil
+	}
+	key := keyMsg.String()
+	justOpened := m.justOpened
+	m.justOpened = false
+	switch {
+	case slices.Contains(common.Hotkeys.Confirm, key) && justOpened:
+		// Ignore the key that just opened this modal
+	case slices.Contains(common.Hotkeys.ConfirmTyping, key):
+		action = m.handleConfirm()
+		m.Close()
+	case slices.Contains(common.Hotkeys.CancelTyping, key), slices.Contains(common.Hotkeys.Quit, key):
+		m.Close()
+	case slices.Contains(common.Hotkeys.ListUp, key):
+		m.navigateUp()
+	case slices.Contains(common.Hotkeys.ListDown, key):
+		m.navigateDown()
+	case key == nextModeKey:
+		m.changeMode(1)
+	case key == prevModeKey:
+		m.changeMode(-1)
+	}
+	return action, nil
+}
+
+func (m *Model) handleConfirm() common.ModelAction {
+	if len(m.modes) == 0 {
+		return common.NoAction{}
+	}
+	return common.RetryOperationAction{ProcessID: m.processID, Mode: m.modes[m.mode]}
+}
+
+func (m *Model) changeMode(delta int) {
+	if len(m.modes) == 0 {
+		return
+	}
+	m.mode = (m.mode + delta + len(m.modes)) % len(m.modes)
+}
@@ -0,0 +1,97 @@
+package failures
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+)
+
+func TestMain(m *testing.M) {
+	common.Hotkeys.Confirm = []string{"enter", "right"}
+	common.Hotkeys.ConfirmTyping = []string{"enter"}
+	common.Hotkeys.CancelTyping = []string{"esc"}
+	common.Hotkeys.Quit = []string{"q"}
+	common.Hotkeys.ListUp = []string{"up"}
+	common.Hotkeys.ListDown = []string{"down"}
+	m.Run()
+}
+
+func failedProcess(cntFailures int, remaining ...string) processbar.Process {
+	p := processbar.Process{ID: "id", Name: "paste", State: processbar.Failed}
+	for i := range cntFailures {
+		p.Failures = append(p.Failures, processbar.ItemFailure{
+			Item: "/src/file" + string(rune('a'+i)), Error: "permission denied"})
+	}
+	p.Remaining = remaining
+	return p
+}
+
+func TestFailuresModal(t *testing.T) {
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	tab := tea.KeyMsg{Type: tea.KeyTab}
+
+	t.Run("Opening key is ignored", func(t *testing.T) {
+		m := DefaultModel(FailuresMinHeight, 60)
+		m.Open(failedProcess(1, "/src/b"), true)
+		action, _ := m.HandleUpdate(enter)
+		assert.Equal(t, common.NoAction{}, action)
+		require.True(t, m.IsOpen())
+
+		action, _ = m.HandleUpdate(enter)
+		assert.Equal(t, common.RetryOperationAction{ProcessID: "id", Mode: common.RetryFailed}, action)
+		assert.False(t, m.IsOpen())
+	})
+
+	t.Run("Modes depend on what is left", func(t *testing.T) {
+		testdata := []struct {
+			name      string
+			p         processbar.Process
+			retryable bool
+			expected  []common.RetryMode
+		}{
+			{"Failed and remaining items", failedProcess(1, "/src/b"), true,
+				[]common.RetryMode{common.RetryFailed, common.RetryRemaining, common.RetrySkipErrors}},
+			{"Only failed items", failedProcess(2), true, []common.RetryMode{common.RetryFailed}},
+			{"Not retryable", failedProcess(1, "/src/b"), false, nil},
+		}
+		for _, tt := range testdata {
+			t.Run(tt.name, func(t *testing.T) {
+				m := DefaultModel(FailuresMinHeight, 60)
+				m.Open(tt.p, tt.retryable)
+				assert.Equal(t, tt.expected, m.modes)
+			})
+		}
+	})
+
+	t.Run("Tab picks the mode", func(t *testing.T) {
+		m := DefaultModel(FailuresMinHeight, 60)
+		m.Open(failedProcess(1, "/src/b"), true)
+		m.HandleUpdate(tab)
+		m.HandleUpdate(tab)
+		assert.Contains(t, m.Render(), "Skip errors and continue")
+		action, _ := m.HandleUpdate(enter)
+		assert.Equal(t, common.RetryOperationAction{ProcessID: "id", Mode: common.RetrySkipErrors}, action)
+	})
+
+	t.Run("Failures are listed and scrolled", func(t *testing.T) {
+		m := DefaultModel(FailuresMinHeight, 60)
+		m.Open(failedProcess(5), false)
+		res := m.Render()
+		assert.Contains(t, res, "5 items failed")
+		assert.Contains(t, res, "filea: permission denied")
+		assert.Contains(t, res, notRetryableText)
+
+		m.HandleUpdate(tea.KeyMsg{Type: tea.KeyUp})
+		assert.Equal(t, 4, m.cursor)
+		assert.Contains(t, m.Render(), "filee: permission denied")
+
+		action, _ := m.HandleUpdate(enter)
+		assert.Equal(t, common.NoAction{}, action)
+		assert.False(t, m.IsOpen())
+	})
+}
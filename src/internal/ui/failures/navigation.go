@@ -0,0 +1,43 @@
+package failures
+
+func (m *Model) navigateUp() {
+	if len(m.failures) == 0 {
+		return
+	}
+	if m.cursor > 0 {
+		m.cursor--
+	} else {
+		m.cursor = len(m.failures) - 1 // Wrap to bottom
+	}
+	m.updateRenderIndex()
+}
+
+func (m *Model) navigateDown() {
+	if len(m.failures) == 0 {
+		return
+	}
+	if m.cursor < len(m.failures)-1 {
+		m.cursor++
+	} else {
+		m.cursor = 0 // Wrap to top
+	}
+	m.updateRenderIndex()
+}
+
+// updateRenderIndex scrolls the list, so that the cursor stays visible
+func (m *Model) updateRenderIndex() {
+	cntVisible := m.cntVisibleFailures()
+	if m.cursor < m.renderIndex {
+		m.renderIndex = m.cursor
+	}
+	if m.cursor >= m.renderIndex+cntVisible {
+		m.renderIndex = m.cursor - cntVisible + 1
+	}
+	m.renderIndex = max(0, min(m.renderIndex, len(m.failures)-cntVisible))
+}
+
+// Count of failures that fit between the summary and the modes
+func (m *Model) cntVisibleFailures() int {
+	// Borders(2), the summary(1), the modes and their separators(2)
+	return max(1, m.maxHeight-2-1-maxModes-2)
+}
@@ -0,0 +1,75 @@
+package failures
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/yorukot/superfile/src/config/icon"
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui"
+	"github.com/yorukot/superfile/src/internal/ui/rendering"
+)
+
+func (m *Model) Render() string {
+	r := ui.FailuresRenderer(m.maxHeight, m.width)
+	r.SetBorderTitle(m.headline)
+	if len(m.failures) > 0 {
+		r.SetBorderInfoItems(fmt.Sprintf("%d/%d", m.cursor+1, len(m.failures)))
+	}
+	r.AddLines(" " + m.summary())
+	r.AddSection()
+	m.renderFailures(r)
+	r.AddSection()
+	m.renderModes(r)
+	return r.Render()
+}
+
+func (m *Model) summary() string {
+	res := fmt.Sprintf("%d items failed", len(m.failures))
+	if len(m.failures) == 1 {
+		res = "1 item failed"
+	}
+	if m.cntRemaining > 0 {
+		res += fmt.Sprintf(", %d not started", m.cntRemaining)
+	}
+	return res
+}
+
+func (m *Model) renderFailures(r *rendering.Renderer) {
+	// Borders(2) and padding(1)
+	width := m.width - 2 - 1
+	endIndex := min(m.renderIndex+m.cntVisibleFailures(), len(m.failures))
+	for i := m.renderIndex; i < endIndex; i++ {
+		f := m.failures[i]
+		line := common.TruncateText(filepath.Base(f.Item)+": "+f.Error, width, "...")
+		if i == m.cursor {
+			line = common.ModalCursorStyle.Render(line)
+		} else {
+			line = common.ModalErrorStyle.Render(line)
+		}
+		r.AddLines(" " + line)
+	}
+}
+
+// renderModes renders the ways to run the items again, one per line, with the selected
+// one highlighted
+func (m *Model) renderModes(r *rendering.Renderer) {
+	if len(m.modes) == 0 {
+		r.AddLines(" " + notRetryableText)
+		return
+	}
+	for i, mode := range m.modes {
+		if i == m.mode {
+			r.AddLines(" " + common.ModalCursorStyle.Render(icon.Cursor+icon.Space+modeText(mode)))
+		} else {
+			r.AddLines("   " + modeText(mode))
+		}
+	}
+}
+
+// modeText returns mode, capitalized
+func modeText(mode common.RetryMode) string {
+	text := mode.String()
+	return strings.ToUpper(text[:1]) + text[1:]
+}
@@ -0,0 +1,29 @@
+package failures
+
+import (
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+)
+
+// Model is the modal listing the failed items of a process
+type Model struct {
+	// State
+	open       bool
+	justOpened bool // Flag to ignore the opening keystroke
+	// Icon and name of the process
+	headline  string
+	processID string
+	failures  []processbar.ItemFailure
+	// Count of items the process didn't get to
+	cntRemaining int
+	// Ways to run the items again, empty if the process can't be retried
+	modes []common.RetryMode
+	// Index of the selected mode in modes
+	mode        int
+	cursor      int // Index of the selected failure
+	renderIndex int // Index of the first visible failure
+
+	// Dimensions, set by the main model as the terminal is resized
+	width     int
+	maxHeight int
+}
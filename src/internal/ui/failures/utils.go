@@ -0,0 +1,73 @@
+package failures
+
+import (
+	"log/slog"
+	"slices"
+
+	"github.com/yorukot/superfile/src/config/icon"
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+)
+
+// Open shows the failed items of p. If retryable is set, they can be run again, along
+// with the items p didn't get to.
+func (m *Model) Open(p processbar.Process, retryable bool) {
+	m.open = true
+	m.justOpened = true
+	m.headline = icon.Warn + icon.Space + p.Name
+	m.processID = p.ID
+	m.failures = slices.Clone(p.Failures)
+	m.cntRemaining = len(p.Remaining)
+	m.modes = nil
+	if retryable {
+		if len(m.failures) > 0 {
+			m.modes = append(m.modes, common.RetryFailed)
+		}
+		if len(m.failures) > 0 && m.cntRemaining > 0 {
+			m.modes = append(m.modes, common.RetryRemaining)
+		}
+		if m.cntRemaining > 0 {
+			m.modes = append(m.modes, common.RetrySkipErrors)
+		}
+	}
+	m.mode = 0
+	m.cursor = 0
+	m.renderIndex = 0
+}
+
+func (m *Model) Close() {
+	m.open = false
+	m.failures = nil
+	m.modes = nil
+	m.cursor = 0
+	m.renderIndex = 0
+}
+
+func (m *Model) IsOpen() bool {
+	return m.open
+}
+
+func (m *Model) GetWidth() int {
+	return m.width
+}
+
+func (m *Model) GetMaxHeight() int {
+	return m.maxHeight
+}
+
+func (m *Model) SetWidth(width int) {
+	if width < FailuresMinWidth {
+		slog.Warn("Failures modal initialized with too less width", "width", width)
+		width = FailuresMinWidth
+	}
+	m.width = width
+}
+
+func (m *Model) SetMaxHeight(maxHeight int) {
+	if maxHeight < FailuresMinHeight {
+		slog.Warn("Failures modal initialized with too less maxHeight", "maxHeight", maxHeight)
+		maxHeight = FailuresMinHeight
+	}
+	m.maxHeight = maxHeight
+	m.updateRenderIndex()
+}
@@ -0,0 +1,14 @@
+package history
+
+const (
+	historyHeadlineText = "Operation history"
+
+	HistoryMinWidth = 30
+	// Borders, one entry, the separator and the details of the selected entry
+	HistoryMinHeight = 2 + 1 + 1 + detailLines
+
+	// Sources, destination and error of the selected entry
+	detailLines = 3
+
+	timeLayout = "Jan 02 15:04"
+)
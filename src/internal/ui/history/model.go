@@ -0,0 +1,52 @@
+package history
+
+import (
+	"log/slog"
+	"reflect"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/yorukot/superfile/src/config/icon"
+	"github.com/yorukot/superfile/src/internal/common"
+)
+
+func DefaultModel(maxHeight int, width int) Model {
+	m := Model{
+		headline: icon.History + icon.Space + historyHeadlineText,
+	}
+	m.SetMaxHeight(maxHeight)
+	m.SetWidth(width)
+	return m
+}
+
+// HandleUpdate browses the entries. The history doesn't change anything, so no action
+// is ever returned.
+func (m *Model) HandleUpdate(msg tea.Msg) (common.ModelAction, tea.Cmd) {
+	slog.Debug("history.Model HandleUpdate()", "msg", msg, "msgType", reflect.TypeOf(msg))
+	action := common.NoAction{}
+	if !m.IsOpen() {
+		slog.Error("HandleUpdate called on closed history")
+		return action, nil
+	}
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return action, nil
+	}
+	key := keyMsg.String()
+	switch {
+	case slices.Contains(common.Hotkeys.OpenOperationHistory, key) && m.justOpened:
+		// Ignore the key that just opened this modal
+	case slices.Contains(common.Hotkeys.ListUp, key):
+		m.navigateUp()
+	case slices.Contains(common.Hotkeys.ListDown, key):
+		m.navigateDown()
+	case slices.Contains(common.Hotkeys.ConfirmTyping, key),
+		slices.Contains(common.Hotkeys.CancelTyping, key),
+		slices.Contains(common.Hotkeys.Quit, key),
+		slices.Contains(common.Hotkeys.OpenOperationHistory, key):
+		m.Close()
+	}
+	m.justOpened = false
+	return action, nil
+}
@@ -0,0 +1,105 @@
+package history
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	historylib "github.com/yorukot/superfile/src/internal/history"
+)
+
+func TestMain(m *testing.M) {
+	common.Hotkeys.ConfirmTyping = []string{"enter"}
+	common.Hotkeys.CancelTyping = []string{"esc"}
+	common.Hotkeys.Quit = []string{"q"}
+	common.Hotkeys.ListUp = []string{"up"}
+	common.Hotkeys.ListDown = []string{"down"}
+	common.Hotkeys.OpenOperationHistory = []string{"O"}
+	m.Run()
+}
+
+func genEntries(cnt int) []historylib.Entry {
+	entries := make([]historylib.Entry, 0, cnt)
+	for i := range cnt {
+		entries = append(entries, historylib.Entry{
+			Name:        "op" + strconv.Itoa(i),
+			Sources:     []string{"/src/file" + strconv.Itoa(i)},
+			Destination: "/dest",
+			State:       "successful",
+			Time:        time.Now(),
+			Duration:    time.Duration(i) * time.Second,
+		})
+	}
+	return entries
+}
+
+func TestHistoryModal(t *testing.T) {
+	key := func(k string) tea.KeyMsg {
+		switch k {
+		case "up":
+			return tea.KeyMsg{Type: tea.KeyUp}
+		case "down":
+			return tea.KeyMsg{Type: tea.KeyDown}
+		case "esc":
+			return tea.KeyMsg{Type: tea.KeyEsc}
+		}
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+	}
+
+	t.Run("Newest entry is shown first", func(t *testing.T) {
+		m := DefaultModel(HistoryMinHeight+2, 60)
+		m.Open(genEntries(3))
+		assert.Equal(t, "op2", m.entries[0].Name)
+		res := m.Render()
+		assert.Contains(t, res, "op2")
+		assert.Contains(t, res, "/src/file2", "Details of the selected entry should be shown")
+	})
+
+	t.Run("Opening key is ignored, then closes", func(t *testing.T) {
+		m := DefaultModel(HistoryMinHeight, 60)
+		m.Open(genEntries(1))
+		m.HandleUpdate(key("O"))
+		require.True(t, m.IsOpen())
+		m.HandleUpdate(key("O"))
+		assert.False(t, m.IsOpen())
+	})
+
+	t.Run("Navigation scrolls and wraps", func(t *testing.T) {
+		m := DefaultModel(HistoryMinHeight+2, 60)
+		m.Open(genEntries(10))
+		cntVisible := m.cntVisibleEntries()
+		require.Equal(t, 3, cntVisible)
+		for range cntVisible {
+			m.HandleUpdate(key("down"))
+		}
+		assert.Equal(t, cntVisible, m.cursor)
+		assert.Equal(t, 1, m.renderIndex)
+
+		m.cursor, m.renderIndex = 0, 0
+		m.HandleUpdate(key("up"))
+		assert.Equal(t, 9, m.cursor, "Should wrap to the oldest entry")
+		assert.Equal(t, 10-cntVisible, m.renderIndex)
+		assert.Contains(t, m.Render(), "op0")
+
+		m.HandleUpdate(key("esc"))
+		assert.False(t, m.IsOpen())
+	})
+
+	t.Run("Error of failed entries is shown", func(t *testing.T) {
+		m := DefaultModel(HistoryMinHeight, 60)
+		m.Open([]historylib.Entry{{Name: "op", State: "failed", Error: "permission denied"}})
+		assert.Contains(t, m.Render(), "permission denied")
+	})
+
+	t.Run("Empty history", func(t *testing.T) {
+		m := DefaultModel(HistoryMinHeight, 60)
+		m.Open(nil)
+		m.HandleUpdate(key("down"))
+		assert.Contains(t, m.Render(), "No operations yet")
+	})
+}
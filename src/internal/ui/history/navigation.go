@@ -0,0 +1,43 @@
+package history
+
+func (m *Model) navigateUp() {
+	if len(m.entries) == 0 {
+		return
+	}
+	if m.cursor > 0 {
+		m.cursor--
+	} else {
+		m.cursor = len(m.entries) - 1 // Wrap to bottom
+	}
+	m.updateRenderIndex()
+}
+
+func (m *Model) navigateDown() {
+	if len(m.entries) == 0 {
+		return
+	}
+	if m.cursor < len(m.entries)-1 {
+		m.cursor++
+	} else {
+		m.cursor = 0 // Wrap to top
+	}
+	m.updateRenderIndex()
+}
+
+// updateRenderIndex scrolls the list, so that the cursor stays visible
+func (m *Model) updateRenderIndex() {
+	cntVisible := m.cntVisibleEntries()
+	if m.cursor < m.renderIndex {
+		m.renderIndex = m.cursor
+	}
+	if m.cursor >= m.renderIndex+cntVisible {
+		m.renderIndex = m.cursor - cntVisible + 1
+	}
+	m.renderIndex = max(0, min(m.renderIndex, len(m.entries)-cntVisible))
+}
+
+// Count of entries that fit above the details of the selected one
+func (m *Model) cntVisibleEntries() int {
+	// Borders(2) and the separator before the details(1)
+	return max(1, m.maxHeight-2-1-detailLines)
+}
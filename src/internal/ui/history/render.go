@@ -0,0 +1,87 @@
+package history
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	historylib "github.com/yorukot/superfile/src/internal/history"
+	"github.com/yorukot/superfile/src/internal/ui"
+	"github.com/yorukot/superfile/src/internal/ui/processbar"
+	"github.com/yorukot/superfile/src/internal/ui/rendering"
+)
+
+func (m *Model) Render() string {
+	r := ui.HistoryRenderer(m.maxHeight, m.width)
+	r.SetBorderTitle(m.headline)
+	if len(m.entries) == 0 {
+		r.AddLines(" No operations yet")
+		return r.Render()
+	}
+	r.SetBorderInfoItems(fmt.Sprintf("%d/%d", m.cursor+1, len(m.entries)))
+
+	endIndex := min(m.renderIndex+m.cntVisibleEntries(), len(m.entries))
+	for i := m.renderIndex; i < endIndex; i++ {
+		r.AddLines(m.renderEntry(m.entries[i], i == m.cursor))
+	}
+	r.AddSection()
+	m.renderDetails(r, m.entries[m.cursor])
+	return r.Render()
+}
+
+// renderEntry renders the state icon, name, end time and duration of e on a line
+func (m *Model) renderEntry(e historylib.Entry, selected bool) string {
+	info := e.Time.Local().Format(timeLayout) + " " + formatDuration(e.Duration)
+	// Borders(2), padding(1), the icon and its space(2) and the space before info(1)
+	nameWidth := max(0, m.width-2-1-2-1-len(info))
+	name := common.TruncateText(e.Name, nameWidth, "...")
+	name += strings.Repeat(" ", max(0, nameWidth-ansi.StringWidth(name)))
+
+	text := name + " " + info
+	if selected {
+		text = common.ModalCursorStyle.Render(text)
+	}
+	return " " + stateIcon(e.State) + " " + text
+}
+
+func (m *Model) renderDetails(r *rendering.Renderer, e historylib.Entry) {
+	// Borders(2) and padding(1)
+	width := m.width - 2 - 1
+	sources := "-"
+	if len(e.Sources) > 0 {
+		sources = strings.Join(e.Sources, ", ")
+	}
+	destination := "-"
+	if e.Destination != "" {
+		destination = e.Destination
+	}
+	r.AddLines(
+		" From: "+common.TruncateTextBeginning(sources, width-len("From: "), "..."),
+		" To: "+common.TruncateTextBeginning(destination, width-len("To: "), "..."),
+	)
+	if e.Error != "" {
+		r.AddLines(" " + common.ModalErrorStyle.Render(common.TruncateText("Error: "+e.Error, width, "...")))
+	} else {
+		r.AddLines(" State: " + e.State)
+	}
+}
+
+func stateIcon(state string) string {
+	for _, s := range []processbar.ProcessState{processbar.Successful, processbar.Cancelled, processbar.Failed} {
+		if s.String() == state {
+			return s.Icon()
+		}
+	}
+	return processbar.Cancelled.Icon()
+}
+
+// formatDuration formats d like "2m5s", without the sub second part
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return "<1s"
+	}
+	return d.Round(time.Second).String()
+}
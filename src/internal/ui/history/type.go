@@ -0,0 +1,22 @@
+package history
+
+import historylib "github.com/yorukot/superfile/src/internal/history"
+
+// No need to name it as HistoryModel. It will me imported as history.Model
+type Model struct {
+
+	// Configuration
+	headline string
+
+	// State
+	open       bool
+	justOpened bool // Flag to ignore the opening keystroke
+	// Newest first
+	entries     []historylib.Entry
+	cursor      int // Index of the selected entry
+	renderIndex int // Index of the first visible entry
+
+	// Dimensions - Exported, since model will be dynamically adjusting them
+	width     int
+	maxHeight int
+}
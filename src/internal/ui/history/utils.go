@@ -0,0 +1,54 @@
+package history
+
+import (
+	"log/slog"
+	"slices"
+
+	historylib "github.com/yorukot/superfile/src/internal/history"
+)
+
+// Open shows entries, which are oldest first, with the newest one selected
+func (m *Model) Open(entries []historylib.Entry) {
+	m.open = true
+	m.justOpened = true
+	m.entries = slices.Clone(entries)
+	slices.Reverse(m.entries)
+	m.cursor = 0
+	m.renderIndex = 0
+}
+
+func (m *Model) Close() {
+	m.open = false
+	m.entries = nil
+	m.cursor = 0
+	m.renderIndex = 0
+}
+
+func (m *Model) IsOpen() bool {
+	return m.open
+}
+
+func (m *Model) GetWidth() int {
+	return m.width
+}
+
+func (m *Model) GetMaxHeight() int {
+	return m.maxHeight
+}
+
+func (m *Model) SetWidth(width int) {
+	if width < HistoryMinWidth {
+		slog.Warn("History initialized with too less width", "width", width)
+		width = HistoryMinWidth
+	}
+	m.width = width
+}
+
+func (m *Model) SetMaxHeight(maxHeight int) {
+	if maxHeight < HistoryMinHeight {
+		slog.Warn("History initialized with too less maxHeight", "maxHeight", maxHeight)
+		maxHeight = HistoryMinHeight
+	}
+	m.maxHeight = maxHeight
+	m.updateRenderIndex()
+}
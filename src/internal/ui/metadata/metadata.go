@@ -105,7 +105,7 @@ func getMetaDataUnsorted(filePath string, metadataFocussed bool, et *exiftool.Ex
 	size := [2]string{keySize, common.FormatFileSize(fileInfo.Size())}
 	modifyDate := [2]string{keyDataModified, fileInfo.ModTime().String()}
 	permissions := [2]string{keyPermissions, fileInfo.Mode().String()}
-	ownerVal, groupVal := getOwnerAndGroup(fileInfo)
+	ownerVal, groupVal := GetOwnerAndGroup(fileInfo)
 	owner := [2]string{keyOwner, ownerVal}
 	group := [2]string{keyGroup, groupVal}
 
@@ -9,7 +9,8 @@ import (
 	"syscall"
 )
 
-func getOwnerAndGroup(fileInfo os.FileInfo) (string, string) {
+// GetOwnerAndGroup returns the names of the owner and the group of a file, if known
+func GetOwnerAndGroup(fileInfo os.FileInfo) (string, string) {
 	usr := ""
 	grp := ""
 	if stat, ok := fileInfo.Sys().(*syscall.Stat_t); ok {
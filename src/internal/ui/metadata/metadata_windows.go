@@ -6,6 +6,7 @@ import (
 	"os"
 )
 
-func getOwnerAndGroup(_ os.FileInfo) (string, string) {
+// GetOwnerAndGroup returns the names of the owner and the group of a file, if known
+func GetOwnerAndGroup(_ os.FileInfo) (string, string) {
 	return "", ""
 }
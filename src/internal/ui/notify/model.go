@@ -1,6 +1,9 @@
 package notify
 
 import (
+	"fmt"
+
+	"github.com/yorukot/superfile/src/config/icon"
 	"github.com/yorukot/superfile/src/internal/common"
 )
 
@@ -9,6 +12,17 @@ type Model struct {
 	title         string
 	content       string
 	confirmAction ConfirmActionType
+
+	// Only used by multi choice dialogs. The "apply to all" row, if enabled,
+	// is rendered after the choices and the cursor can point to it.
+	choices       []string
+	cursor        int
+	allowApplyAll bool
+	applyToAll    bool
+
+	// Only used by list dialogs. Lines are scrolled when there are more than maxListLines.
+	lines      []string
+	listOffset int
 }
 
 func New(open bool, title string, content string, confirmAction ConfirmActionType) Model {
@@ -20,6 +34,32 @@ func New(open bool, title string, content string, confirmAction ConfirmActionTyp
 	}
 }
 
+// NewMultiChoice returns an open dialog that lets the user pick one of the choices
+// If allowApplyAll is true, an additional "apply to all" checkbox is shown below them.
+func NewMultiChoice(title string, content string, confirmAction ConfirmActionType,
+	choices []string, allowApplyAll bool) Model {
+	return Model{
+		open:          true,
+		title:         title,
+		content:       content,
+		confirmAction: confirmAction,
+		choices:       choices,
+		allowApplyAll: allowApplyAll,
+	}
+}
+
+// NewList returns an open dialog that shows lines below its content, for example the
+// changes that confirming it will apply
+func NewList(title string, content string, confirmAction ConfirmActionType, lines []string) Model {
+	return Model{
+		open:          true,
+		title:         title,
+		content:       content,
+		confirmAction: confirmAction,
+		lines:         lines,
+	}
+}
+
 func (m *Model) GetTitle() string {
 	return m.title
 }
@@ -44,6 +84,96 @@ func (m *Model) GetConfirmAction() ConfirmActionType {
 	return m.confirmAction
 }
 
+func (m *Model) IsMultiChoice() bool {
+	return len(m.choices) > 0
+}
+
+func (m *Model) IsList() bool {
+	return len(m.lines) > 0
+}
+
+func (m *Model) GetLines() []string {
+	return m.lines
+}
+
+// Count of lines of a list dialog that are shown at once
+func (m *Model) cntVisibleLines() int {
+	return min(len(m.lines), maxListLines)
+}
+
+// Number of rows the cursor can move through
+func (m *Model) cntRows() int {
+	if m.allowApplyAll {
+		return len(m.choices) + 1
+	}
+	return len(m.choices)
+}
+
+func (m *Model) ListUp() {
+	if m.IsList() {
+		m.listOffset = max(m.listOffset-1, 0)
+		return
+	}
+	if m.cntRows() == 0 {
+		return
+	}
+	m.cursor = (m.cursor - 1 + m.cntRows()) % m.cntRows()
+}
+
+func (m *Model) ListDown() {
+	if m.IsList() {
+		m.listOffset = min(m.listOffset+1, len(m.lines)-m.cntVisibleLines())
+		return
+	}
+	if m.cntRows() == 0 {
+		return
+	}
+	m.cursor = (m.cursor + 1) % m.cntRows()
+}
+
+// IsCursorOnApplyToAll reports whether the cursor is on the "apply to all" row. Confirming there
+// should toggle the checkbox instead of picking a choice.
+func (m *Model) IsCursorOnApplyToAll() bool {
+	return m.allowApplyAll && m.cursor == len(m.choices)
+}
+
+func (m *Model) ToggleApplyToAll() {
+	m.applyToAll = !m.applyToAll
+}
+
+func (m *Model) IsApplyToAll() bool {
+	return m.applyToAll
+}
+
+// SetSelectedChoice moves the cursor to the choice at index, if there is one
+func (m *Model) SetSelectedChoice(index int) {
+	if index >= 0 && index < len(m.choices) {
+		m.cursor = index
+	}
+}
+
+// GetSelectedChoice returns the index of the choice under the cursor, or -1 if the
+// cursor isn't on a choice
+func (m *Model) GetSelectedChoice() int {
+	if m.cursor < 0 || m.cursor >= len(m.choices) {
+		return -1
+	}
+	return m.cursor
+}
+
+// GetHeight returns the height of the rendered dialog, excluding borders
+func (m *Model) GetHeight() int {
+	if m.IsList() {
+		// title, content, input keys and the blank lines between them
+		return m.cntVisibleLines() + 6
+	}
+	if !m.IsMultiChoice() {
+		return common.ModalHeight
+	}
+	// title, content, input keys and the blank lines between them
+	return m.cntRows() + 6
+}
+
 // TODO: Remove code duplication with typineModalRender
 func (m *Model) Render() string {
 	var inputKeysText string
@@ -52,6 +182,54 @@ func (m *Model) Render() string {
 	} else {
 		inputKeysText = common.ModalConfirmInputText + common.ModalInputSpacingText + common.ModalCancelInputText
 	}
+	if m.IsMultiChoice() {
+		return common.ModalBorderStyleLeft(m.GetHeight(), common.ModalWidth).
+			Render(" " + m.title + "\n\n " + m.content + "\n\n" + m.renderChoices() + "\n" + inputKeysText)
+	}
+	if m.IsList() {
+		return common.ModalBorderStyleLeft(m.GetHeight(), common.ModalWidth).
+			Render(" " + m.title + "\n\n " + m.renderListContent() + "\n\n" + m.renderLines() + "\n" + inputKeysText)
+	}
 	return common.ModalBorderStyle(common.ModalHeight, common.ModalWidth).
 		Render(m.title + "\n\n" + m.content + "\n\n" + inputKeysText)
 }
+
+func (m *Model) renderChoices() string {
+	res := ""
+	for i, choice := range m.choices {
+		res += m.renderCursor(i) + common.ModalStyle.Render(" "+choice) + "\n"
+	}
+	if m.allowApplyAll {
+		checkbox := "[ ]"
+		if m.applyToAll {
+			checkbox = "[x]"
+		}
+		res += m.renderCursor(len(m.choices)) + common.ModalStyle.Render(" "+checkbox+" Apply to all") + "\n"
+	}
+	return res
+}
+
+// renderListContent returns the content, followed by the range of shown lines if
+// they don't all fit
+func (m *Model) renderListContent() string {
+	if len(m.lines) <= maxListLines {
+		return m.content
+	}
+	return fmt.Sprintf("%s (%d-%d of %d)", m.content, m.listOffset+1,
+		m.listOffset+m.cntVisibleLines(), len(m.lines))
+}
+
+func (m *Model) renderLines() string {
+	res := ""
+	for _, line := range m.lines[m.listOffset : m.listOffset+m.cntVisibleLines()] {
+		res += common.ModalStyle.Render(" "+common.TruncateText(line, common.ModalWidth-2, "...")) + "\n"
+	}
+	return res
+}
+
+func (m *Model) renderCursor(row int) string {
+	if row == m.cursor {
+		return common.FilePanelCursorStyle.Render(icon.Cursor)
+	}
+	return " "
+}
@@ -8,4 +8,11 @@ const (
 	QuitAction
 	NoAction
 	PermanentDeleteAction
+	PasteConflictAction
+	EmptyTrashAction
+	BulkRenameAction
+	PastePreflightAction
 )
+
+// Count of lines of a list dialog that are shown at once
+const maxListLines = 10
@@ -0,0 +1,12 @@
+package password
+
+const (
+	passwordHeadlineText = "Archive password"
+
+	PasswordMinWidth = 40
+	// Borders, the archive name, the input, the status and the hint
+	PasswordHeight = 6
+
+	// Shown in place of each character of the password
+	echoCharacter = '•'
+)
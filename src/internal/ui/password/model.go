@@ -0,0 +1,91 @@
+package password
+
+import (
+	"log/slog"
+	"reflect"
+	"slices"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/yorukot/superfile/src/config/icon"
+	"github.com/yorukot/superfile/src/internal/common"
+)
+
+func DefaultModel(width int) Model {
+	return GenerateModel(width)
+}
+
+func GenerateModel(width int) Model {
+	m := Model{
+		headline: icon.Lock + icon.Space + passwordHeadlineText,
+		open:     false,
+		input:    common.GeneratePromptTextInput(),
+	}
+	m.input.EchoMode = textinput.EchoPassword
+	m.input.EchoCharacter = echoCharacter
+	m.SetWidth(width)
+	return m
+}
+
+func (m *Model) HandleUpdate(msg tea.Msg) (common.ModelAction, tea.Cmd) {
+	// The message is not logged, as it could contain a part of the password
+	slog.Debug("password.Model HandleUpdate()", "msgType", reflect.TypeOf(msg))
+	var action common.ModelAction
+	action = common.NoAction{}
+	var cmd tea.Cmd
+	if !m.IsOpen() {
+		slog.Error("HandleUpdate called on closed password modal")
+		return action, cmd
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case slices.Contains(common.Hotkeys.ConfirmTyping, msg.String()):
+			action = m.handleConfirm()
+		case slices.Contains(common.Hotkeys.CancelTyping, msg.String()):
+			m.Close()
+		default:
+			m.input, cmd = m.input.Update(msg)
+			m.errMsg = ""
+		}
+	default:
+		// Non keypress updates like Cursor Blink
+		m.input, cmd = m.input.Update(msg)
+	}
+	return action, cmd
+}
+
+func (m *Model) handleConfirm() common.ModelAction {
+	if m.input.Value() == "" {
+		m.errMsg = "The password can't be empty"
+		return common.NoAction{}
+	}
+	action := common.ExtractArchiveAction{Archive: m.archive, Password: m.input.Value()}
+	m.Close()
+	return action
+}
+
+// Open asks the password of archive. errMsg tells why it is asked again, like when the
+// previous one was wrong, and is empty the first time.
+func (m *Model) Open(archive string, errMsg string) {
+	m.open = true
+	m.archive = archive
+	m.errMsg = errMsg
+	m.input.SetValue("")
+	_ = m.input.Focus()
+}
+
+// Close the modal. The password is not kept in it.
+func (m *Model) Close() {
+	m.open = false
+	m.archive = ""
+	m.errMsg = ""
+	m.input.SetValue("")
+	m.input.Blur()
+}
+
+func (m *Model) IsOpen() bool {
+	return m.open
+}
@@ -0,0 +1,57 @@
+package password
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yorukot/superfile/src/internal/common"
+)
+
+func TestMain(m *testing.M) {
+	common.Hotkeys.ConfirmTyping = []string{"enter"}
+	common.Hotkeys.CancelTyping = []string{"esc"}
+	m.Run()
+}
+
+func typeText(m *Model, text string) {
+	for _, r := range text {
+		m.HandleUpdate(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+func TestPasswordModal(t *testing.T) {
+	t.Run("Confirm returns the password", func(t *testing.T) {
+		m := DefaultModel(PasswordMinWidth)
+		m.Open("/x/archive.zip", "")
+		typeText(&m, "secret")
+		assert.NotContains(t, m.Render(), "secret", "the password should be masked")
+
+		action, _ := m.HandleUpdate(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Equal(t, common.ExtractArchiveAction{Archive: "/x/archive.zip", Password: "secret"}, action)
+		assert.False(t, m.IsOpen())
+		assert.NotContains(t, action.String(), "secret", "the password should not be logged")
+	})
+
+	t.Run("Empty password is refused", func(t *testing.T) {
+		m := DefaultModel(PasswordMinWidth)
+		m.Open("/x/archive.zip", "")
+		action, _ := m.HandleUpdate(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Equal(t, common.NoAction{}, action)
+		assert.True(t, m.IsOpen())
+		assert.NotEmpty(t, m.errMsg)
+	})
+
+	t.Run("Cancel forgets the password", func(t *testing.T) {
+		m := DefaultModel(PasswordMinWidth)
+		m.Open("/x/archive.zip", "Wrong password")
+		require.Contains(t, m.Render(), "Wrong password")
+		typeText(&m, "secret")
+		action, _ := m.HandleUpdate(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.Equal(t, common.NoAction{}, action)
+		assert.False(t, m.IsOpen())
+		assert.Empty(t, m.input.Value())
+	})
+}
@@ -0,0 +1,23 @@
+package password
+
+import (
+	"path/filepath"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui"
+)
+
+func (m *Model) Render() string {
+	r := ui.PasswordRenderer(PasswordHeight, m.width)
+	r.SetBorderTitle(m.headline)
+
+	r.AddLines(" " + common.TruncateText(filepath.Base(m.archive), m.width-3, "..."))
+	r.AddLines(" " + m.input.View())
+	if m.errMsg != "" {
+		r.AddLines(common.ModalErrorStyle.Render(" " + m.errMsg))
+	} else {
+		r.AddLines("")
+	}
+	r.AddLines(common.ModalTitleStyle.Render(" enter: extract, esc: cancel"))
+	return r.Render()
+}
@@ -0,0 +1,21 @@
+package password
+
+import "github.com/charmbracelet/bubbles/textinput"
+
+// No need to name it as PasswordModel. It will me imported as password.Model
+type Model struct {
+
+	// Configuration
+	headline string
+
+	// State
+	open bool
+	// Archive the password is asked for
+	archive string
+	// Shown under the input, like when the previous password was wrong
+	errMsg string
+	input  textinput.Model
+
+	// Dimensions - Exported, since model will be dynamically adjusting them
+	width int
+}
@@ -0,0 +1,22 @@
+package password
+
+import "log/slog"
+
+func (m *Model) GetWidth() int {
+	return m.width
+}
+
+func (m *Model) GetHeight() int {
+	return PasswordHeight
+}
+
+func (m *Model) SetWidth(width int) {
+	if width < PasswordMinWidth {
+		slog.Warn("Password modal initialized with too less width", "width", width)
+		width = PasswordMinWidth
+	}
+	m.width = width
+	// Excluding borders(2), the leading space, and one extra character that is appended
+	// by textInput.View()
+	m.input.Width = width - 2 - 1 - 1
+}
@@ -0,0 +1,22 @@
+package permissions
+
+const (
+	permissionsHeadlineText = "Permissions"
+
+	PermissionsMinWidth  = 40
+	PermissionsMinHeight = 14
+
+	// Width of the field labels, like " Owner: "
+	labelWidth = 12
+
+	nextFieldKey = "tab"
+	prevFieldKey = "shift+tab"
+	leftKey      = "left"
+	rightKey     = "right"
+	upKey        = "up"
+	downKey      = "down"
+	toggleKey    = " "
+
+	// Highest mode that can be set, with the setuid, setgid and sticky bits
+	maxPerm = 0o7777
+)
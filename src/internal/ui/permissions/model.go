@@ -0,0 +1,263 @@
+package permissions
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"slices"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/yorukot/superfile/src/config/icon"
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui/metadata"
+)
+
+func DefaultModel(maxHeight int, width int) Model {
+	return GenerateModel(maxHeight, width)
+}
+
+func GenerateModel(maxHeight int, width int) Model {
+	m := Model{
+		headline:   icon.Lock + icon.Space + permissionsHeadlineText,
+		open:       false,
+		octalInput: common.GeneratePromptTextInput(),
+		ownerInput: common.GeneratePromptTextInput(),
+		groupInput: common.GeneratePromptTextInput(),
+	}
+	m.octalInput.CharLimit = 4
+	m.SetMaxHeight(maxHeight)
+	m.SetWidth(width)
+	return m
+}
+
+func (m *Model) HandleUpdate(msg tea.Msg) (common.ModelAction, tea.Cmd) {
+	slog.Debug("permissions.Model HandleUpdate()", "msg", msg,
+		"msgType", reflect.TypeOf(msg), "focus", m.focus)
+	var action common.ModelAction
+	action = common.NoAction{}
+	var cmd tea.Cmd
+	if !m.IsOpen() {
+		slog.Error("HandleUpdate called on closed permissions modal")
+		return action, cmd
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case slices.Contains(common.Hotkeys.ChangePermissions, msg.String()) && m.justOpened:
+			// Ignore the key that just opened this modal to prevent it from appearing in text input
+		case slices.Contains(common.Hotkeys.ConfirmTyping, msg.String()):
+			action = m.handleConfirm()
+		case slices.Contains(common.Hotkeys.CancelTyping, msg.String()):
+			m.Close()
+		case msg.String() == nextFieldKey:
+			m.setFocus((m.focus + 1) % fieldCnt)
+		case msg.String() == prevFieldKey:
+			m.setFocus((m.focus + fieldCnt - 1) % fieldCnt)
+		default:
+			cmd = m.handleFieldKey(msg)
+		}
+		m.justOpened = false
+	default:
+		// Non keypress updates like Cursor Blink
+		if input := m.focusedInput(); input != nil {
+			*input, cmd = input.Update(msg)
+		}
+	}
+	return action, cmd
+}
+
+func (m *Model) handleFieldKey(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	switch m.focus {
+	case gridField:
+		m.handleGridKey(msg.String())
+	case octalField:
+		m.octalInput, cmd = m.octalInput.Update(msg)
+		m.updatePermFromOctal()
+	case ownerField:
+		m.ownerInput, cmd = m.ownerInput.Update(msg)
+		m.ownerErr = validateName(m.ownerInput.Value(), lookupUID)
+	case groupField:
+		m.groupInput, cmd = m.groupInput.Update(msg)
+		m.groupErr = validateName(m.groupInput.Value(), lookupGID)
+	case recursiveField:
+		if msg.String() == toggleKey {
+			m.recursive = !m.recursive
+		}
+	case targetField:
+		switch msg.String() {
+		case rightKey, toggleKey:
+			m.target = (m.target + 1) % targetCnt
+		case leftKey:
+			m.target = (m.target + targetCnt - 1) % targetCnt
+		}
+	case fieldCnt:
+	}
+	return cmd
+}
+
+// Move in the 3x3 grid of read, write and execute bits of owner, group and others,
+// and toggle the bit under the cursor
+func (m *Model) handleGridKey(key string) {
+	switch key {
+	case leftKey:
+		m.gridCol = (m.gridCol + 2) % 3
+	case rightKey:
+		m.gridCol = (m.gridCol + 1) % 3
+	case upKey:
+		m.gridRow = (m.gridRow + 2) % 3
+	case downKey:
+		m.gridRow = (m.gridRow + 1) % 3
+	case toggleKey:
+		m.perm ^= gridBit(m.gridRow, m.gridCol)
+		m.permChanged = true
+		m.octalErr = nil
+		m.octalInput.SetValue(formatPerm(m.perm))
+	}
+}
+
+func (m *Model) updatePermFromOctal() {
+	perm, err := parseOctal(m.octalInput.Value())
+	m.octalErr = err
+	if err == nil {
+		m.perm = perm
+		m.permChanged = true
+	}
+}
+
+// Empty names are valid, they keep the current owner or group
+func validateName(name string, lookup func(string) (int, error)) error {
+	if name == "" {
+		return nil
+	}
+	_, err := lookup(name)
+	return err
+}
+
+// Invalid inputs keep the modal open, the first of their errors is in the status line.
+// Nothing is applied if nothing was changed.
+func (m *Model) handleConfirm() common.ModelAction {
+	action, err := m.getAction()
+	if err != nil {
+		return common.NoAction{}
+	}
+	m.Close()
+	if !action.SetMode && action.UID == -1 && action.GID == -1 {
+		return common.NoAction{}
+	}
+	return action
+}
+
+func (m *Model) getAction() (common.ChangePermissionsAction, error) {
+	action := common.ChangePermissionsAction{
+		Items:     slices.Clone(m.items),
+		SetMode:   m.permChanged,
+		Mode:      fileModeFromPerm(m.perm),
+		UID:       -1,
+		GID:       -1,
+		Recursive: m.recursive,
+		DirsOnly:  m.target == DirsOnly,
+		FilesOnly: m.target == FilesOnly,
+	}
+	if err := m.firstError(); err != nil {
+		return action, err
+	}
+	var err error
+	if owner := m.ownerInput.Value(); owner != "" {
+		if action.UID, err = lookupUID(owner); err != nil {
+			return action, err
+		}
+	}
+	if group := m.groupInput.Value(); group != "" {
+		if action.GID, err = lookupGID(group); err != nil {
+			return action, err
+		}
+	}
+	return action, nil
+}
+
+// Open the modal to change paths. The current mode, owner and group are the ones of
+// the first item.
+func (m *Model) Open(paths []string) error {
+	if len(paths) == 0 {
+		return errors.New("no items to change")
+	}
+	info, err := os.Lstat(paths[0])
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", paths[0], err)
+	}
+	m.items = slices.Clone(paths)
+	m.open = true
+	m.justOpened = true
+	m.perm = permFromFileMode(info.Mode())
+	m.permChanged = false
+	m.octalInput.SetValue(formatPerm(m.perm))
+	m.curOwner, m.curGroup = metadata.GetOwnerAndGroup(info)
+	m.ownerInput.SetValue("")
+	m.groupInput.SetValue("")
+	m.ownerInput.Placeholder = "unchanged " + m.curOwner
+	m.groupInput.Placeholder = "unchanged " + m.curGroup
+	m.octalErr, m.ownerErr, m.groupErr = nil, nil, nil
+	m.recursive = false
+	m.target = AllItems
+	m.gridRow, m.gridCol = 0, 0
+	m.setFocus(gridField)
+	return nil
+}
+
+func (m *Model) Close() {
+	m.open = false
+	m.justOpened = false
+	m.items = nil
+	m.setFocus(gridField)
+	m.octalInput.SetValue("")
+	m.ownerInput.SetValue("")
+	m.groupInput.SetValue("")
+}
+
+func (m *Model) IsOpen() bool {
+	return m.open
+}
+
+func (m *Model) setFocus(f field) {
+	m.focus = f
+	for _, input := range []*textinput.Model{&m.octalInput, &m.ownerInput, &m.groupInput} {
+		input.Blur()
+	}
+	if input := m.focusedInput(); input != nil {
+		_ = input.Focus()
+	}
+	// A partially typed mode is replaced by the current one
+	if f != octalField && m.octalErr != nil {
+		m.octalErr = nil
+		m.octalInput.SetValue(formatPerm(m.perm))
+	}
+}
+
+func (m *Model) focusedInput() *textinput.Model {
+	switch m.focus {
+	case octalField:
+		return &m.octalInput
+	case ownerField:
+		return &m.ownerInput
+	case groupField:
+		return &m.groupInput
+	case gridField, recursiveField, targetField, fieldCnt:
+	}
+	return nil
+}
+
+// gridBit is the permission bit of row, which is owner, group or others, and col,
+// which is read, write or execute
+func gridBit(row int, col int) uint32 {
+	return 1 << ((2-row)*3 + (2 - col))
+}
+
+func formatPerm(perm uint32) string {
+	return fmt.Sprintf("%03o", perm)
+}
@@ -0,0 +1,168 @@
+package permissions
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/utils"
+)
+
+func TestMain(m *testing.M) {
+	common.Hotkeys.ConfirmTyping = []string{"enter"}
+	common.Hotkeys.CancelTyping = []string{"esc"}
+	common.Hotkeys.ChangePermissions = []string{"M"}
+	m.Run()
+}
+
+func TestPermConversion(t *testing.T) {
+	testdata := []struct {
+		mode os.FileMode
+		perm uint32
+	}{
+		{mode: 0o644, perm: 0o644},
+		{mode: 0o755 | os.ModeSetuid, perm: 0o4755},
+		{mode: 0o775 | os.ModeSetgid | os.ModeSticky, perm: 0o3775},
+	}
+	for _, tt := range testdata {
+		assert.Equal(t, tt.perm, permFromFileMode(tt.mode))
+		assert.Equal(t, tt.mode, fileModeFromPerm(tt.perm))
+	}
+	assert.Equal(t, uint32(0o644), permFromFileMode(os.ModeDir|0o644), "type bits are not permissions")
+}
+
+func TestParseOctal(t *testing.T) {
+	testdata := []struct {
+		input       string
+		expected    uint32
+		expectError bool
+	}{
+		{input: "644", expected: 0o644},
+		{input: "0755", expected: 0o755},
+		{input: "4755", expected: 0o4755},
+		{input: "64", expectError: true},
+		{input: "648", expectError: true},
+		{input: "rwx", expectError: true},
+		{input: "07777", expectError: true},
+	}
+	for _, tt := range testdata {
+		t.Run(tt.input, func(t *testing.T) {
+			perm, err := parseOctal(tt.input)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, perm)
+		})
+	}
+}
+
+func setupTestModel(t *testing.T, mode os.FileMode) (Model, string) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "file.txt")
+	utils.SetupFiles(t, file)
+	require.NoError(t, os.Chmod(file, mode))
+	m := GenerateModel(20, 60)
+	require.NoError(t, m.Open([]string{file}))
+	return m, file
+}
+
+func sendKeys(m *Model, keys ...tea.KeyMsg) {
+	for _, key := range keys {
+		m.HandleUpdate(key)
+	}
+}
+
+func runeKey(r rune) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}
+}
+
+func TestModeEditing(t *testing.T) {
+	if runtime.GOOS == utils.OsWindows {
+		t.Skip("Unix permissions are not available on Windows")
+	}
+
+	t.Run("Grid updates octal", func(t *testing.T) {
+		m, file := setupTestModel(t, 0o644)
+		assert.Equal(t, "644", m.octalInput.Value())
+		// Owner execute, then others read
+		sendKeys(&m, tea.KeyMsg{Type: tea.KeyRight}, tea.KeyMsg{Type: tea.KeyRight}, runeKey(' '),
+			tea.KeyMsg{Type: tea.KeyLeft}, tea.KeyMsg{Type: tea.KeyLeft}, tea.KeyMsg{Type: tea.KeyUp}, runeKey(' '))
+		assert.Equal(t, "740", m.octalInput.Value())
+
+		action, _ := m.HandleUpdate(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Equal(t, common.ChangePermissionsAction{
+			Items:   []string{file},
+			SetMode: true,
+			Mode:    0o740,
+			UID:     -1,
+			GID:     -1,
+		}, action)
+		assert.False(t, m.IsOpen())
+	})
+
+	t.Run("Octal updates grid", func(t *testing.T) {
+		m, _ := setupTestModel(t, 0o644)
+		sendKeys(&m, tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyBackspace},
+			tea.KeyMsg{Type: tea.KeyBackspace}, tea.KeyMsg{Type: tea.KeyBackspace})
+		require.Error(t, m.octalErr)
+		action, _ := m.HandleUpdate(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Equal(t, common.NoAction{}, action, "confirm is refused with an invalid mode")
+
+		sendKeys(&m, runeKey('7'), runeKey('0'), runeKey('0'))
+		require.NoError(t, m.octalErr)
+		assert.Equal(t, uint32(0o700), m.perm)
+		assert.Contains(t, m.renderGridRow(0), "[x] ")
+		assert.NotContains(t, m.renderGridRow(1), "[x]")
+	})
+
+	t.Run("No change", func(t *testing.T) {
+		m, _ := setupTestModel(t, 0o644)
+		action, _ := m.HandleUpdate(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Equal(t, common.NoAction{}, action)
+		assert.False(t, m.IsOpen())
+	})
+}
+
+func TestOwnerValidation(t *testing.T) {
+	if runtime.GOOS == utils.OsWindows {
+		t.Skip("Changing the owner is not supported on Windows")
+	}
+	cur, err := user.Current()
+	require.NoError(t, err)
+
+	m, file := setupTestModel(t, 0o644)
+	sendKeys(&m, tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyTab})
+	for _, r := range "no-such-user-spf" {
+		sendKeys(&m, runeKey(r))
+	}
+	require.Error(t, m.ownerErr)
+	assert.Contains(t, m.Render(), "unknown user")
+
+	m.ownerInput.SetValue(cur.Username)
+	sendKeys(&m, tea.KeyMsg{Type: tea.KeyEnd})
+	require.NoError(t, m.ownerErr)
+	// Recursive, directories only
+	sendKeys(&m, tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyTab}, runeKey(' '),
+		tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyRight})
+
+	action, _ := m.HandleUpdate(tea.KeyMsg{Type: tea.KeyEnter})
+	permAction, ok := action.(common.ChangePermissionsAction)
+	require.True(t, ok)
+	assert.Equal(t, []string{file}, permAction.Items)
+	assert.False(t, permAction.SetMode)
+	assert.Equal(t, cur.Uid, strconv.Itoa(permAction.UID))
+	assert.Equal(t, -1, permAction.GID)
+	assert.True(t, permAction.Recursive)
+	assert.True(t, permAction.DirsOnly)
+	assert.False(t, permAction.FilesOnly)
+}
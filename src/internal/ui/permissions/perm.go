@@ -0,0 +1,87 @@
+package permissions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+)
+
+// Special bits of os.FileMode, and their unix permission bits
+var specialBits = []struct {
+	mode os.FileMode
+	perm uint32
+}{
+	{mode: os.ModeSetuid, perm: 0o4000},
+	{mode: os.ModeSetgid, perm: 0o2000},
+	{mode: os.ModeSticky, perm: 0o1000},
+}
+
+// permFromFileMode returns the unix permission bits of mode, like 0o4755
+func permFromFileMode(mode os.FileMode) uint32 {
+	perm := uint32(mode.Perm())
+	for _, bit := range specialBits {
+		if mode&bit.mode != 0 {
+			perm |= bit.perm
+		}
+	}
+	return perm
+}
+
+// fileModeFromPerm is the reverse of permFromFileMode
+func fileModeFromPerm(perm uint32) os.FileMode {
+	mode := os.FileMode(perm) & os.ModePerm
+	for _, bit := range specialBits {
+		if perm&bit.perm != 0 {
+			mode |= bit.mode
+		}
+	}
+	return mode
+}
+
+// parseOctal parses a mode of 3 or 4 octal digits, like 644 or 0755
+func parseOctal(s string) (uint32, error) {
+	if len(s) != 3 && len(s) != 4 {
+		return 0, errors.New("expected 3 or 4 octal digits")
+	}
+	perm, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || perm > maxPerm {
+		return 0, fmt.Errorf("invalid octal mode %q", s)
+	}
+	return uint32(perm), nil
+}
+
+// lookupUID returns the id of the user with the given name or id, which must
+// exist in the user database
+func lookupUID(name string) (int, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		u, err = user.LookupId(name)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("unknown user %q", name)
+	}
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return 0, errors.New("changing the owner is not supported on this system")
+	}
+	return uid, nil
+}
+
+// lookupGID returns the id of the group with the given name or id, which must
+// exist in the group database
+func lookupGID(name string) (int, error) {
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		g, err = user.LookupGroupId(name)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("unknown group %q", name)
+	}
+	gid, err := strconv.Atoi(g.Gid)
+	if err != nil {
+		return 0, errors.New("changing the group is not supported on this system")
+	}
+	return gid, nil
+}
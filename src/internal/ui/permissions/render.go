@@ -0,0 +1,83 @@
+package permissions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yorukot/superfile/src/internal/common"
+	"github.com/yorukot/superfile/src/internal/ui"
+)
+
+var (
+	gridRowLabels = [3]string{"Owner", "Group", "Others"}
+	gridColLabels = [3]string{"Read", "Write", "Exec"}
+)
+
+func (m *Model) Render() string {
+	r := ui.PermissionsRenderer(m.maxHeight, m.width)
+	r.SetBorderTitle(m.headline)
+
+	r.AddLines(m.renderLabel(gridField, "Mode") + strings.Join(gridColLabels[:], " "))
+	for row := range 3 {
+		r.AddLines(m.renderGridRow(row))
+	}
+	r.AddLines(m.renderLabel(octalField, "Octal") + m.octalInput.View())
+	r.AddLines(m.renderLabel(ownerField, "Owner") + m.ownerInput.View())
+	r.AddLines(m.renderLabel(groupField, "Group") + m.groupInput.View())
+	r.AddLines(m.renderLabel(recursiveField, "Recursive") + checkbox(m.recursive))
+	r.AddLines(m.renderLabel(targetField, "Apply to") + "< " + m.target.String() + " >")
+
+	r.AddSection()
+	r.AddLines(m.renderStatus())
+	r.AddLines(common.ModalTitleStyle.Render(fmt.Sprintf(" %s/%s: switch field, space: toggle",
+		nextFieldKey, prevFieldKey)))
+	return r.Render()
+}
+
+func (m *Model) renderLabel(f field, label string) string {
+	label = fmt.Sprintf(" %-*s", labelWidth-1, label+":")
+	if m.focus == f {
+		return common.ModalCursorStyle.Render(label)
+	}
+	return label
+}
+
+func (m *Model) renderGridRow(row int) string {
+	var line strings.Builder
+	line.WriteString(fmt.Sprintf("   %-*s", labelWidth-3, gridRowLabels[row]))
+	for col := range 3 {
+		cell := fmt.Sprintf("%-*s", len(gridColLabels[col]), checkbox(m.perm&gridBit(row, col) != 0))
+		if m.focus == gridField && row == m.gridRow && col == m.gridCol {
+			cell = common.ModalCursorStyle.Render(cell)
+		}
+		line.WriteString(cell + " ")
+	}
+	return line.String()
+}
+
+func (m *Model) renderStatus() string {
+	if err := m.firstError(); err != nil {
+		return common.ModalErrorStyle.Render(" " + err.Error())
+	}
+	mode := "mode unchanged"
+	if m.permChanged {
+		mode = "mode " + formatPerm(m.perm)
+	}
+	return fmt.Sprintf(" %d items, %s", len(m.items), mode)
+}
+
+func (m *Model) firstError() error {
+	for _, err := range []error{m.octalErr, m.ownerErr, m.groupErr} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkbox(checked bool) string {
+	if checked {
+		return "[x]"
+	}
+	return "[ ]"
+}
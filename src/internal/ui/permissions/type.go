@@ -0,0 +1,76 @@
+package permissions
+
+import "github.com/charmbracelet/bubbles/textinput"
+
+// Model is the modal editing the permissions and ownership of items
+type Model struct {
+	// Configuration
+	headline string
+
+	// State
+	open       bool
+	justOpened bool // Flag to ignore the opening keystroke
+	items      []string
+	// Unix permission bits, like 0o755, including setuid, setgid and sticky
+	perm uint32
+	// Whether perm was changed by the user, and must be applied
+	permChanged bool
+	focus       field
+	// Cell of the rwx grid under the cursor
+	gridRow int
+	gridCol int
+
+	octalInput textinput.Model
+	ownerInput textinput.Model
+	groupInput textinput.Model
+	recursive  bool
+	target     Target
+
+	// Current owner and group of the first item, shown as placeholders
+	curOwner string
+	curGroup string
+
+	// Validation errors of the inputs
+	octalErr error
+	ownerErr error
+	groupErr error
+
+	// Dimensions, set by the main model as the terminal is resized
+	width     int
+	maxHeight int
+}
+
+// Target is the kind of items that changes are applied to
+type Target int
+
+const (
+	AllItems Target = iota
+	DirsOnly
+	FilesOnly
+	targetCnt
+)
+
+func (t Target) String() string {
+	switch t {
+	case AllItems:
+		return "All items"
+	case DirsOnly:
+		return "Directories only"
+	case FilesOnly:
+		return "Files only"
+	case targetCnt:
+	}
+	return "Unknown"
+}
+
+type field int
+
+const (
+	gridField field = iota
+	octalField
+	ownerField
+	groupField
+	recursiveField
+	targetField
+	fieldCnt
+)
@@ -0,0 +1,33 @@
+package permissions
+
+import "log/slog"
+
+func (m *Model) GetWidth() int {
+	return m.width
+}
+
+func (m *Model) GetMaxHeight() int {
+	return m.maxHeight
+}
+
+func (m *Model) SetWidth(width int) {
+	if width < PermissionsMinWidth {
+		slog.Warn("Permissions modal initialized with too less width", "width", width)
+		width = PermissionsMinWidth
+	}
+	m.width = width
+	// Excluding borders(2), label, and one extra character that is appended
+	// by textInput.View()
+	inputWidth := width - 2 - labelWidth - 1
+	m.octalInput.Width = inputWidth
+	m.ownerInput.Width = inputWidth
+	m.groupInput.Width = inputWidth
+}
+
+func (m *Model) SetMaxHeight(maxHeight int) {
+	if maxHeight < PermissionsMinHeight {
+		slog.Warn("Permissions modal initialized with too less maxHeight", "maxHeight", maxHeight)
+		maxHeight = PermissionsMinHeight
+	}
+	m.maxHeight = maxHeight
+}
@@ -133,7 +133,17 @@ func renderDirectoryPreview(r *rendering.Renderer, itemPath string, previewHeigh
 		r.AddLines(common.FilePreviewDirectoryUnreadableText)
 		return r.Render()
 	}
+	return renderDirEntries(r, files, previewHeight)
+}
+
+// RenderWithDirEntries renders the preview of a directory that is not on disk, like the
+// ones inside archives, from its entries
+func (m *Model) RenderWithDirEntries(files []os.DirEntry) string {
+	r := ui.FilePreviewPanelRenderer(m.height, m.width)
+	return renderDirEntries(r, files, m.height) + m.imagePreviewer.ClearKittyImages()
+}
 
+func renderDirEntries(r *rendering.Renderer, files []os.DirEntry, previewHeight int) string {
 	if len(files) == 0 {
 		r.AddLines(common.FilePreviewEmptyText)
 		return r.Render()
@@ -8,4 +8,10 @@ const (
 	// This should allow smooth tracking of 5-10 active processes
 	// In case we have issues in future, we could attempt to change this
 	msgChannelSize = 50
+
+	// Speed and ETA of a process are not rendered if it leaves less than this
+	// much width for the process name
+	minNameWidthWithInfo = 10
+
+	queuedText = "Queued"
 )
@@ -22,3 +22,19 @@ type ProcessAlreadyExistsError struct {
 func (p *ProcessAlreadyExistsError) Error() string {
 	return "process already exists with id : " + p.id
 }
+
+type ProcessNotCancellableError struct {
+	id string
+}
+
+func (p *ProcessNotCancellableError) Error() string {
+	return "process cannot be cancelled, id : " + p.id
+}
+
+type ProcessNotQueuedError struct {
+	id string
+}
+
+func (p *ProcessNotQueuedError) Error() string {
+	return "process is not queued, id : " + p.id
+}
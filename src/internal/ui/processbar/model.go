@@ -1,8 +1,11 @@
 package processbar
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"sync/atomic"
+	"time"
 
 	"github.com/yorukot/superfile/src/internal/common"
 	"github.com/yorukot/superfile/src/internal/ui"
@@ -17,12 +20,20 @@ type Model struct {
 	height int
 	width  int
 
-	// TODO: Fix this. No mechanism to remove completed processes from memory
-	// processes map grows indefinitely
-	// Maybe, TTL or cleanup mechanism for successful/failed processes
+	// Finished processes are removed common.Config.FinishedProcessTTL seconds after
+	// they finish, or when the user clears them
 	processes map[string]Process
-	msgChan   chan UpdateMsg
-	reqCnt    int
+	// Cancel functions of the running cancellable processes, keyed by Process.ID
+	// Only accessed on the bubbletea goroutine, via UpdateMsg.Apply()
+	cancelFuncs map[string]context.CancelFunc
+	msgChan     chan UpdateMsg
+	// Shared by the copies of the model, as both the workers and the bubbletea
+	// goroutine send messages
+	reqCnt *atomic.Int64
+	// Shared by the copies of the model, as the workers wait in it
+	queue *queue
+	// Called by the workers with the final state of each process, see AddFinishHook()
+	finishHooks []func(Process)
 }
 
 func New() Model {
@@ -36,8 +47,10 @@ func NewModelWithOptions(width int, height int) Model {
 		renderIndex: 0,
 		cursor:      0,
 		processes:   make(map[string]Process),
+		cancelFuncs: make(map[string]context.CancelFunc),
 		msgChan:     make(chan UpdateMsg, msgChannelSize),
-		reqCnt:      0,
+		reqCnt:      &atomic.Int64{},
+		queue:       newQueue(),
 	}
 	m.SetDimensions(width, height)
 	return m
@@ -76,6 +89,135 @@ func (m *Model) UpdateExistingProcess(p Process) error {
 	return nil
 }
 
+func (m *Model) setCancelFunc(id string, cancel context.CancelFunc) {
+	if m.cancelFuncs == nil {
+		m.cancelFuncs = make(map[string]context.CancelFunc)
+	}
+	m.cancelFuncs[id] = cancel
+}
+
+// Release the context of a process once it is not running anymore
+func (m *Model) releaseCancelFunc(p Process) {
+	if !p.State.IsFinished() {
+		return
+	}
+	if cancel, ok := m.cancelFuncs[p.ID]; ok {
+		cancel()
+		delete(m.cancelFuncs, p.ID)
+	}
+}
+
+// CancelProcess requests cancellation of a running process. The process itself
+// is marked Cancelled by its worker, once it stops.
+func (m *Model) CancelProcess(id string) error {
+	p, ok := m.processes[id]
+	if !ok {
+		return &NoProcessFoundError{id: id}
+	}
+	cancel, ok := m.cancelFuncs[id]
+	if !ok || p.State.IsFinished() {
+		return &ProcessNotCancellableError{id: id}
+	}
+	slog.Debug("Cancelling process", "id", id, "name", p.Name)
+	cancel()
+	return nil
+}
+
+// CancelSelectedProcess cancels the process under the cursor
+func (m *Model) CancelSelectedProcess() error {
+	p, err := m.GetSelectedProcess()
+	if err != nil {
+		return err
+	}
+	return m.CancelProcess(p.ID)
+}
+
+// StartSelectedProcess starts the queued process under the cursor, without waiting for
+// the processes before it
+func (m *Model) StartSelectedProcess() error {
+	p, err := m.GetSelectedProcess()
+	if err != nil {
+		return err
+	}
+	if !m.queue.startNow(p.ID) {
+		return &ProcessNotQueuedError{id: p.ID}
+	}
+	slog.Debug("Starting queued process", "id", p.ID, "name", p.Name)
+	return nil
+}
+
+// MoveSelectedProcess moves the queued process under the cursor by delta places in its
+// queue. The cursor follows it.
+func (m *Model) MoveSelectedProcess(delta int) error {
+	p, err := m.GetSelectedProcess()
+	if err != nil {
+		return err
+	}
+	if !m.queue.move(p.ID, delta) {
+		return &ProcessNotQueuedError{id: p.ID}
+	}
+	m.setCursorOnProcess(p.ID)
+	return nil
+}
+
+// GetSelectedProcess returns the process under the cursor
+func (m *Model) GetSelectedProcess() (Process, error) {
+	processes := m.getSortedProcesses()
+	if m.cursor < 0 || m.cursor >= len(processes) {
+		return Process{}, &NoProcessFoundError{id: ""}
+	}
+	return processes[m.cursor], nil
+}
+
+// AddFinishHook makes hook be called with each process once it is finished. It is called
+// by the worker of the process, when it sends the final update, so it must be safe for
+// concurrent use. Hooks must be added before any process is started.
+func (m *Model) AddFinishHook(hook func(Process)) {
+	m.finishHooks = append(m.finishHooks, hook)
+}
+
+// ClearFinishedProcesses removes all the finished processes from the process bar
+func (m *Model) ClearFinishedProcesses() {
+	m.removeProcesses(func(p Process) bool {
+		return p.State.IsFinished()
+	})
+}
+
+// removeExpiredProcesses removes the processes that finished at least ttl ago
+func (m *Model) removeExpiredProcesses(ttl time.Duration, now time.Time) {
+	m.removeProcesses(func(p Process) bool {
+		return p.State.IsFinished() && !p.DoneTime.Add(ttl).After(now)
+	})
+}
+
+// removeProcesses removes the processes for which remove returns true. The cursor stays
+// on the same process, if it is kept.
+func (m *Model) removeProcesses(remove func(Process) bool) {
+	selectedID := ""
+	if p, err := m.GetSelectedProcess(); err == nil {
+		selectedID = p.ID
+	}
+	cntRemoved := 0
+	for id, p := range m.processes {
+		if remove(p) {
+			delete(m.processes, id)
+			cntRemoved++
+		}
+	}
+	if cntRemoved == 0 {
+		return
+	}
+	slog.Debug("Removed finished processes", "count", cntRemoved)
+	if _, ok := m.processes[selectedID]; ok {
+		m.setCursorOnProcess(selectedID)
+	} else {
+		m.cursor = max(0, min(m.cursor, m.cntProcesses()-1))
+	}
+	// Show as many processes as possible once the last ones are gone
+	m.renderIndex = min(m.renderIndex, m.cursor,
+		max(0, m.cntProcesses()-cntRenderableProcess(m.viewHeight())))
+}
+
 func (m *Model) GetByID(id string) (Process, bool) {
 	p, ok := m.processes[id]
 	return p, ok
@@ -83,7 +225,7 @@ func (m *Model) GetByID(id string) (Process, bool) {
 
 func (m *Model) HasRunningProcesses() bool {
 	for _, data := range m.processes {
-		if data.State == InOperation && data.Done != data.Total {
+		if data.State == Queued || (data.State == InOperation && (data.TotalPending || data.Done != data.Total)) {
 			return true
 		}
 	}
@@ -106,6 +248,7 @@ func (m *Model) Render(processBarFocussed bool) string {
 	r.SetBorderInfoItems(fmt.Sprintf("%d/%d", m.cursor+1, m.cntProcesses()))
 
 	renderedHeight := 0
+	now := time.Now()
 	processes := m.getSortedProcesses()
 	for i := m.renderIndex; i < len(processes); i++ {
 		// We allow rendering of a process if we have at least 2 lines left
@@ -130,19 +273,20 @@ func (m *Model) Render(processBarFocussed bool) string {
 			cursor = common.FooterCursorStyle.Render("  ")
 		}
 
+		nameWidth := m.viewWidth() - 7
+		info := curProcess.statusInfo(now)
+		// Only show the info if the name still has some room
+		if info != "" && nameWidth-len(info)-1 >= minNameWidthWithInfo {
+			nameWidth -= len(info) + 1
+			info = " " + info
+		} else {
+			info = ""
+		}
 		r.AddLines(cursor + common.FooterStyle.Render(
-			common.TruncateText(curProcess.Name, m.viewWidth()-7, "...")+" ") +
+			common.TruncateText(curProcess.Name, nameWidth, "...")+info+" ") +
 			curProcess.State.Icon())
 
-		// calculate progress percentage
-		// if the total is 0, that means the process only have directory
-		// so we can set the progress to 100%
-		if curProcess.Total != 0 {
-			progressPercentage := float64(curProcess.Done) / float64(curProcess.Total)
-			r.AddLines(cursor+curProcess.Progress.ViewAs(progressPercentage), "")
-		} else {
-			r.AddLines(cursor + curProcess.Progress.ViewAs(1))
-		}
+		r.AddLines(cursor+curProcess.Progress.ViewAs(curProcess.progressRatio()), "")
 	}
 
 	return r.Render()
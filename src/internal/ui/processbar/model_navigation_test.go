@@ -33,6 +33,7 @@ func genProcessBarModel(count int, cursor int, render int, viewHeight int) Model
 		renderIndex: render,
 		width:       minWidth,
 		height:      viewHeight + 2,
+		queue:       newQueue(),
 	}
 }
 
@@ -1,10 +1,12 @@
 package processbar
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -103,3 +105,240 @@ func TestModelSetDimenstions(t *testing.T) {
 	assert.Equal(t, minHeight, m.height, "Min value should be set")
 	assert.Equal(t, minWidth+1, m.width, "Given value should be set")
 }
+
+func TestModelCancelProcess(t *testing.T) {
+	m := New()
+	// Apply the next message in the channel, like the listener would
+	applyNextMsg := func() {
+		t.Helper()
+		_, err := (<-m.msgChan).Apply(&m)
+		require.NoError(t, err)
+	}
+
+	p, ctx, err := m.SendAddCancellableProcessMsg("cancellable", 10, false)
+	require.NoError(t, err)
+	applyNextMsg()
+
+	pNonCancellable, err := m.SendAddProcessMsg("non cancellable", 10, false)
+	require.NoError(t, err)
+	applyNextMsg()
+
+	var errNotCancellable *ProcessNotCancellableError
+	require.ErrorAs(t, m.CancelProcess(pNonCancellable.ID), &errNotCancellable)
+	var errNotFound *NoProcessFoundError
+	require.ErrorAs(t, m.CancelProcess("invalid"), &errNotFound)
+
+	require.NoError(t, ctx.Err())
+	require.NoError(t, m.CancelProcess(p.ID))
+	require.ErrorIs(t, ctx.Err(), context.Canceled)
+
+	// Worker finishes the process
+	p.State = Cancelled
+	p.Done = 4
+	require.NoError(t, m.SendUpdateProcessMsg(p, false))
+	applyNextMsg()
+
+	pRes, ok := m.GetByID(p.ID)
+	require.True(t, ok)
+	assert.Equal(t, Cancelled, pRes.State)
+	assert.Equal(t, 4, pRes.Done)
+	assert.NotContains(t, m.cancelFuncs, p.ID, "Finished process should not be cancellable")
+	require.ErrorAs(t, m.CancelProcess(p.ID), &errNotCancellable)
+}
+
+func TestModelCancelSelectedProcess(t *testing.T) {
+	m := New()
+	var errNotFound *NoProcessFoundError
+	require.ErrorAs(t, m.CancelSelectedProcess(), &errNotFound, "Empty process bar")
+
+	p, ctx, err := m.SendAddCancellableProcessMsg("cancellable", 10, false)
+	require.NoError(t, err)
+	_, err = (<-m.msgChan).Apply(&m)
+	require.NoError(t, err)
+
+	require.NoError(t, m.CancelSelectedProcess())
+	require.ErrorIs(t, ctx.Err(), context.Canceled)
+	_, ok := m.GetByID(p.ID)
+	assert.True(t, ok)
+}
+
+func TestModelQueuedProcesses(t *testing.T) {
+	limit := common.Config.OperationsPerDevice
+	common.Config.OperationsPerDevice = 1
+	t.Cleanup(func() { common.Config.OperationsPerDevice = limit })
+
+	m := NewModelWithOptions(40, 20)
+	applyNextMsg := func() {
+		t.Helper()
+		_, err := (<-m.msgChan).Apply(&m)
+		require.NoError(t, err)
+	}
+	getState := func(id string) ProcessState {
+		t.Helper()
+		p, ok := m.GetByID(id)
+		require.True(t, ok)
+		return p.State
+	}
+	type queuedRes struct {
+		p   Process
+		err error
+	}
+	// Add a process that waits in the queue, the result is sent once it starts
+	addQueued := func(name string, key string) (chan queuedRes, Process) {
+		t.Helper()
+		res := make(chan queuedRes, 1)
+		go func() {
+			p, _, err := m.SendAddQueuedProcessMsg(name, 1, key)
+			res <- queuedRes{p: p, err: err}
+		}()
+		msg, ok := (<-m.msgChan).(newProcessMsg)
+		require.True(t, ok)
+		_, err := msg.Apply(&m)
+		require.NoError(t, err)
+		return res, msg.NewProcess
+	}
+
+	running, _, err := m.SendAddQueuedProcessMsg("running", 1, "dev1")
+	require.NoError(t, err)
+	applyNextMsg()
+	otherDev, _, err := m.SendAddQueuedProcessMsg("other device", 1, "dev2")
+	require.NoError(t, err)
+	applyNextMsg()
+	assert.Equal(t, InOperation, getState(running.ID))
+	assert.Equal(t, InOperation, getState(otherDev.ID), "Processes of other devices should not wait")
+
+	secondRes, second := addQueued("second", "dev1")
+	thirdRes, third := addQueued("third", "dev1")
+	assert.Equal(t, Queued, second.State)
+	assert.Equal(t, Queued, third.State)
+	assert.Contains(t, m.Render(true), queuedText)
+
+	// The third process is moved before the second one
+	m.setCursorOnProcess(third.ID)
+	require.Equal(t, 3, m.cursor)
+	require.NoError(t, m.MoveSelectedProcess(-1))
+	assert.Equal(t, 2, m.cursor, "Cursor should follow the moved process")
+	var errNotQueued *ProcessNotQueuedError
+	require.ErrorAs(t, m.MoveSelectedProcess(-1), &errNotQueued, "Process is already first")
+
+	running.State = Successful
+	require.NoError(t, m.SendUpdateProcessMsg(running, true))
+	applyNextMsg()
+	res := <-thirdRes
+	require.NoError(t, res.err)
+	applyNextMsg()
+	assert.Equal(t, InOperation, getState(third.ID), "Next process should start once the first one is done")
+	assert.Equal(t, Queued, getState(second.ID))
+
+	// Started right away, over the limit
+	m.setCursorOnProcess(second.ID)
+	require.NoError(t, m.StartSelectedProcess())
+	res = <-secondRes
+	require.NoError(t, res.err)
+	applyNextMsg()
+	assert.Equal(t, InOperation, getState(second.ID))
+	require.ErrorAs(t, m.StartSelectedProcess(), &errNotQueued)
+
+	cancelledRes, cancelled := addQueued("cancelled", "dev1")
+	require.NoError(t, m.CancelProcess(cancelled.ID))
+	res = <-cancelledRes
+	require.ErrorIs(t, res.err, context.Canceled)
+	applyNextMsg()
+	assert.Equal(t, Cancelled, getState(cancelled.ID))
+	assert.Empty(t, m.queue.positions())
+}
+
+func TestModelRemoveQueuedProcess(t *testing.T) {
+	limit := common.Config.OperationsPerDevice
+	common.Config.OperationsPerDevice = 1
+	t.Cleanup(func() { common.Config.OperationsPerDevice = limit })
+
+	m := NewModelWithOptions(40, 20)
+	removed, _, err := m.SendAddQueuedProcessMsg("removed", 1, "dev1")
+	require.NoError(t, err)
+	_, err = (<-m.msgChan).Apply(&m)
+	require.NoError(t, err)
+
+	started := make(chan error, 1)
+	go func() {
+		_, _, err := m.SendAddQueuedProcessMsg("next", 1, "dev1")
+		started <- err
+	}()
+	_, err = (<-m.msgChan).Apply(&m)
+	require.NoError(t, err)
+
+	m.SendRemoveProcessMsg(removed.ID)
+	require.NoError(t, <-started, "Next process should start once the removed one releases its slot")
+	_, err = (<-m.msgChan).Apply(&m)
+	require.NoError(t, err)
+	_, ok := m.GetByID(removed.ID)
+	assert.False(t, ok)
+}
+
+func TestModelRemoveFinishedProcesses(t *testing.T) {
+	ttl := common.Config.FinishedProcessTTL
+	common.Config.FinishedProcessTTL = 0
+	t.Cleanup(func() { common.Config.FinishedProcessTTL = ttl })
+
+	now := time.Now()
+	newFinished := func(id string, state ProcessState, doneTime time.Time) Process {
+		p := NewProcess(id, id, 1)
+		p.State = state
+		p.DoneTime = doneTime
+		return p
+	}
+	setup := func() Model {
+		m := NewModelWithOptions(40, 20)
+		m.AddOrUpdateProcess(NewProcess("running", "running", 10))
+		m.AddOrUpdateProcess(newFinished("old", Successful, now.Add(-10*time.Minute)))
+		m.AddOrUpdateProcess(newFinished("recent", Failed, now.Add(-time.Second)))
+		return m
+	}
+
+	t.Run("Finished hooks are called once finished", func(t *testing.T) {
+		m := New()
+		var finished []string
+		m.AddFinishHook(func(p Process) { finished = append(finished, p.ID) })
+		p, err := m.SendAddProcessMsg("process", 1, false)
+		require.NoError(t, err)
+		p.Done = 1
+		require.NoError(t, m.SendUpdateProcessMsg(p, false))
+		assert.Empty(t, finished, "Running process should not call the hooks")
+		p.State = Successful
+		require.NoError(t, m.SendUpdateProcessMsg(p, false))
+		assert.Equal(t, []string{p.ID}, finished)
+	})
+
+	t.Run("Expired processes are removed", func(t *testing.T) {
+		m := setup()
+		m.cursor = 2
+		m.removeExpiredProcesses(5*time.Minute, now)
+		assert.ElementsMatch(t, []string{"running", "recent"}, processIDs(&m))
+		assert.Equal(t, 1, m.cursor, "Cursor on a removed process should move to the last one")
+		assert.True(t, m.isValid())
+	})
+
+	t.Run("Clear finished processes", func(t *testing.T) {
+		m := setup()
+		m.cursor = 2
+		m.ClearFinishedProcesses()
+		assert.Equal(t, []string{"running"}, processIDs(&m))
+		assert.Equal(t, 0, m.cursor)
+		assert.True(t, m.isValid())
+	})
+
+	t.Run("Expiry message", func(t *testing.T) {
+		m := setup()
+		_, err := expireProcessesMsg{ttl: time.Minute}.Apply(&m)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"running", "recent"}, processIDs(&m))
+	})
+}
+
+func processIDs(m *Model) []string {
+	var ids []string
+	for _, p := range m.getSortedProcesses() {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
@@ -1,7 +1,9 @@
 package processbar
 
 import (
+	"context"
 	"log/slog"
+	"time"
 )
 
 // Only used in tests, to have processbar used in a standalone way without model
@@ -72,13 +74,94 @@ func (m *Model) SendAddProcessMsg(name string, total int, blockingSend bool) (Pr
 	return p, nil
 }
 
+// SendAddCancellableProcessMsg is like SendAddProcessMsg, but the returned context is
+// cancelled when the user cancels the process. The worker must check it, and finish
+// the process with Cancelled state.
+func (m *Model) SendAddCancellableProcessMsg(name string, total int,
+	blockingSend bool) (Process, context.Context, error) {
+	id := m.newUUIDForProcess()
+	p := NewProcess(id, name, total)
+	ctx, cancel := context.WithCancel(context.Background())
+	msg := newProcessMsg{
+		NewProcess: p,
+		cancel:     cancel,
+		BaseMsg:    BaseMsg{reqID: m.newReqCnt()},
+	}
+	err := m.sendMsgToChannel(msg, blockingSend)
+	if err != nil {
+		cancel()
+		// Return zero-value process to indicate failure
+		return Process{}, nil, err
+	}
+	return p, ctx, nil
+}
+
+// SendAddQueuedProcessMsg is like SendAddCancellableProcessMsg, but the process only
+// starts once fewer than common.Config.OperationsPerDevice processes with the same
+// queueKey are running. Until then, it is shown as Queued, and this blocks. If it is
+// cancelled while queued, it is finished with Cancelled state, and context.Canceled is
+// returned. The next process starts once this one sends its final update.
+func (m *Model) SendAddQueuedProcessMsg(name string, total int, queueKey string) (Process, context.Context, error) {
+	id := m.newUUIDForProcess()
+	p := NewProcess(id, name, total)
+	start := m.queue.acquire(id, queueKey)
+	if start != nil {
+		p.State = Queued
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	msg := newProcessMsg{
+		NewProcess: p,
+		cancel:     cancel,
+		BaseMsg:    BaseMsg{reqID: m.newReqCnt()},
+	}
+	m.sendMsgToChannelBlocking(msg)
+	if start == nil {
+		return p, ctx, nil
+	}
+
+	select {
+	case <-start:
+	case <-ctx.Done():
+		m.queue.leave(id)
+		p.State = Cancelled
+		p.DoneTime = time.Now()
+		_ = m.SendUpdateProcessMsg(p, true)
+		return Process{}, nil, ctx.Err()
+	}
+	slog.Debug("Queued process started", "id", id, "name", name)
+	p.State = InOperation
+	// The speed and time left are based on the time spent running
+	p.StartTime = time.Now()
+	m.sendMsgToChannelBlocking(updateProcessMsg{NewProcess: p, BaseMsg: BaseMsg{reqID: m.newReqCnt()}})
+	return p, ctx, nil
+}
+
+// SendUpdateProcessMsg sends the new state of p. If p is finished, this must be its final
+// update, as the finish hooks are called with it.
 func (m *Model) SendUpdateProcessMsg(p Process, blockingSend bool) error {
+	m.releaseQueueSlot(p)
+	if p.State.IsFinished() {
+		for _, hook := range m.finishHooks {
+			hook(p)
+		}
+	}
 	msg := updateProcessMsg{NewProcess: p, BaseMsg: BaseMsg{reqID: m.newReqCnt()}}
 	return m.sendMsgToChannel(msg, blockingSend)
 }
 
+// SendRemoveProcessMsg removes the process id without finishing it, for the processes that
+// only show the work done before an operation, or that are run again. The finish hooks are
+// not called, and its slot in the queue is released.
+func (m *Model) SendRemoveProcessMsg(id string) {
+	if m.queue != nil {
+		m.queue.release(id)
+	}
+	m.sendMsgToChannelBlocking(removeProcessMsg{id: id, BaseMsg: BaseMsg{reqID: m.newReqCnt()}})
+}
+
 // Non Blocking and can fail
 func (m *Model) TrySendingUpdateProcessMsg(p Process) {
+	m.releaseQueueSlot(p)
 	msg := updateProcessMsg{NewProcess: p, BaseMsg: BaseMsg{reqID: m.newReqCnt()}}
 	err := m.sendMsgToChannel(msg, false)
 	if err != nil {
@@ -89,3 +172,11 @@ func (m *Model) TrySendingUpdateProcessMsg(p Process) {
 func (m *Model) SendStopListeningMsgBlocking() {
 	m.sendMsgToChannelBlocking(stopListeningMsg{BaseMsg: BaseMsg{reqID: m.newReqCnt()}})
 }
+
+// releaseQueueSlot lets the next queued process start, once p is finished. It is done
+// by the worker, so that it doesn't wait for the update to be applied.
+func (m *Model) releaseQueueSlot(p Process) {
+	if p.State.IsFinished() && m.queue != nil {
+		m.queue.release(p.ID)
+	}
+}
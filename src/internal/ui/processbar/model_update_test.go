@@ -1,6 +1,10 @@
 package processbar
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
 
 func TestUpdateMsg(_ *testing.T) {
 	// TODO
@@ -15,3 +19,16 @@ func TestUpdateMsg(_ *testing.T) {
 	//     - Test m.IsListeningForUpdates()
 
 }
+
+func TestFinishedProcess(t *testing.T) {
+	p := NewProcess("1", "copy", 1)
+	_, finished := FinishedProcess(updateProcessMsg{NewProcess: p})
+	assert.False(t, finished, "a running process isn't finished")
+	_, finished = FinishedProcess(newProcessMsg{NewProcess: p})
+	assert.False(t, finished, "adding a process doesn't finish it")
+
+	p.State = Failed
+	res, finished := FinishedProcess(updateProcessMsg{NewProcess: p})
+	assert.True(t, finished)
+	assert.Equal(t, p, res)
+}
@@ -1,6 +1,7 @@
 package processbar
 
 import (
+	"slices"
 	"sort"
 
 	"github.com/lithammer/shortuuid"
@@ -39,23 +40,30 @@ func (m *Model) getSortedProcesses() []Process {
 	for _, p := range m.processes {
 		processes = append(processes, p)
 	}
+	positions := m.queue.positions()
 	// sort by the process
 	sort.Slice(processes, func(i, j int) bool {
-		doneI := (processes[i].State == Successful || processes[i].State == Failed)
-		doneJ := (processes[j].State == Successful || processes[j].State == Failed)
+		rankI := processes[i].sortRank()
+		rankJ := processes[j].sortRank()
 
-		// sort by done or not
-		if doneI != doneJ {
-			return !doneI
+		// Running first, then queued, then done
+		if rankI != rankJ {
+			return rankI < rankJ
 		}
 
-		// if both not done
-		if !doneI {
-			completionI := float64(processes[i].Done) / float64(processes[i].Total)
-			completionJ := float64(processes[j].Done) / float64(processes[j].Total)
-			return completionI < completionJ // Those who finish first will be ranked later.
+		switch processes[i].State {
+		case InOperation:
+			// Those who finish first will be ranked later.
+			return processes[i].progressRatio() < processes[j].progressRatio()
+		case Queued:
+			// In the order they will start
+			posI, posJ := positions[processes[i].ID], positions[processes[j].ID]
+			if posI != posJ {
+				return posI < posJ
+			}
+			return processes[i].StartTime.Before(processes[j].StartTime)
+		case Successful, Cancelled, Failed:
 		}
-
 		// if both done sort by the doneTime
 		return processes[j].DoneTime.Before(processes[i].DoneTime)
 	})
@@ -63,9 +71,23 @@ func (m *Model) getSortedProcesses() []Process {
 	return processes
 }
 
+// setCursorOnProcess moves the cursor to the process id, and scrolls to keep it visible
+func (m *Model) setCursorOnProcess(id string) {
+	idx := slices.IndexFunc(m.getSortedProcesses(), func(p Process) bool { return p.ID == id })
+	if idx < 0 {
+		return
+	}
+	m.cursor = idx
+	cntRenderable := cntRenderableProcess(m.viewHeight())
+	if m.cursor < m.renderIndex {
+		m.renderIndex = m.cursor
+	} else if m.cursor > m.renderIndex+cntRenderable-1 {
+		m.renderIndex = m.cursor - cntRenderable + 1
+	}
+}
+
 func (m *Model) newReqCnt() int {
-	m.reqCnt++
-	return m.reqCnt
+	return int(m.reqCnt.Add(1))
 }
 
 // TODO: Maybe make sure that there isn't any existing process with this UUID
@@ -1,6 +1,7 @@
 package processbar
 
 import (
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -30,3 +31,27 @@ func TestModelUtils(t *testing.T) {
 	m.renderIndex = 1
 	assert.False(t, m.isValid())
 }
+
+func TestNewReqCntConcurrent(t *testing.T) {
+	m := NewModelWithOptions(14, 10)
+	// A copy, like the one the workers use while the bubbletea goroutine updates the model
+	worker := m
+	const cnt = 100
+	ids := make(chan int, 2*cnt)
+	var wg sync.WaitGroup
+	for _, model := range []*Model{&m, &worker} {
+		wg.Go(func() {
+			for range cnt {
+				ids <- model.newReqCnt()
+			}
+		})
+	}
+	wg.Wait()
+	close(ids)
+	seen := make(map[int]bool)
+	for id := range ids {
+		assert.False(t, seen[id], "id %d was given twice", id)
+		seen[id] = true
+	}
+	assert.Len(t, seen, 2*cnt)
+}
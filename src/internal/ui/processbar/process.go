@@ -1,6 +1,7 @@
 package processbar
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/charmbracelet/bubbles/progress"
@@ -18,20 +19,111 @@ type Process struct {
 	State    ProcessState
 	Total    int
 	Done     int
-	DoneTime time.Time
+	// Byte level progress, for processes that track it. Progress is based on the
+	// bytes if TotalBytes is non zero, and on the file count otherwise.
+	TotalBytes int64
+	DoneBytes  int64
+	// Set while Total and TotalBytes are still being computed in the background
+	TotalPending bool
+	// Problems that didn't make the process fail, like attributes that could not be preserved
+	Warnings []string
+	// Items the process reads, and where it writes them, kept in the operation history
+	Sources     []string
+	Destination string
+	// Why the process failed, if it did
+	ErrorMsg string
+	// Items that failed, with their error. If the process stopped on a failure, the items
+	// it didn't get to are kept in Remaining.
+	Failures  []ItemFailure
+	Remaining []string
+	StartTime time.Time
+	DoneTime  time.Time
+}
+
+// ItemFailure is an item that a process failed to handle
+type ItemFailure struct {
+	Item  string
+	Error string
+	// Where the item was being written when it failed, for the processes that write
+	// items, like pastes. Empty if nothing was written.
+	Dst string
 }
 
 func NewProcess(id string, name string, total int) Process {
 	prog := progress.New(common.GenerateGradientColor())
 	prog.PercentageStyle = common.FooterStyle
 	return Process{
-		ID:       id,
-		Name:     name,
-		Progress: prog,
-		State:    InOperation,
-		Total:    total,
-		Done:     0,
+		ID:        id,
+		Name:      name,
+		Progress:  prog,
+		State:     InOperation,
+		Total:     total,
+		Done:      0,
+		StartTime: time.Now(),
+	}
+}
+
+// Rank of the process in the process bar : running, then queued, then finished
+func (p Process) sortRank() int {
+	switch p.State {
+	case InOperation:
+		return 0
+	case Queued:
+		return 1
+	case Successful, Cancelled, Failed:
+	}
+	return 2
+}
+
+// Completion ratio, between 0 and 1
+func (p Process) progressRatio() float64 {
+	switch {
+	case p.TotalBytes > 0:
+		return min(1, float64(p.DoneBytes)/float64(p.TotalBytes))
+	case p.Total != 0:
+		return min(1, float64(p.Done)/float64(p.Total))
+	case p.TotalPending:
+		return 0
+	default:
+		// if the total is 0, that means the process only have directory
+		// so we can set the progress to 100%
+		return 1
+	}
+}
+
+// transferInfo returns the transfer speed and the estimated time left, like
+// "1.20 MiB/s, 2m5s left". Returns an empty string if the process doesn't track
+// bytes or isn't running.
+func (p Process) transferInfo(now time.Time) string {
+	elapsed := now.Sub(p.StartTime)
+	if p.State != InOperation || p.DoneBytes <= 0 || elapsed <= 0 {
+		return ""
+	}
+	speed := float64(p.DoneBytes) / elapsed.Seconds()
+	res := common.FormatFileSize(int64(speed)) + "/s"
+	if p.TotalBytes > p.DoneBytes && !p.TotalPending {
+		eta := time.Duration(float64(p.TotalBytes-p.DoneBytes) / speed * float64(time.Second))
+		res += ", " + eta.Round(time.Second).String() + " left"
+	}
+	return res
+}
+
+// statusInfo returns the extra info shown next to the name : the transfer info of a
+// running process, or the count of failed items or warnings of a finished one.
+func (p Process) statusInfo(now time.Time) string {
+	if p.State == Queued {
+		return queuedText
+	}
+	if p.State == Failed && len(p.Failures) > 0 {
+		return strconv.Itoa(len(p.Failures)) + " failed"
+	}
+	if p.State == InOperation || len(p.Warnings) == 0 {
+		return p.transferInfo(now)
 	}
+	if len(p.Warnings) == 1 {
+		return "1 warning"
+	}
+	return strconv.Itoa(len(p.Warnings)) + " warnings"
 }
 
 type ProcessState int
@@ -41,8 +133,31 @@ const (
 	Successful
 	Cancelled
 	Failed
+	// Waiting for other processes on the same device to finish
+	Queued
 )
 
+// IsFinished reports whether a process in this state won't change anymore
+func (p ProcessState) IsFinished() bool {
+	return p != InOperation && p != Queued
+}
+
+func (p ProcessState) String() string {
+	switch p {
+	case InOperation:
+		return "in operation"
+	case Successful:
+		return "successful"
+	case Cancelled:
+		return "cancelled"
+	case Failed:
+		return "failed"
+	case Queued:
+		return "queued"
+	}
+	return "unknown"
+}
+
 // TODO : Should we store in a global map for efficiency ? At least need to prerender
 // Yes, this is a Render() call, which is expensive
 func (p ProcessState) Icon() string {
@@ -53,6 +168,8 @@ func (p ProcessState) Icon() string {
 		return common.ProcessSuccessfulStyle.Render(icon.Done)
 	case InOperation:
 		return common.ProcessInOperationStyle.Render(icon.InOperation)
+	case Queued:
+		return common.ProcessInOperationStyle.Render(icon.Queued)
 	case Cancelled:
 		fallthrough
 	default:
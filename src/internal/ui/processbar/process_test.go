@@ -0,0 +1,64 @@
+package processbar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessProgressRatio(t *testing.T) {
+	testdata := []struct {
+		name     string
+		p        Process
+		expected float64
+	}{
+		{name: "Files only", p: Process{Total: 4, Done: 1}, expected: 0.25},
+		{name: "Bytes preferred", p: Process{Total: 4, Done: 1, TotalBytes: 100, DoneBytes: 50}, expected: 0.5},
+		{name: "Only directories", p: Process{}, expected: 1},
+		{name: "Total not counted yet", p: Process{TotalPending: true, Done: 3}, expected: 0},
+		{name: "Done more than total", p: Process{Total: 1, Done: 2}, expected: 1},
+	}
+	for _, tt := range testdata {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.expected, tt.p.progressRatio(), 0.0001)
+		})
+	}
+}
+
+func TestProcessTransferInfo(t *testing.T) {
+	start := time.Now()
+	p := Process{
+		State:      InOperation,
+		StartTime:  start,
+		TotalBytes: 3000,
+		DoneBytes:  1000,
+	}
+	now := start.Add(10 * time.Second)
+	// 100 B/s, and 2000 bytes left
+	assert.Equal(t, "100.00 B/s, 20s left", p.transferInfo(now))
+
+	p.TotalPending = true
+	assert.Equal(t, "100.00 B/s", p.transferInfo(now), "No ETA without the total")
+
+	p.State = Successful
+	assert.Empty(t, p.transferInfo(now))
+
+	p = Process{State: InOperation, StartTime: start, Total: 3, Done: 1}
+	assert.Empty(t, p.transferInfo(now), "Processes without bytes have no speed")
+}
+
+func TestProcessStatusInfo(t *testing.T) {
+	now := time.Now()
+	p := Process{State: Successful, Warnings: []string{"a", "b"}}
+	assert.Equal(t, "2 warnings", p.statusInfo(now))
+
+	p.Warnings = p.Warnings[:1]
+	assert.Equal(t, "1 warning", p.statusInfo(now))
+
+	p.Warnings = nil
+	assert.Empty(t, p.statusInfo(now))
+
+	p = Process{State: Failed, Failures: []ItemFailure{{Item: "/a", Error: "denied"}}, Warnings: []string{"a"}}
+	assert.Equal(t, "1 failed", p.statusInfo(now), "Failures are shown before warnings")
+}
@@ -1,5 +1,13 @@
 package processbar
 
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/yorukot/superfile/src/internal/common"
+)
+
 type Cmd func() UpdateMsg
 
 type UpdateMsg interface {
@@ -20,10 +28,25 @@ type newProcessMsg struct {
 	BaseMsg
 
 	NewProcess Process
+	// Only set for cancellable processes
+	cancel context.CancelFunc
 }
 
 func (msg newProcessMsg) Apply(m *Model) (Cmd, error) {
-	return m.GetListenCmd(), m.AddProcess(msg.NewProcess)
+	err := m.AddProcess(msg.NewProcess)
+	if err == nil && msg.cancel != nil {
+		m.setCancelFunc(msg.NewProcess.ID, msg.cancel)
+	}
+	return m.GetListenCmd(), err
+}
+
+// FinishedProcess returns the process of msg, if msg is the final update of a process
+func FinishedProcess(msg UpdateMsg) (Process, bool) {
+	update, ok := msg.(updateProcessMsg)
+	if !ok || !update.NewProcess.State.IsFinished() {
+		return Process{}, false
+	}
+	return update.NewProcess, true
 }
 
 type updateProcessMsg struct {
@@ -33,7 +56,62 @@ type updateProcessMsg struct {
 }
 
 func (msg updateProcessMsg) Apply(m *Model) (Cmd, error) {
-	return m.GetListenCmd(), m.UpdateExistingProcess(msg.NewProcess)
+	prev, wasAdded := m.processes[msg.NewProcess.ID]
+	err := m.UpdateExistingProcess(msg.NewProcess)
+	if err == nil {
+		m.releaseCancelFunc(msg.NewProcess)
+		if wasAdded && !prev.State.IsFinished() && msg.NewProcess.State.IsFinished() {
+			m.scheduleExpiry()
+		}
+	}
+	return m.GetListenCmd(), err
+}
+
+// scheduleExpiry removes the finished processes from the process bar once they are older
+// than common.Config.FinishedProcessTTL, via an expireProcessesMsg
+func (m *Model) scheduleExpiry() {
+	ttl := time.Duration(common.Config.FinishedProcessTTL) * time.Second
+	if ttl <= 0 {
+		return
+	}
+	msgChan := m.msgChan
+	msg := expireProcessesMsg{ttl: ttl, BaseMsg: BaseMsg{reqID: m.newReqCnt()}}
+	time.AfterFunc(ttl, func() {
+		select {
+		case msgChan <- msg:
+		default:
+			// The process will be removed with the ones that finish after it
+			slog.Debug("Process channel full, could not send expiry", "reqID", msg.GetReqID())
+		}
+	})
+}
+
+type expireProcessesMsg struct {
+	BaseMsg
+
+	ttl time.Duration
+}
+
+func (msg expireProcessesMsg) Apply(m *Model) (Cmd, error) {
+	m.removeExpiredProcesses(msg.ttl, time.Now())
+	return m.GetListenCmd(), nil
+}
+
+type removeProcessMsg struct {
+	BaseMsg
+
+	id string
+}
+
+func (msg removeProcessMsg) Apply(m *Model) (Cmd, error) {
+	if cancel, ok := m.cancelFuncs[msg.id]; ok {
+		cancel()
+		delete(m.cancelFuncs, msg.id)
+	}
+	m.removeProcesses(func(p Process) bool {
+		return p.ID == msg.id
+	})
+	return m.GetListenCmd(), nil
 }
 
 // Construction will be options UpdateName(), UpdateDone(), etc..
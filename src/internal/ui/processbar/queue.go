@@ -0,0 +1,151 @@
+package processbar
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/yorukot/superfile/src/internal/common"
+)
+
+// queue lets at most common.Config.OperationsPerDevice processes with the same key, like
+// the ones writing to the same device, run at a time. The others wait in the order of the
+// queue, which the user can change. It is shared by the workers and the bubbletea
+// goroutine, so it is guarded by a mutex, unlike the rest of the Model.
+type queue struct {
+	mu sync.Mutex
+	// Key of the running processes, by Process.ID
+	running map[string]string
+	// Count of running processes, by key
+	runningCnt map[string]int
+	// Waiting processes, by key, the first one starts first
+	waiting map[string][]queuedProcess
+}
+
+type queuedProcess struct {
+	id string
+	// Closed once the process can start
+	start chan struct{}
+}
+
+func newQueue() *queue {
+	return &queue{
+		running:    make(map[string]string),
+		runningCnt: make(map[string]int),
+		waiting:    make(map[string][]queuedProcess),
+	}
+}
+
+// acquire makes the process id run, or wait behind the other processes of key. It returns
+// a channel closed once the process can start, or nil if it can start right away.
+func (q *queue) acquire(id string, key string) <-chan struct{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.waiting[key]) == 0 && q.hasFreeSlot(key) {
+		q.running[id] = key
+		q.runningCnt[key]++
+		return nil
+	}
+	qp := queuedProcess{id: id, start: make(chan struct{})}
+	q.waiting[key] = append(q.waiting[key], qp)
+	return qp.start
+}
+
+// release frees the slot of the process id once it is finished, and starts the next
+// waiting processes. It does nothing if the process isn't running.
+func (q *queue) release(id string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	key, ok := q.running[id]
+	if !ok {
+		return
+	}
+	delete(q.running, id)
+	q.runningCnt[key]--
+	if q.runningCnt[key] <= 0 {
+		delete(q.runningCnt, key)
+	}
+	for len(q.waiting[key]) > 0 && q.hasFreeSlot(key) {
+		q.startLocked(key, 0)
+	}
+}
+
+// leave removes the process id from the queue, when it is cancelled while waiting. It
+// may have been started in the meantime, its slot is released then.
+func (q *queue) leave(id string) {
+	q.mu.Lock()
+	key, idx, ok := q.findLocked(id)
+	if ok {
+		q.waiting[key] = slices.Delete(q.waiting[key], idx, idx+1)
+		if len(q.waiting[key]) == 0 {
+			delete(q.waiting, key)
+		}
+	}
+	q.mu.Unlock()
+	if !ok {
+		q.release(id)
+	}
+}
+
+// startNow starts the waiting process id, even if it goes over the limit
+func (q *queue) startNow(id string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	key, idx, ok := q.findLocked(id)
+	if ok {
+		q.startLocked(key, idx)
+	}
+	return ok
+}
+
+// move moves the waiting process id by delta places in its queue. It returns false if the
+// process isn't waiting, or is already first or last.
+func (q *queue) move(id string, delta int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	key, idx, ok := q.findLocked(id)
+	newIdx := idx + delta
+	if !ok || newIdx < 0 || newIdx >= len(q.waiting[key]) {
+		return false
+	}
+	waiting := q.waiting[key]
+	waiting[idx], waiting[newIdx] = waiting[newIdx], waiting[idx]
+	return true
+}
+
+// positions returns the place of each waiting process in its queue, by Process.ID
+func (q *queue) positions() map[string]int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	res := make(map[string]int)
+	for _, waiting := range q.waiting {
+		for i, qp := range waiting {
+			res[qp.id] = i
+		}
+	}
+	return res
+}
+
+func (q *queue) hasFreeSlot(key string) bool {
+	limit := common.Config.OperationsPerDevice
+	return limit <= 0 || q.runningCnt[key] < limit
+}
+
+func (q *queue) findLocked(id string) (string, int, bool) {
+	for key, waiting := range q.waiting {
+		if idx := slices.IndexFunc(waiting, func(qp queuedProcess) bool { return qp.id == id }); idx >= 0 {
+			return key, idx, true
+		}
+	}
+	return "", 0, false
+}
+
+func (q *queue) startLocked(key string, idx int) {
+	qp := q.waiting[key][idx]
+	q.waiting[key] = slices.Delete(q.waiting[key], idx, idx+1)
+	if len(q.waiting[key]) == 0 {
+		delete(q.waiting, key)
+	}
+	q.running[qp.id] = key
+	q.runningCnt[key]++
+	close(qp.start)
+}
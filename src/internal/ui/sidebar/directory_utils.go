@@ -2,10 +2,12 @@ package sidebar
 
 import (
 	"os"
+	"runtime"
 	"slices"
 
 	"github.com/adrg/xdg"
 
+	variable "github.com/yorukot/superfile/src/config"
 	"github.com/yorukot/superfile/src/config/icon"
 	"github.com/yorukot/superfile/src/internal/utils"
 )
@@ -53,6 +55,11 @@ func getWellKnownDirectories() []directory {
 		{Location: xdg.UserDirs.Templates, Name: icon.Templates + icon.Space + "Templates"},
 		{Location: xdg.UserDirs.PublicShare, Name: icon.PublicShare + icon.Space + "PublicShare"},
 	}
+	// Only the freedesktop.org trash has the info needed by the trash view
+	if runtime.GOOS == utils.OsLinux {
+		wellKnownDirectories = append(wellKnownDirectories,
+			directory{Location: variable.LinuxTrashDirectoryFiles, Name: icon.Trash + icon.Space + "Trash"})
+	}
 
 	return slices.DeleteFunc(wellKnownDirectories, func(d directory) bool {
 		_, err := os.Stat(d.Location)
@@ -91,6 +91,30 @@ func ZoxideRenderer(totalHeight int, totalWidth int) *rendering.Renderer {
 	return PromptRenderer(totalHeight, totalWidth)
 }
 
+func BatchRenameRenderer(totalHeight int, totalWidth int) *rendering.Renderer {
+	return PromptRenderer(totalHeight, totalWidth)
+}
+
+func PermissionsRenderer(totalHeight int, totalWidth int) *rendering.Renderer {
+	return PromptRenderer(totalHeight, totalWidth)
+}
+
+func PasswordRenderer(totalHeight int, totalWidth int) *rendering.Renderer {
+	return PromptRenderer(totalHeight, totalWidth)
+}
+
+func CompressRenderer(totalHeight int, totalWidth int) *rendering.Renderer {
+	return PromptRenderer(totalHeight, totalWidth)
+}
+
+func HistoryRenderer(totalHeight int, totalWidth int) *rendering.Renderer {
+	return PromptRenderer(totalHeight, totalWidth)
+}
+
+func FailuresRenderer(totalHeight int, totalWidth int) *rendering.Renderer {
+	return PromptRenderer(totalHeight, totalWidth)
+}
+
 func HelpMenuRenderer(totalHeight int, totalWidth int) *rendering.Renderer {
 	cfg := rendering.DefaultRendererConfig(totalHeight, totalWidth)
 	cfg.ContentFGColor = common.ModalFGColor
@@ -7,6 +7,7 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
 	"reflect"
 	"strings"
@@ -221,6 +222,36 @@ func DirSize(path string) int64 {
 	return size
 }
 
+// ParseExcludePatterns splits a list of glob patterns separated by commas or spaces,
+// like ".git, node_modules *.log", and checks that they are valid
+func ParseExcludePatterns(list string) ([]string, error) {
+	patterns := strings.FieldsFunc(list, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return patterns, nil
+}
+
+// MatchesExcludePattern reports whether the item at relPath, a slash separated path, is
+// matched by one of patterns. Patterns without a slash are matched against the name of
+// the item, like ".git" or "*.log", and the others against the whole of relPath.
+func MatchesExcludePattern(relPath string, patterns []string) bool {
+	for _, pattern := range patterns {
+		target := path.Base(relPath)
+		if strings.Contains(pattern, "/") {
+			target = relPath
+		}
+		if matched, _ := path.Match(pattern, target); matched {
+			return true
+		}
+	}
+	return false
+}
+
 // Helper functions
 // Create all dirs that does not already exists
 func CreateDirectories(dirs ...string) error {
@@ -394,3 +394,28 @@ func TestReadFileContentBOMHandling(t *testing.T) {
 	assert.NotContains(t, result, "\uFEFF",
 		"BOM character should be removed from output: %q", result)
 }
+
+func TestExcludePatterns(t *testing.T) {
+	patterns, err := ParseExcludePatterns(".git, node_modules  *.log,docs/*.tmp")
+	require.NoError(t, err)
+	assert.Equal(t, []string{".git", "node_modules", "*.log", "docs/*.tmp"}, patterns)
+
+	testdata := []struct {
+		relPath  string
+		expected bool
+	}{
+		{relPath: "src/.git", expected: true},
+		{relPath: "src/web/node_modules", expected: true},
+		{relPath: "src/debug.log", expected: true},
+		{relPath: "docs/draft.tmp", expected: true},
+		{relPath: "src/docs/draft.tmp", expected: false},
+		{relPath: "src/.gitignore", expected: false},
+		{relPath: "src/main.go", expected: false},
+	}
+	for _, tt := range testdata {
+		assert.Equal(t, tt.expected, MatchesExcludePattern(tt.relPath, patterns), tt.relPath)
+	}
+
+	_, err = ParseExcludePatterns("[a-")
+	require.Error(t, err)
+}